@@ -0,0 +1,59 @@
+package config
+
+import "testing"
+
+func TestLoadKeepsPaginationSettingsUsable(t *testing.T) {
+	tests := []struct {
+		defaultSize, maxSize string
+		wantDefault, wantMax int
+	}{
+		{"10", "40", 10, 40},
+		{"80", "40", 40, 40},
+		{"0", "40", 40, 40},
+		{"10", "0", 10, 50},
+	}
+	for _, tt := range tests {
+		t.Setenv("DEFAULT_PAGE_SIZE", tt.defaultSize)
+		t.Setenv("MAX_PAGE_SIZE", tt.maxSize)
+		
+		cfg := Load()
+		if cfg.DefaultPageSize != tt.wantDefault || cfg.MaxPageSize != tt.wantMax {
+			t.Errorf("DEFAULT_PAGE_SIZE=%s MAX_PAGE_SIZE=%s: got %d and %d, want %d and %d", tt.defaultSize, tt.maxSize,
+				cfg.DefaultPageSize, cfg.MaxPageSize, tt.wantDefault, tt.wantMax)
+		}
+	}
+}
+
+func TestLoadReadsFeatureFlags(t *testing.T) {
+	t.Setenv("FEATURE_COMMENTS", "false")
+	t.Setenv("FEATURE_LIKES", "")
+	
+	cfg := Load()
+	if cfg.FeatureEnabled(FeatureComments) {
+		t.Error("expected comments to be disabled")
+	}
+	if !cfg.FeatureEnabled(FeatureLikes) {
+		t.Error("expected features to be enabled by default")
+	}
+}
+
+func TestLoadKeepsMaxRecipePriceStorable(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"250.50", 250.50},
+		{"99999999.99", 99999999.99},
+		{"100000000", 10000},
+		{"0", 10000},
+		{"-5", 10000},
+		{"lots", 10000},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAX_RECIPE_PRICE", tt.value)
+		
+		if got := Load().MaxRecipePrice; got != tt.want {
+			t.Errorf("MAX_RECIPE_PRICE=%s: got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// activityFeedSQL merges a user's recipes, comments, ratings and likes into
+// one timeline. Actions on recipes that have since been deleted are left out.
+const activityFeedSQL = `
+	SELECT 'recipe_created' AS type, r.id AS item_id, r.id AS recipe_id, r.title AS recipe_title,
+		NULL::text AS content, NULL::int AS rating, r.created_at AS occurred_at
+	FROM recipes r
+	WHERE r.user_id = @user AND r.deleted_at IS NULL
+	UNION ALL
+	SELECT 'comment_posted', c.id, r.id, r.title, c.content, NULL, c.created_at
+	FROM comments c JOIN recipes r ON r.id = c.recipe_id
+	WHERE c.user_id = @user AND c.deleted_at IS NULL AND r.deleted_at IS NULL
+	UNION ALL
+	SELECT 'recipe_rated', ra.id, r.id, r.title, NULL, ra.rating, ra.created_at
+	FROM ratings ra JOIN recipes r ON r.id = ra.recipe_id
+	WHERE ra.user_id = @user AND ra.deleted_at IS NULL AND r.deleted_at IS NULL
+	UNION ALL
+	SELECT 'recipe_liked', l.id, r.id, r.title, NULL, NULL, l.created_at
+	FROM likes l JOIN recipes r ON r.id = l.recipe_id
+	WHERE l.user_id = @user AND l.deleted_at IS NULL AND r.deleted_at IS NULL`
+
+// activityItem is one entry in a user's activity feed. Content is set for
+// comments and Rating for ratings.
+type activityItem struct {
+	Type        string    `json:"type"`
+	ItemID      string    `json:"item_id"`
+	RecipeID    string    `json:"recipe_id"`
+	RecipeTitle string    `json:"recipe_title"`
+	Content     *string   `json:"content,omitempty"`
+	Rating      *int      `json:"rating,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// GetMyActivity returns the caller's recent actions, newest first.
+func (h *RecipeHandler) GetMyActivity(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	
+	args := map[string]interface{}{
+		"user":   userID,
+		"limit":  limit,
+		"offset": (page - 1) * limit,
+	}
+	
+	var total int64
+	if err := h.db(c).Raw("SELECT COUNT(*) FROM ("+activityFeedSQL+") AS feed", args).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	
+	// The item ID breaks ties so pages don't overlap
+	items := []activityItem{}
+	if err := h.db(c).Raw("SELECT * FROM ("+activityFeedSQL+") AS feed "+
+		"ORDER BY occurred_at DESC, item_id DESC LIMIT @limit OFFSET @offset", args).
+		Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, newPaginatedResponse(items, total, page, limit))
+}
\ No newline at end of file
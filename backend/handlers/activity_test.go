@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+)
+
+func TestActivityFeedIsMergedAndScopedToTheUser(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	
+	hoursAgo := func(hours int) time.Time { return time.Now().Add(-time.Duration(hours) * time.Hour) }
+	own := createRecipe(t, db, user, category, func(r *models.Recipe) { r.CreatedAt = hoursAgo(5) })
+	theirs := createRecipe(t, db, other, category, func(r *models.Recipe) { r.CreatedAt = hoursAgo(6) })
+	removed := createRecipe(t, db, other, category, nil)
+	
+	comment := models.Comment{UserID: user.ID, RecipeID: theirs.ID, Content: "Lovely", CreatedAt: hoursAgo(3)}
+	rating := models.Rating{UserID: user.ID, RecipeID: theirs.ID, Rating: 4, CreatedAt: hoursAgo(2)}
+	like := models.Like{UserID: user.ID, RecipeID: theirs.ID, CreatedAt: hoursAgo(1)}
+	for _, row := range []interface{}{
+		&comment, &rating, &like,
+		&models.Like{UserID: user.ID, RecipeID: removed.ID},
+		&models.Comment{UserID: other.ID, RecipeID: own.ID, Content: "Not mine"},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete(&removed).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	feed := func(query string) PaginatedResponse[activityItem] {
+		t.Helper()
+		w := serve(h.GetMyActivity, "GET", "/me/activity", "/me/activity"+query, user.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var page PaginatedResponse[activityItem]
+		decode(t, w, &page)
+		return page
+	}
+	items := func(page PaginatedResponse[activityItem]) []string {
+		ids := make([]string, len(page.Data))
+		for i, item := range page.Data {
+			ids[i] = item.Type + ":" + item.ItemID
+		}
+		return ids
+	}
+	
+	all := feed("")
+	want := []string{
+		"recipe_liked:" + like.ID,
+		"recipe_rated:" + rating.ID,
+		"comment_posted:" + comment.ID,
+		"recipe_created:" + own.ID,
+	}
+	if got := items(all); !slices.Equal(got, want) || all.Total != 4 {
+		t.Fatalf("expected %v, got %v of %d", want, got, all.Total)
+	}
+	if item := all.Data[1]; item.Rating == nil || *item.Rating != 4 || item.RecipeTitle != theirs.Title {
+		t.Errorf("expected the rating and recipe title, got %+v", item)
+	}
+	if item := all.Data[2]; item.Content == nil || *item.Content != "Lovely" {
+		t.Errorf("expected the comment text, got %+v", item)
+	}
+	
+	if got := items(feed("?page=2&limit=2")); !slices.Equal(got, want[2:]) {
+		t.Errorf("expected the second page to hold %v, got %v", want[2:], got)
+	}
+	
+	w := serve(h.GetMyActivity, "GET", "/me/activity", "/me/activity", "", nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
\ No newline at end of file
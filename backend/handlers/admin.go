@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/middleware"
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDuplicatePairs bounds the similar-title pairs considered per request.
+const maxDuplicatePairs = 500
+
+type AdminHandler struct {
+	DB          *gorm.DB
+	Config      *config.Config
+	Maintenance *middleware.Maintenance
+}
+
+func NewAdminHandler(db *gorm.DB, cfg *config.Config, maintenance *middleware.Maintenance) *AdminHandler {
+	return &AdminHandler{DB: db, Config: cfg, Maintenance: maintenance}
+}
+
+// db returns the handler's database bound to the request context.
+func (h *AdminHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+func (h *AdminHandler) UnpublishRecipe(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	h.setRecipePublished(c, false, "unpublish", input.Reason)
+}
+
+func (h *AdminHandler) RepublishRecipe(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	
+	// The reason is optional when restoring a recipe
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	
+	h.setRecipePublished(c, true, "republish", input.Reason)
+}
+
+// setRecipePublished flips IsPublished regardless of ownership and records the
+// action in the moderation audit log. A takedown also sets ModerationHidden,
+// which keeps the author from publishing the recipe again until it is
+// republished here.
+func (h *AdminHandler) setRecipePublished(c *gin.Context, published bool, action, reason string) {
+	recipeID := c.Param("id")
+	
+	var recipe models.Recipe
+	if err := h.db(c).First(&recipe, "id = ?", recipeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	entry := models.ModerationLog{
+		RecipeID: recipe.ID,
+		AdminID:  c.GetString("user_id"),
+		Action:   action,
+		Reason:   reason,
+	}
+	
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		// Moderation overrides whatever the author had scheduled
+		changes := map[string]interface{}{"is_published": published, "publish_at": nil, "moderation_hidden": !published}
+		if !published {
+			// An unpublished recipe shouldn't hold one of the featured slots
+			changes["is_featured"] = false
+			changes["featured_rank"] = nil
+		}
+		if err := tx.Model(&recipe).Updates(changes).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipe_id":    recipe.ID,
+		"is_published": published,
+		"log":          entry,
+	})
+}
+
+// FeatureRecipe adds a published recipe to the curated featured list. The
+// optional rank orders the list; without one the recipe goes to the end.
+func (h *AdminHandler) FeatureRecipe(c *gin.Context) {
+	var input struct {
+		Rank *int `json:"rank" binding:"omitempty,min=1"`
+	}
+	
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	
+	var recipe models.Recipe
+	if err := h.db(c).First(&recipe, "id = ? AND is_published = ?", c.Param("id"), true).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	h.setRecipeFeatured(c, &recipe, true, input.Rank)
+}
+
+// UnfeatureRecipe removes a recipe from the featured list.
+func (h *AdminHandler) UnfeatureRecipe(c *gin.Context) {
+	var recipe models.Recipe
+	if err := h.db(c).First(&recipe, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	h.setRecipeFeatured(c, &recipe, false, nil)
+}
+
+// setRecipeFeatured features the recipe at rank, or unfeatures it, and records
+// the change in the moderation log. Featuring without a rank keeps the recipe's
+// current rank or puts it at the end of the list.
+func (h *AdminHandler) setRecipeFeatured(c *gin.Context, recipe *models.Recipe, featured bool, rank *int) {
+	entry := models.ModerationLog{
+		RecipeID: recipe.ID,
+		AdminID:  c.GetString("user_id"),
+		Action:   "unfeature",
+	}
+	if featured {
+		entry.Action = "feature"
+	}
+	
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if featured {
+			var err error
+			if rank, err = featuredRank(tx, recipe.ID, rank, h.Config.MaxFeatured); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(recipe).Updates(map[string]interface{}{
+			"is_featured":   featured,
+			"featured_rank": rank,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entry).Error
+	})
+	if errors.Is(err, errFeaturedFull) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("At most %d recipes can be featured, unfeature one first", h.Config.MaxFeatured)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipe_id":     recipe.ID,
+		"is_featured":   featured,
+		"featured_rank": rank,
+		"log":           entry,
+	})
+}
+
+// featuredListLock is the advisory lock key taken while the featured list
+// changes, "feat" in ASCII.
+const featuredListLock = 0x66656174
+
+var errFeaturedFull = errors.New("featured list is full")
+
+// featuredRank checks that the recipe fits on the featured list and returns
+// the rank to feature it at. Concurrent calls take turns on featuredListLock
+// until their transaction ends, so they can't overrun maxFeatured or share the
+// last rank.
+func featuredRank(tx *gorm.DB, recipeID string, rank *int, maxFeatured int) (*int, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", featuredListLock).Error; err != nil {
+		return nil, err
+	}
+	
+	var current struct {
+		IsFeatured   bool
+		FeaturedRank *int
+	}
+	if err := tx.Model(&models.Recipe{}).Select("is_featured", "featured_rank").Where("id = ?", recipeID).
+		Scan(&current).Error; err != nil {
+		return nil, err
+	}
+	
+	// Featuring again only moves a recipe and doesn't count against the limit
+	if !current.IsFeatured {
+		var featured int64
+		if err := tx.Model(&models.Recipe{}).Where("is_featured = ?", true).Count(&featured).Error; err != nil {
+			return nil, err
+		}
+		if int(featured) >= maxFeatured {
+			return nil, errFeaturedFull
+		}
+	}
+	
+	if rank != nil {
+		return rank, nil
+	}
+	if current.FeaturedRank != nil {
+		return current.FeaturedRank, nil
+	}
+	var last int
+	if err := tx.Model(&models.Recipe{}).Where("is_featured = ?", true).Select("COALESCE(MAX(featured_rank), 0)").
+		Scan(&last).Error; err != nil {
+		return nil, err
+	}
+	next := last + 1
+	return &next, nil
+}
+
+// duplicatePair is two recipes whose titles are similar enough to review.
+type duplicatePair struct {
+	AID   string
+	BID   string
+	Score float64
+}
+
+// DuplicateRecipe is a recipe listed in a duplicate cluster.
+type DuplicateRecipe struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	UserID      string    `json:"user_id"`
+	IsPublished bool      `json:"is_published"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FindDuplicateRecipes groups recipes with near-identical titles so moderators
+// can spot spam. It uses pg_trgm similarity when the extension is installed and
+// falls back to case-insensitive exact title matches otherwise.
+func (h *AdminHandler) FindDuplicateRecipes(c *gin.Context) {
+	threshold := 0.6
+	if raw := c.Query("threshold"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be a number between 0 and 1"})
+			return
+		}
+		threshold = parsed
+	}
+	
+	var trigram bool
+	h.db(c).Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&trigram)
+	
+	// One pair more than the limit is fetched to tell whether the list was cut
+	method := "exact"
+	var pairs []duplicatePair
+	var err error
+	if trigram {
+		method = "trigram"
+		err = h.db(c).Transaction(func(tx *gorm.DB) error {
+			// The % operator can use the trigram index on titles, unlike a
+			// similarity() comparison. Its threshold is set for this transaction only.
+			if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(threshold, 'f', -1, 64)).Error; err != nil {
+				return err
+			}
+			return tx.Raw(`SELECT a.id AS a_id, b.id AS b_id, similarity(a.title, b.title) AS score
+				FROM recipes a
+				JOIN recipes b ON a.title % b.title AND a.id < b.id
+				WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+				ORDER BY score DESC
+				LIMIT ?`, maxDuplicatePairs+1).Scan(&pairs).Error
+		})
+	} else {
+		err = h.db(c).Raw(`SELECT a.id AS a_id, b.id AS b_id, 1.0 AS score
+			FROM recipes a
+			JOIN recipes b ON a.id < b.id
+			WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+			AND LOWER(TRIM(a.title)) = LOWER(TRIM(b.title))
+			LIMIT ?`, maxDuplicatePairs+1).Scan(&pairs).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search for duplicates"})
+		return
+	}
+	truncated := len(pairs) > maxDuplicatePairs
+	if truncated {
+		pairs = pairs[:maxDuplicatePairs]
+	}
+	
+	// Join pairs into clusters, so A~B and B~C end up in one group
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		if parent[id] == "" || parent[id] == id {
+			parent[id] = id
+			return id
+		}
+		parent[id] = find(parent[id])
+		return parent[id]
+	}
+	bestScore := make(map[string]float64)
+	for _, pair := range pairs {
+		parent[find(pair.AID)] = find(pair.BID)
+	}
+	for _, pair := range pairs {
+		root := find(pair.AID)
+		if pair.Score > bestScore[root] {
+			bestScore[root] = pair.Score
+		}
+	}
+	
+	ids := make([]string, 0, len(parent))
+	for id := range parent {
+		ids = append(ids, id)
+	}
+	
+	var recipes []DuplicateRecipe
+	if len(ids) > 0 {
+		if err := h.db(c).Model(&models.Recipe{}).Where("id IN ?", ids).
+			Order("created_at ASC").Find(&recipes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicate recipes"})
+			return
+		}
+	}
+	
+	members := make(map[string][]DuplicateRecipe)
+	var roots []string
+	for _, recipe := range recipes {
+		root := find(recipe.ID)
+		if members[root] == nil {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], recipe)
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return bestScore[roots[i]] > bestScore[roots[j]] })
+	
+	clusters := make([]gin.H, 0, len(roots))
+	for _, root := range roots {
+		clusters = append(clusters, gin.H{
+			"similarity": bestScore[root],
+			"recipes":    members[root],
+		})
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"method":    method,
+		"threshold": threshold,
+		"clusters":  clusters,
+		// More pairs matched than maxDuplicatePairs, raise the threshold to see them all
+		"truncated": truncated,
+	})
+}
+
+// GetMaintenance reports whether maintenance mode is on.
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.Maintenance.Enabled()})
+}
+
+// SetMaintenance switches maintenance mode on or off for this server.
+func (h *AdminHandler) SetMaintenance(c *gin.Context) {
+	var input struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	h.Maintenance.SetEnabled(*input.Enabled)
+	state := "disabled"
+	if *input.Enabled {
+		state = "enabled"
+	}
+	log.Printf("Maintenance mode %s by admin %s", state, c.GetString("user_id"))
+	
+	c.JSON(http.StatusOK, gin.H{"enabled": *input.Enabled})
+}
\ No newline at end of file
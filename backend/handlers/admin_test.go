@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/middleware"
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// createAdmin stores a user with admin rights.
+func createAdmin(t *testing.T, db *gorm.DB) models.User {
+	t.Helper()
+	
+	admin := createUser(t, db)
+	if err := db.Model(&admin).Update("is_admin", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	return admin
+}
+
+func TestAdminTakedownUnpublishesAndLogs(t *testing.T) {
+	db := testDB(t)
+	h := NewAdminHandler(db, testConfig(), middleware.NewMaintenance(testConfig()))
+	unpublish := chain(middleware.AdminMiddleware(db), h.UnpublishRecipe)
+	admin := createAdmin(t, db)
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	target := "/admin/recipes/" + recipe.ID + "/unpublish"
+	
+	w := serve(unpublish, "POST", "/admin/recipes/:id/unpublish", target, author.ID, gin.H{"reason": "Mine"})
+	expectStatus(t, w, http.StatusForbidden)
+	w = serve(unpublish, "POST", "/admin/recipes/:id/unpublish", target, admin.ID, gin.H{})
+	expectStatus(t, w, http.StatusBadRequest)
+	
+	w = serve(unpublish, "POST", "/admin/recipes/:id/unpublish", target, admin.ID, gin.H{"reason": "Copied from a cookbook"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var reloaded models.Recipe
+	if err := db.First(&reloaded, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.IsPublished {
+		t.Error("expected the recipe to be unpublished")
+	}
+	
+	var entries []models.ModerationLog
+	if err := db.Where("recipe_id = ?", recipe.ID).Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+	if entries[0].AdminID != admin.ID || entries[0].Action != "unpublish" || entries[0].Reason != "Copied from a cookbook" {
+		t.Errorf("unexpected audit entry %+v", entries[0])
+	}
+}
+
+func TestFindDuplicateRecipesRejectsBadThresholds(t *testing.T) {
+	// The threshold is checked before the database is used
+	h := NewAdminHandler(nil, testConfig(), middleware.NewMaintenance(testConfig()))
+	for _, threshold := range []string{"0", "1.5", "high"} {
+		w := serve(h.FindDuplicateRecipes, "GET", "/admin/recipes/duplicates", "/admin/recipes/duplicates?threshold="+threshold, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("threshold %s: expected 400, got %d", threshold, w.Code)
+		}
+	}
+}
+
+func TestFindDuplicateRecipesClustersSimilarTitles(t *testing.T) {
+	db := testDB(t)
+	h := NewAdminHandler(db, testConfig(), middleware.NewMaintenance(testConfig()))
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	recipes := make(map[string]string)
+	for _, title := range []string{"Spicy Lentil Soup", "spicy lentil soup", "Spicy Lentil Soups", "Chocolate Cake"} {
+		recipe := createRecipe(t, db, author, category, func(r *models.Recipe) { r.Title = title })
+		recipes[recipe.ID] = title
+	}
+	
+	w := serve(h.FindDuplicateRecipes, "GET", "/admin/recipes/duplicates", "/admin/recipes/duplicates", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	var body struct {
+		Method   string `json:"method"`
+		Clusters []struct {
+			Similarity float64           `json:"similarity"`
+			Recipes    []DuplicateRecipe `json:"recipes"`
+		} `json:"clusters"`
+	}
+	decode(t, w, &body)
+	if len(body.Clusters) != 1 {
+		t.Fatalf("expected one cluster, got %+v", body.Clusters)
+	}
+	
+	// Without pg_trgm only the exact title match is found
+	want := []string{"Spicy Lentil Soup", "spicy lentil soup"}
+	if body.Method == "trigram" {
+		want = append(want, "Spicy Lentil Soups")
+	}
+	var got []string
+	for _, recipe := range body.Clusters[0].Recipes {
+		got = append(got, recipes[recipe.ID])
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: expected the cluster %v, got %v", body.Method, want, got)
+	}
+	if body.Clusters[0].Similarity < 0.6 {
+		t.Errorf("expected a similarity above the threshold, got %v", body.Clusters[0].Similarity)
+	}
+}
+
+func TestFeaturedRecipes(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.MaxFeatured = 3
+	h := NewAdminHandler(db, cfg, middleware.NewMaintenance(cfg))
+	recipes := NewRecipeHandler(db, cfg)
+	admin := createAdmin(t, db)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	
+	var ids []string
+	for i := 0; i < 4; i++ {
+		ids = append(ids, createRecipe(t, db, author, category, nil).ID)
+	}
+	draft := createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	
+	feature := func(id string, body interface{}) *httptest.ResponseRecorder {
+		return serve(h.FeatureRecipe, "POST", "/admin/recipes/:id/feature", "/admin/recipes/"+id+"/feature", admin.ID, body)
+	}
+	featured := func() []string {
+		t.Helper()
+		w := serve(recipes.GetFeaturedRecipes, "GET", "/recipes/featured", "/recipes/featured", "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var body struct {
+			Recipes []models.Recipe `json:"recipes"`
+		}
+		decode(t, w, &body)
+		var listed []string
+		for _, recipe := range body.Recipes {
+			listed = append(listed, recipe.ID)
+		}
+		return listed
+	}
+	
+	// Without a rank a recipe goes to the end of the list
+	expectStatus(t, feature(ids[0], nil), http.StatusOK)
+	expectStatus(t, feature(ids[1], nil), http.StatusOK)
+	expectStatus(t, feature(ids[2], gin.H{"rank": 1}), http.StatusOK)
+	expectStatus(t, feature(draft.ID, nil), http.StatusNotFound)
+	// ids[0] and ids[2] share rank 1, which the newer recipe wins
+	if got, want := featured(), []string{ids[2], ids[0], ids[1]}; !slices.Equal(got, want) {
+		t.Errorf("expected the featured order %v, got %v", want, got)
+	}
+	
+	expectStatus(t, feature(ids[3], nil), http.StatusConflict)
+	
+	// Featuring again only moves a recipe and doesn't count against the limit
+	expectStatus(t, feature(ids[1], gin.H{"rank": 0}), http.StatusBadRequest)
+	expectStatus(t, feature(ids[1], gin.H{"rank": 2}), http.StatusOK)
+	
+	w := serve(h.UnfeatureRecipe, "POST", "/admin/recipes/:id/unfeature", "/admin/recipes/"+ids[0]+"/unfeature", admin.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	expectStatus(t, feature(ids[3], nil), http.StatusOK)
+	if got, want := featured(), []string{ids[2], ids[1], ids[3]}; !slices.Equal(got, want) {
+		t.Errorf("expected the featured order %v, got %v", want, got)
+	}
+}
+
+func TestConcurrentFeaturingRespectsTheLimit(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.MaxFeatured = 3
+	h := NewAdminHandler(db, cfg, middleware.NewMaintenance(cfg))
+	admin := createAdmin(t, db)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	
+	var ids []string
+	for i := 0; i < 6; i++ {
+		ids = append(ids, createRecipe(t, db, author, category, nil).ID)
+	}
+	
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			serve(h.FeatureRecipe, "POST", "/admin/recipes/:id/feature", "/admin/recipes/"+id+"/feature", admin.ID, nil)
+		}(id)
+	}
+	wg.Wait()
+	
+	var featured []models.Recipe
+	if err := db.Where("is_featured = ?", true).Find(&featured).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(featured) != cfg.MaxFeatured {
+		t.Fatalf("expected %d featured recipes, got %d", cfg.MaxFeatured, len(featured))
+	}
+	ranks := make(map[int]bool)
+	for _, recipe := range featured {
+		if recipe.FeaturedRank == nil || ranks[*recipe.FeaturedRank] {
+			t.Errorf("expected distinct ranks, got %v for %s", recipe.FeaturedRank, recipe.ID)
+			continue
+		}
+		ranks[*recipe.FeaturedRank] = true
+	}
+}
+
+func TestAdminsToggleMaintenanceMode(t *testing.T) {
+	maintenance := middleware.NewMaintenance(testConfig())
+	h := NewAdminHandler(nil, testConfig(), maintenance)
+	
+	state := func() bool {
+		t.Helper()
+		w := serve(h.GetMaintenance, "GET", "/admin/maintenance", "/admin/maintenance", "admin", nil)
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Enabled bool `json:"enabled"`
+		}
+		decode(t, w, &response)
+		return response.Enabled
+	}
+	if state() {
+		t.Fatal("expected maintenance mode to start off")
+	}
+	
+	for _, enabled := range []bool{true, false} {
+		w := serve(h.SetMaintenance, "PUT", "/admin/maintenance", "/admin/maintenance", "admin", gin.H{"enabled": enabled})
+		expectStatus(t, w, http.StatusOK)
+		if maintenance.Enabled() != enabled || state() != enabled {
+			t.Errorf("expected maintenance mode to be %v", enabled)
+		}
+	}
+	
+	w := serve(h.SetMaintenance, "PUT", "/admin/maintenance", "/admin/maintenance", "admin", gin.H{})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestAuthorsCannotRepublishATakenDownRecipe(t *testing.T) {
+	db := testDB(t)
+	admin := NewAdminHandler(db, testConfig(), middleware.NewMaintenance(testConfig()))
+	recipes := NewRecipeHandler(db, testConfig())
+	moderator := createAdmin(t, db)
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	w := serve(admin.UnpublishRecipe, "POST", "/admin/recipes/:id/unpublish", "/admin/recipes/"+recipe.ID+"/unpublish",
+		moderator.ID, gin.H{"reason": "Copied from a cookbook"})
+	expectStatus(t, w, http.StatusOK)
+	
+	update := func(body gin.H) *httptest.ResponseRecorder {
+		return serve(recipes.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, body)
+	}
+	published := func() bool {
+		t.Helper()
+		var stored models.Recipe
+		if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return stored.IsPublished
+	}
+	
+	past := time.Now().Add(-time.Hour)
+	for _, body := range []gin.H{{"is_published": true}, {"publish_at": past}, {"publish_at": time.Now().Add(time.Hour)}} {
+		expectStatus(t, update(body), http.StatusForbidden)
+	}
+	// Other edits still go through
+	expectStatus(t, update(gin.H{"description": "Rewritten in my own words"}), http.StatusOK)
+	
+	// A schedule set before the takedown doesn't publish it either
+	if err := db.Model(&models.Recipe{}).Where("id = ?", recipe.ID).Update("publish_at", past.UTC()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PublishDueRecipes(db); err != nil {
+		t.Fatal(err)
+	}
+	if published() {
+		t.Fatal("expected the recipe to stay taken down")
+	}
+	
+	w = serve(admin.RepublishRecipe, "POST", "/admin/recipes/:id/republish", "/admin/recipes/"+recipe.ID+"/republish", moderator.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	expectStatus(t, update(gin.H{"is_published": false}), http.StatusOK)
+	expectStatus(t, update(gin.H{"is_published": true}), http.StatusOK)
+	if !published() {
+		t.Error("expected the author to publish the recipe again after it was republished")
+	}
+}
\ No newline at end of file
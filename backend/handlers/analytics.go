@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dailyStats holds the per-day activity of a recipe in the analytics response.
+type dailyStats struct {
+	Date      string `json:"date"`
+	Views     int64  `json:"views"`
+	Likes     int64  `json:"likes"`
+	Bookmarks int64  `json:"bookmarks"`
+	Comments  int64  `json:"comments"`
+	Purchases int64  `json:"purchases"`
+}
+
+// viewSessionCookie identifies anonymous visitors for view counting.
+const viewSessionCookie = "recipe_session"
+
+// viewSessionMaxAge is how long the anonymous session cookie lives.
+const viewSessionMaxAge = 30 * 24 * 60 * 60
+
+// recordView stores a view of a recipe. Authors viewing their own recipe are
+// not counted, and repeat views by the same user or anonymous session within
+// ViewDedupMinutes count once.
+func (h *RecipeHandler) recordView(c *gin.Context, recipe *models.Recipe, userID interface{}) {
+	view := models.RecipeView{RecipeID: recipe.ID}
+	recent := h.db(c).Model(&models.RecipeView{}).Where("recipe_id = ?", recipe.ID)
+	
+	if id, ok := userID.(string); ok {
+		if id == recipe.UserID {
+			return
+		}
+		view.UserID = &id
+		recent = recent.Where("user_id = ?", id)
+	} else {
+		sessionID := h.viewSession(c)
+		if sessionID == "" {
+			h.db(c).Create(&view)
+			return
+		}
+		view.SessionID = &sessionID
+		recent = recent.Where("session_id = ?", sessionID)
+	}
+	
+	if h.Config.ViewDedupMinutes > 0 {
+		since := time.Now().Add(-time.Duration(h.Config.ViewDedupMinutes) * time.Minute)
+		var count int64
+		recent.Where("created_at >= ?", since).Count(&count)
+		if count > 0 {
+			return
+		}
+	}
+	
+	h.db(c).Create(&view)
+}
+
+// viewSession returns the anonymous visitor's session ID from its signed
+// cookie, issuing a new cookie when there is none or it was tampered with.
+func (h *RecipeHandler) viewSession(c *gin.Context) string {
+	secret := []byte(h.Config.JWTSecret)
+	if value, err := c.Cookie(viewSessionCookie); err == nil {
+		if id, ok := utils.VerifySession(value, secret); ok {
+			return id
+		}
+	}
+	
+	id, err := utils.NewSessionID()
+	if err != nil {
+		return ""
+	}
+	
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(viewSessionCookie, utils.SignSession(id, secret), viewSessionMaxAge, "/", "", c.Request.TLS != nil, true)
+	return id
+}
+
+func (h *RecipeHandler) GetRecipeAnalytics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipe, ok := h.loadOwnedRecipe(c, c.Param("id"), userID)
+	if !ok {
+		return
+	}
+	
+	// Default to the last 30 days
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = parseDateParam(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD) or RFC3339 timestamp"})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = parseDateParam(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD) or RFC3339 timestamp"})
+			return
+		}
+		// A plain end date includes that whole day
+		if len(value) == len("2006-01-02") {
+			to = to.Add(24*time.Hour - time.Nanosecond)
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	
+	db := h.db(c)
+	days := make(map[string]*dailyStats)
+	totals := make(map[string]int64)
+	
+	series := []struct {
+		name  string
+		model interface{}
+		where string
+		set   func(*dailyStats, int64)
+	}{
+		{"views", &models.RecipeView{}, "", func(d *dailyStats, n int64) { d.Views = n }},
+		{"likes", &models.Like{}, "", func(d *dailyStats, n int64) { d.Likes = n }},
+		{"bookmarks", &models.Bookmark{}, "", func(d *dailyStats, n int64) { d.Bookmarks = n }},
+		{"comments", &models.Comment{}, "", func(d *dailyStats, n int64) { d.Comments = n }},
+		{"purchases", &models.Purchase{}, "status = 'completed'", func(d *dailyStats, n int64) { d.Purchases = n }},
+	}
+	for _, s := range series {
+		counts, err := dailyCounts(db, s.model, recipe.ID, from, to, s.where)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+			return
+		}
+		for _, count := range counts {
+			day, ok := days[count.Day]
+			if !ok {
+				day = &dailyStats{Date: count.Day}
+				days[count.Day] = day
+			}
+			s.set(day, count.Count)
+			totals[s.name] += count.Count
+		}
+	}
+	
+	// Rating distribution of ratings given in the range
+	var ratingRows []struct {
+		Rating int
+		Count  int64
+	}
+	if err := db.Model(&models.Rating{}).Select("rating, COUNT(*) AS count").
+		Where("recipe_id = ? AND created_at BETWEEN ? AND ?", recipe.ID, from, to).
+		Group("rating").Scan(&ratingRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	distribution := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range ratingRows {
+		distribution[row.Rating] = row.Count
+		totals["ratings"] += row.Count
+	}
+	
+	// Emit every day in the range so charts don't need to fill gaps
+	daily := []dailyStats{}
+	for day := from.Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		if stats, ok := days[key]; ok {
+			daily = append(daily, *stats)
+		} else {
+			daily = append(daily, dailyStats{Date: key})
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipe_id":           recipe.ID,
+		"from":                from,
+		"to":                  to,
+		"totals":              totals,
+		"average_rating":      recipe.AverageRating,
+		"rating_distribution": distribution,
+		"daily":               daily,
+	})
+}
+
+type dayCount struct {
+	Day   string
+	Count int64
+}
+
+// dailyCounts counts the rows of a recipe interaction table per day.
+func dailyCounts(db *gorm.DB, model interface{}, recipeID string, from, to time.Time, where string) ([]dayCount, error) {
+	query := db.Model(model).
+		Select("TO_CHAR(DATE(created_at), 'YYYY-MM-DD') AS day, COUNT(*) AS count").
+		Where("recipe_id = ? AND created_at BETWEEN ? AND ?", recipeID, from, to)
+	if where != "" {
+		query = query.Where(where)
+	}
+	
+	var counts []dayCount
+	err := query.Group("DATE(created_at)").Scan(&counts).Error
+	return counts, err
+}
+
+// parseDateParam accepts either a plain date or an RFC3339 timestamp.
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
\ No newline at end of file
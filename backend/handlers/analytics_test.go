@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+)
+
+func TestParseDateParam(t *testing.T) {
+	for _, value := range []string{"2026-03-01", "2026-03-01T10:00:00Z", "2026-03-01T10:00:00+03:00"} {
+		if _, err := parseDateParam(value); err != nil {
+			t.Errorf("%q: unexpected error %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "01/03/2026", "2026-13-01", "yesterday"} {
+		if _, err := parseDateParam(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestRecipeAnalyticsAggregateByDay(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	fan := createUser(t, db)
+	critic := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	// Noon keeps each row on its day whatever the server's time zone
+	day := func(date int) time.Time { return time.Date(2026, 3, date, 12, 0, 0, 0, time.Local) }
+	before := time.Date(2026, 2, 10, 12, 0, 0, 0, time.Local)
+	rows := []interface{}{
+		&models.RecipeView{RecipeID: recipe.ID, UserID: &fan.ID, CreatedAt: day(2)},
+		&models.RecipeView{RecipeID: recipe.ID, UserID: &critic.ID, CreatedAt: day(4)},
+		&models.RecipeView{RecipeID: recipe.ID, UserID: &critic.ID, CreatedAt: before},
+		&models.Like{UserID: fan.ID, RecipeID: recipe.ID, CreatedAt: day(2)},
+		&models.Like{UserID: critic.ID, RecipeID: recipe.ID, CreatedAt: day(4)},
+		&models.Comment{UserID: fan.ID, RecipeID: recipe.ID, Content: "Yum", CreatedAt: day(2)},
+		&models.Rating{UserID: fan.ID, RecipeID: recipe.ID, Rating: 5, CreatedAt: day(2)},
+		&models.Rating{UserID: critic.ID, RecipeID: recipe.ID, Rating: 2, CreatedAt: before},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	target := "/recipes/" + recipe.ID + "/analytics?from=2026-03-01&to=2026-03-05"
+	expectStatus(t, serve(h.GetRecipeAnalytics, "GET", "/recipes/:id/analytics", target, fan.ID, nil), http.StatusForbidden)
+	
+	w := serve(h.GetRecipeAnalytics, "GET", "/recipes/:id/analytics", target, author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	var analytics struct {
+		Totals       map[string]int64 `json:"totals"`
+		Distribution map[string]int64 `json:"rating_distribution"`
+		Daily        []dailyStats     `json:"daily"`
+	}
+	decode(t, w, &analytics)
+	
+	wantTotals := map[string]int64{"views": 2, "likes": 2, "comments": 1, "ratings": 1}
+	for name, want := range wantTotals {
+		if analytics.Totals[name] != want {
+			t.Errorf("expected %d %s in the range, got %d", want, name, analytics.Totals[name])
+		}
+	}
+	if analytics.Distribution["5"] != 1 || analytics.Distribution["2"] != 0 {
+		t.Errorf("expected one 5 star rating in the range, got %v", analytics.Distribution)
+	}
+	
+	if len(analytics.Daily) != 5 {
+		t.Fatalf("expected a row for each of the 5 days, got %+v", analytics.Daily)
+	}
+	want := map[string]dailyStats{
+		"2026-03-02": {Date: "2026-03-02", Views: 1, Likes: 1, Comments: 1},
+		"2026-03-03": {Date: "2026-03-03"},
+		"2026-03-04": {Date: "2026-03-04", Views: 1, Likes: 1},
+	}
+	for _, stats := range analytics.Daily {
+		if expected, ok := want[stats.Date]; ok && stats != expected {
+			t.Errorf("%s: expected %+v, got %+v", stats.Date, expected, stats)
+		}
+	}
+}
+
+func TestAnonymousViewsAreCountedOncePerSession(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	
+	view := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/recipes/"+recipe.ID, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := serveRequest(h.GetRecipe, "/recipes/:id", req, "")
+		expectStatus(t, w, http.StatusOK)
+		return w
+	}
+	sessionCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == viewSessionCookie {
+				return cookie
+			}
+		}
+		return nil
+	}
+	views := func() int64 {
+		var count int64
+		db.Model(&models.RecipeView{}).Where("recipe_id = ?", recipe.ID).Count(&count)
+		return count
+	}
+	
+	cookie := sessionCookie(view(nil))
+	if cookie == nil {
+		t.Fatal("expected a session cookie on the first view")
+	}
+	if !cookie.HttpOnly || cookie.MaxAge != viewSessionMaxAge {
+		t.Errorf("expected an HttpOnly cookie living %ds, got %+v", viewSessionMaxAge, cookie)
+	}
+	
+	if w := view(cookie); sessionCookie(w) != nil {
+		t.Error("expected a valid cookie to be kept")
+	}
+	view(cookie)
+	if got := views(); got != 1 {
+		t.Errorf("expected repeated views from one session to count once, got %d", got)
+	}
+	
+	// A forged cookie gets a new session, which counts as a new visitor
+	forged := &http.Cookie{Name: viewSessionCookie, Value: "chosen-id.forged"}
+	if sessionCookie(view(forged)) == nil {
+		t.Error("expected a forged cookie to be replaced")
+	}
+	if got := views(); got != 2 {
+		t.Errorf("expected a second visitor to be counted, got %d", got)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// The response types below only describe handler output for the OpenAPI spec.
+
+type recipeResponse struct {
+	Recipe         models.Recipe `json:"recipe"`
+	UserLiked      bool          `json:"user_liked"`
+	UserBookmarked bool          `json:"user_bookmarked"`
+	UserRating     int           `json:"user_rating"`
+	IsOwner        bool          `json:"is_owner"`
+	Units          string        `json:"units,omitempty"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type uploadResponse struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	FileSize int64  `json:"file_size"`
+	MimeType string `json:"mime_type"`
+	WebPURL  string `json:"webp_url,omitempty"`
+}
+
+// commentQuery documents the query parameters of GetComments.
+type commentQuery struct {
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest oldest"`
+	ParentID string `form:"parent_id" binding:"omitempty,uuid"`
+	Cursor   string `form:"cursor"`
+	Page     int    `form:"page"`
+	Limit    int    `form:"limit"`
+}
+
+type pageQuery struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+// apiOperations documents the /api routes, keyed by method and gin path. Admin
+// routes are always documented as requiring an admin token.
+var apiOperations = map[string]apiOperation{
+	// Auth
+	"POST /api/auth/signup": {Summary: "Create an account", Body: models.SignupRequest{},
+		Response: models.AuthResponse{}, Status: 201},
+	"POST /api/auth/login": {Summary: "Log in with email and password", Body: models.LoginRequest{},
+		Response: models.AuthResponse{}},
+	"GET /api/auth/username-available": {Summary: "Check whether a username is free",
+		Query: struct {
+			Username string `form:"username" binding:"required"`
+		}{}, Response: struct {
+			Available bool `json:"available"`
+		}{}},
+	"GET /api/auth/profile": {Summary: "Get the current user", Auth: authRequired, Response: models.User{}},
+	"DELETE /api/auth/account": {Summary: "Delete the current user's account", Auth: authRequired,
+		Body: models.DeleteAccountRequest{}, Response: messageResponse{}},
+	"POST /api/auth/avatar": {Summary: "Upload a new avatar for the current user", Auth: authRequired, Upload: true,
+		Response: models.User{}},
+	"GET /api/me/activity": {Summary: "List the current user's recent activity", Auth: authRequired,
+		Query: pageQuery{}, Response: PaginatedResponse[activityItem]{}},
+	
+	"GET /api/me/recently-viewed": {Summary: "List the recipes the current user viewed last", Auth: authRequired,
+		Response: struct {
+			Recipes []recentlyViewedItem `json:"recipes"`
+		}{}},
+	
+	// Search and categories
+	"GET /api/search": {Summary: "Search recipes and users",
+		Query: struct {
+			Query string `form:"q" binding:"required"`
+			Limit int    `form:"limit"`
+		}{}, Response: gin.H{}},
+	"GET /api/search/trending": {Summary: "List the most searched terms of recent days",
+		Query: struct {
+			Limit int `form:"limit"`
+			Days  int `form:"days"`
+		}{}, Response: struct {
+			Days  int            `json:"days"`
+			Terms []trendingTerm `json:"terms"`
+		}{}},
+	"GET /api/stats": {Summary: "Get platform-wide counts for the stats page",
+		Response: platformStats{}},
+	"GET /api/categories": {Summary: "List categories",
+		Query: struct {
+			Lang string `form:"lang"`
+		}{}, Response: []models.Category{}},
+	"GET /api/categories/recipes": {Summary: "List recipes for several categories at once", Auth: authOptional,
+		Query: struct {
+			CategoryIDs string `form:"category_ids" binding:"required"`
+			Limit       int    `form:"limit"`
+		}{}, Response: struct {
+			Categories map[string][]recipeListItem `json:"categories"`
+			Limit      int                         `json:"limit"`
+		}{}},
+	"GET /api/categories/:id/recipes": {Summary: "List a category's recipes", Auth: authOptional,
+		Query: models.SearchFilters{}, Response: categoryRecipesResponse{}},
+	
+	// Recipes
+	"GET /api/recipes": {Summary: "Search published recipes", Auth: authOptional,
+		Query: models.SearchFilters{}, Response: PaginatedResponse[recipeListItem]{}},
+	"GET /api/recipes/ids": {Summary: "List published recipe IDs for sitemaps",
+		Query: struct {
+			After string `form:"after" binding:"omitempty,uuid"`
+			Limit int    `form:"limit" binding:"omitempty,max=1000"`
+		}{}, Response: struct {
+			Recipes []struct {
+				ID        string    `json:"id"`
+				UpdatedAt time.Time `json:"updated_at"`
+			} `json:"recipes"`
+			NextCursor *string `json:"next_cursor"`
+		}{}},
+	"POST /api/recipes/by-ingredients": {Summary: "Find recipes that use the given ingredients", Auth: authOptional,
+		Body: struct {
+			Ingredients []string `json:"ingredients" binding:"required,min=1,max=50"`
+		}{}, Response: struct {
+			Recipes     []ingredientMatch `json:"recipes"`
+			Ingredients []string          `json:"ingredients"`
+		}{}},
+	"GET /api/recipes/featured": {Summary: "List featured recipes", Auth: authOptional,
+		Response: struct {
+			Recipes []recipeListItem `json:"recipes"`
+		}{}},
+	"GET /api/recipes/:id": {Summary: "Get a recipe", Auth: authOptional,
+		Query: struct {
+			Units string `form:"units" binding:"omitempty,oneof=metric imperial"`
+		}{}, Response: recipeResponse{}},
+	"GET /api/recipes/by-slug/:slug": {Summary: "Get a recipe by its slug", Auth: authOptional,
+		Response: recipeResponse{}},
+	"GET /api/recipes/:id/likes": {Summary: "List the users who liked a recipe", Auth: authOptional,
+		Query: pageQuery{}, Response: PaginatedResponse[models.RecipeLiker]{}},
+	"GET /api/recipes/:id/also-bought": {Summary: "List recipes bought by buyers of this one", Auth: authOptional,
+		Query: struct {
+			Limit int `form:"limit"`
+		}{}, Response: gin.H{}},
+	"GET /api/recipes/:id/cook": {Summary: "Get a recipe laid out for cooking", Auth: authOptional,
+		Response: gin.H{}},
+	"GET /api/recipes/:id/made": {Summary: "Get a recipe's made-it count and photos", Auth: authOptional,
+		Query: pageQuery{}, Response: madeGalleryResponse{}},
+	"GET /api/recipes/:id/nutrition-per-serving": {Summary: "Get a recipe's nutrition per serving", Auth: authOptional,
+		Response: struct {
+			RecipeID   string         `json:"recipe_id"`
+			Servings   int            `json:"servings"`
+			Total      nutritionFacts `json:"total"`
+			PerServing nutritionFacts `json:"per_serving"`
+			Complete   bool           `json:"complete"`
+			Missing    []nutritionGap `json:"missing"`
+		}{}},
+	"GET /api/recipes/mine": {Summary: "List the current user's recipes, drafts included", Auth: authRequired,
+		Query: struct {
+			models.SearchFilters
+			Status string `form:"status" binding:"omitempty,oneof=all draft scheduled published"`
+		}{}, Response: PaginatedResponse[models.Recipe]{}},
+	"POST /api/recipes": {Summary: "Create a recipe", Auth: authRequired,
+		Query: struct {
+			Force bool `form:"force"`
+		}{}, Body: createRecipeRequest{}, Response: models.Recipe{}, Status: 201},
+	"PUT /api/recipes/:id": {Summary: "Update a recipe", Auth: authRequired,
+		Body: models.UpdateRecipeRequest{}, Response: models.Recipe{}},
+	"PATCH /api/recipes/:id": {Summary: "Update a recipe", Auth: authRequired,
+		Body: models.UpdateRecipeRequest{}, Response: models.Recipe{}},
+	"DELETE /api/recipes": {Summary: "Delete several recipes", Auth: authRequired,
+		Body: struct {
+			IDs []string `json:"ids" binding:"required,min=1,max=100"`
+		}{}},
+	"DELETE /api/recipes/:id": {Summary: "Delete a recipe", Auth: authRequired, Response: messageResponse{}},
+	"PATCH /api/recipes/:id/steps/reorder": {Summary: "Reorder a recipe's steps", Auth: authRequired,
+		Body: struct {
+			StepIDs []string `json:"step_ids" binding:"required,min=1"`
+		}{}},
+	"POST /api/recipes/:id/restore": {Summary: "Restore a deleted recipe", Auth: authRequired},
+	"GET /api/recipes/:id/me": {Summary: "Get the current user's interactions with a recipe", Auth: authRequired},
+	"GET /api/recipes/:id/purchasers": {Summary: "List a recipe's buyers", Auth: authRequired,
+		Query: pageQuery{}, Response: PaginatedResponse[models.RecipePurchaser]{}},
+	"GET /api/recipes/:id/analytics": {Summary: "Get daily statistics for a recipe", Auth: authRequired},
+	"GET /api/recipes/:id/checklist": {Summary: "Get the current user's cooking checklist", Auth: authRequired,
+		Response: models.UserRecipeProgress{}},
+	"POST /api/recipes/:id/checklist": {Summary: "Save the current user's cooking checklist", Auth: authRequired,
+		Body: struct {
+			CheckedIngredients []string `json:"checked_ingredients" binding:"max=500"`
+			CheckedSteps       []string `json:"checked_steps" binding:"max=500"`
+		}{}, Response: models.UserRecipeProgress{}},
+	"POST /api/recipes/:id/like": {Summary: "Like or unlike a recipe", Auth: authRequired},
+	"POST /api/recipes/:id/bookmark": {Summary: "Bookmark or unbookmark a recipe", Auth: authRequired},
+	"POST /api/recipes/:id/rating": {Summary: "Rate a recipe", Auth: authRequired,
+		Body: struct {
+			Rating int `json:"rating" binding:"required,min=1,max=5"`
+		}{}},
+	"DELETE /api/recipes/:id/rating": {Summary: "Remove the current user's rating", Auth: authRequired},
+	"POST /api/recipes/:id/made": {Summary: "Record that the current user made a recipe", Auth: authRequired,
+		Body: struct {
+			PhotoURL string `json:"photo_url"`
+			Note     string `json:"note" binding:"max=1000"`
+		}{}, Response: struct {
+			Made      models.Made `json:"made"`
+			MadeCount int         `json:"made_count"`
+		}{}, Status: 201},
+	
+	// Bookmarks
+	"GET /api/bookmarks": {Summary: "List the current user's bookmarks", Auth: authRequired,
+		Query: pageQuery{}, Response: PaginatedResponse[models.Bookmark]{}},
+	"PUT /api/bookmarks/:id": {Summary: "Bookmark a recipe with a note", Auth: authRequired,
+		Body: struct {
+			Note string `json:"note" binding:"max=1000"`
+		}{}, Response: models.Bookmark{}},
+	
+	// Cooking queue
+	"GET /api/queue": {Summary: "List the current user's cooking queue", Auth: authRequired,
+		Response: struct {
+			Queue []models.Queue `json:"queue"`
+		}{}},
+	"PUT /api/queue/order": {Summary: "Reorder the current user's cooking queue", Auth: authRequired,
+		Body: struct {
+			RecipeIDs []string `json:"recipe_ids" binding:"required,max=100"`
+		}{}, Response: struct {
+			Queue []models.Queue `json:"queue"`
+		}{}},
+	"POST /api/recipes/:id/queue": {Summary: "Add a recipe to the end of the cooking queue", Auth: authRequired,
+		Response: models.Queue{}, Status: 201},
+	"DELETE /api/recipes/:id/queue": {Summary: "Remove a recipe from the cooking queue", Auth: authRequired,
+		Response: messageResponse{}},
+	"POST /api/recipes/:id/queue/cooked": {Summary: "Mark a queued recipe as cooked", Auth: authRequired,
+		Response: struct {
+			Made models.Made `json:"made"`
+		}{}},
+	
+	// Comments
+	"GET /api/recipes/:id/comments": {Summary: "List a recipe's comments or a comment's replies", Auth: authOptional,
+		Query: commentQuery{}, Response: commentListResponse{}},
+	"POST /api/recipes/:id/comment": {Summary: "Comment on a recipe or reply to a comment", Auth: authRequired,
+		Body: struct {
+			Content  string  `json:"content" binding:"required"`
+			ParentID *string `json:"parent_id" binding:"omitempty,uuid"`
+		}{}, Response: models.Comment{}, Status: 201},
+	"PUT /api/comments/:id": {Summary: "Edit a comment", Auth: authRequired,
+		Body: struct {
+			Content string `json:"content" binding:"required"`
+		}{}, Response: models.Comment{}},
+	"PUT /api/comments/:id/visibility": {Summary: "Hide or show a comment", Auth: authRequired,
+		Body: struct {
+			Hidden bool `json:"hidden" binding:"required"`
+		}{}},
+	
+	// Uploads
+	"POST /api/upload": {Summary: "Upload an image", Auth: authRequired, Upload: true,
+		Response: uploadResponse{}},
+	
+	// Payments
+	"POST /api/payment/initialize": {Summary: "Start paying for a recipe", Auth: authRequired,
+		Body: struct {
+			RecipeID string  `json:"recipe_id" binding:"required"`
+			Amount   float64 `json:"amount" binding:"required,min=0.01"`
+		}{}, Response: struct {
+			CheckoutURL string `json:"checkout_url"`
+			PurchaseID  string `json:"purchase_id"`
+		}{}},
+	"GET /api/payment/purchases": {Summary: "List the current user's purchases", Auth: authRequired,
+		Response: []models.Purchase{}},
+	"GET /api/payment/verify": {Summary: "Payment provider callback",
+		Query: struct {
+			TxRef string `form:"tx_ref" binding:"required"`
+		}{}, Response: struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}{}},
+	
+	// Admin
+	"GET /api/admin/recipes/duplicates": {Summary: "Find recipes with near-identical titles",
+		Query: struct {
+			Threshold float64 `form:"threshold"`
+		}{}},
+	"POST /api/admin/recipes/:id/unpublish": {Summary: "Unpublish a recipe",
+		Body: struct {
+			Reason string `json:"reason" binding:"required"`
+		}{}},
+	"POST /api/admin/recipes/:id/republish": {Summary: "Republish a recipe",
+		Body: struct {
+			Reason string `json:"reason"`
+		}{}},
+	"POST /api/admin/recipes/:id/feature": {Summary: "Feature a recipe",
+		Body: struct {
+			Rank *int `json:"rank" binding:"omitempty,min=1"`
+		}{}},
+	"POST /api/admin/recipes/:id/unfeature": {Summary: "Stop featuring a recipe"},
+	"GET /api/admin/maintenance": {Summary: "Check whether maintenance mode is on",
+		Response: struct {
+			Enabled bool `json:"enabled"`
+		}{}},
+	"PUT /api/admin/maintenance": {Summary: "Switch maintenance mode on or off",
+		Body: struct {
+			Enabled bool `json:"enabled" binding:"required"`
+		}{}, Response: struct {
+			Enabled bool `json:"enabled"`
+		}{}},
+}
\ No newline at end of file
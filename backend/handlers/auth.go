@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 	
+	"food-recipes-backend/config"
+	"food-recipes-backend/middleware"
 	"food-recipes-backend/models"
 	"food-recipes-backend/utils"
 	
@@ -10,24 +18,81 @@ import (
 	"gorm.io/gorm"
 )
 
+// The placeholder account that takes over recipes, comments and purchases of
+// deleted users when DELETED_USER_RECIPES=reassign. It is seeded by a migration
+// and always looked up by its ID.
+const (
+	deletedUserID       = "00000000-0000-0000-0000-000000000001"
+	deletedUserUsername = "deleted-user"
+)
+
+// reservedUsernamePrefix starts the usernames of the placeholder and of deleted
+// accounts, so nobody can register a name that passes for either.
+const reservedUsernamePrefix = "deleted-"
+
+// usernamePattern limits usernames to characters that are safe in URLs and mentions.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
 type AuthHandler struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Config *config.Config
+	
+	usernameCheckLimiter *utils.RateLimiter
+	emailValidator       *utils.EmailValidator
+}
+
+func NewAuthHandler(db *gorm.DB, cfg *config.Config) *AuthHandler {
+	return &AuthHandler{
+		DB:                   db,
+		Config:               cfg,
+		usernameCheckLimiter: utils.NewRateLimiter(cfg.UsernameCheckLimit, time.Minute),
+		emailValidator:       utils.NewEmailValidator(cfg.EmailBlocklist, cfg.EmailCheckMX, time.Duration(cfg.EmailMXTimeout)*time.Second),
+	}
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{DB: db}
+// db returns the handler's database bound to the request context.
+func (h *AuthHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
 }
 
 func (h *AuthHandler) Signup(c *gin.Context) {
 	var req models.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
 		return
 	}
 	
-	// Check if user already exists
+	req.Email = normalizeEmail(req.Email)
+	req.Username = strings.TrimSpace(req.Username)
+	
+	if err := h.emailValidator.Validate(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": gin.H{"email": err.Error()},
+		})
+		return
+	}
+	
+	if err := validateUsername(req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": gin.H{"username": err.Error()},
+		})
+		return
+	}
+	
+	avatarURL := trimmedOrNil(req.AvatarURL)
+	if avatarURL != nil && !isAvatarURL(h.Config, *avatarURL) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": gin.H{"avatar_url": "must be an uploaded image or an http(s) URL"},
+		})
+		return
+	}
+	
+	// Check if user already exists, ignoring case
 	var existingUser models.User
-	if err := h.DB.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
+	if err := h.db(c).Where("LOWER(email) = ? OR LOWER(username) = LOWER(?)", req.Email, req.Username).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email or username already exists"})
 		return
 	}
@@ -44,36 +109,40 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		Email:        req.Email,
 		Username:     req.Username,
 		PasswordHash: hashedPassword,
+		AvatarURL:    avatarURL,
+		Bio:          trimmedOrNil(req.Bio),
 	}
 	
-	if err := h.DB.Create(&user).Error; err != nil {
+	if err := h.db(c).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 	
 	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	token, claims, err := utils.GenerateJWT(user.ID, user.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 	
 	c.JSON(http.StatusCreated, models.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:     token,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+		User:      user,
 	})
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
 		return
 	}
 	
 	// Find user
 	var user models.User
-	if err := h.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	if err := h.db(c).Where("LOWER(email) = ?", normalizeEmail(req.Email)).First(&user).Error; err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -85,18 +154,46 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 	
 	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	token, claims, err := utils.GenerateJWT(user.ID, user.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 	
 	c.JSON(http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:     token,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+		User:      user,
 	})
 }
 
+func (h *AuthHandler) CheckUsernameAvailable(c *gin.Context) {
+	// Throttle per client to make enumerating usernames expensive
+	if !h.usernameCheckLimiter.Allow(c.ClientIP()) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+		return
+	}
+	
+	username := strings.TrimSpace(c.Query("username"))
+	if err := validateUsername(username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": gin.H{"username": err.Error()},
+		})
+		return
+	}
+	
+	// Unscoped so usernames of deleted accounts are reported as taken too
+	var count int64
+	if err := h.db(c).Unscoped().Model(&models.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"available": count == 0})
+}
+
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -105,10 +202,206 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	}
 	
 	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
+	if err := h.db(c).First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 	
 	c.JSON(http.StatusOK, user)
+}
+
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	
+	// Require the current password to confirm deletion
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+	
+	var orphans []models.Upload
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := h.handleDeletedUserContent(tx, &user); err != nil {
+			return err
+		}
+		
+		// Anonymize the account so the email and username can be reused
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":      fmt.Sprintf("deleted-%s@deleted.invalid", user.ID),
+			"username":   reservedUsernamePrefix + user.ID,
+			"avatar_url": nil,
+			"bio":        nil,
+		}).Error; err != nil {
+			return err
+		}
+		
+		// Soft deleting the user also revokes its tokens in AuthMiddleware
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		
+		// Uploads only used by the deleted recipes or avatar go with the account
+		var err error
+		if orphans, err = orphanedUploads(tx, user.ID); err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			return nil
+		}
+		return tx.Delete(&orphans).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	
+	middleware.ForgetUser(user.ID)
+	
+	// Files are removed once the records are gone for good
+	removeUploadFiles(h.Config.UploadDir, orphans)
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// handleDeletedUserContent either deletes the user's recipes and comments or
+// reassigns them to the "deleted user" placeholder, depending on configuration.
+// Purchases are payment records and are kept, reassigned along with the recipes
+// in reassign mode. Likes, ratings, bookmarks, the cooking queue, made-it
+// entries, cooking checklists and recently viewed recipes are personal and
+// always deleted.
+func (h *AuthHandler) handleDeletedUserContent(tx *gorm.DB, user *models.User) error {
+	if h.Config.DeletedUserRecipes == "reassign" {
+		// The placeholder is only created here when its seeded row is missing,
+		// e.g. in a database whose tables were emptied
+		placeholder := models.User{
+			ID:           deletedUserID,
+			Email:        "deleted-user@deleted.invalid",
+			Username:     deletedUserUsername,
+			PasswordHash: "!", // Not a valid bcrypt hash, so nobody can log in
+		}
+		if err := tx.Where(models.User{ID: deletedUserID}).FirstOrCreate(&placeholder).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Recipe{}, &models.Comment{}, &models.Purchase{}} {
+			if err := tx.Model(model).Where("user_id = ?", user.ID).Update("user_id", placeholder.ID).Error; err != nil {
+				return err
+			}
+		}
+		return deleteUserReactions(tx, user.ID)
+	}
+	
+	var recipes []models.Recipe
+	if err := tx.Where("user_id = ?", user.ID).Find(&recipes).Error; err != nil {
+		return err
+	}
+	for i := range recipes {
+		if err := deleteRecipeCascade(tx, &recipes[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	return deleteUserReactions(tx, user.ID)
+}
+
+// deleteUserReactions deletes the user's likes, ratings, bookmarks, queue,
+// made-it entries, checklists and recently viewed recipes, then refreshes the
+// aggregates of the recipes they liked, rated or made. The batch deletes run
+// the hooks without a recipe ID, so the refresh is explicit.
+func deleteUserReactions(tx *gorm.DB, userID string) error {
+	var likedIDs, ratedIDs, madeIDs []string
+	if err := tx.Model(&models.Like{}).Where("user_id = ?", userID).Pluck("recipe_id", &likedIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Rating{}).Where("user_id = ?", userID).Pluck("recipe_id", &ratedIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Made{}).Distinct("recipe_id").Where("user_id = ?", userID).Pluck("recipe_id", &madeIDs).Error; err != nil {
+		return err
+	}
+	
+	// The user row is only soft deleted, so ON DELETE CASCADE doesn't clean up.
+	// The rows go for good, like an unlike does, so restoring a recipe can't
+	// bring them back and made-it photos stop counting as used uploads
+	for _, model := range []interface{}{&models.Like{}, &models.Rating{}, &models.Made{}, &models.Bookmark{},
+		&models.Queue{}, &models.UserRecipeProgress{}, &models.RecentlyViewed{}} {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	
+	for _, recipeID := range likedIDs {
+		if err := models.RefreshLikeCount(tx, recipeID); err != nil {
+			return err
+		}
+	}
+	for _, recipeID := range ratedIDs {
+		if err := models.RefreshRatingStats(tx, recipeID); err != nil {
+			return err
+		}
+	}
+	for _, recipeID := range madeIDs {
+		if err := models.RefreshMadeCount(tx, recipeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateUsername applies the signup rules for usernames.
+func validateUsername(username string) error {
+	if len(username) < 3 {
+		return errors.New("username must be at least 3 characters")
+	}
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username may only contain letters, numbers, dots, dashes and underscores")
+	}
+	if strings.HasPrefix(strings.ToLower(username), reservedUsernamePrefix) {
+		return errors.New("username is reserved")
+	}
+	return nil
+}
+
+// normalizeEmail lowercases and trims an email so lookups and uniqueness are
+// case-insensitive.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isAvatarURL accepts images uploaded to this server and absolute http(s) URLs.
+func isAvatarURL(cfg *config.Config, raw string) bool {
+	if isUploadURL(cfg, raw) {
+		return true
+	}
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// trimmedOrNil trims an optional string, treating a blank value as absent.
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
 }
\ No newline at end of file
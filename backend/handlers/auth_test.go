@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/middleware"
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteAccountRequiresPasswordAndRevokesTokens(t *testing.T) {
+	db := testDB(t)
+	h := NewAuthHandler(db, testConfig())
+	user := createUser(t, db)
+	setPassword(t, db, &user, "correct horse")
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	
+	router := gin.New()
+	router.GET("/auth/profile", middleware.AuthMiddleware(db), h.GetProfile)
+	router.DELETE("/auth/account", middleware.AuthMiddleware(db), h.DeleteAccount)
+	
+	token, _, err := utils.GenerateJWT(user.ID, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	
+	expectStatus(t, send("GET", "/auth/profile", ""), http.StatusOK)
+	expectStatus(t, send("DELETE", "/auth/account", `{"password": "wrong"}`), http.StatusUnauthorized)
+	expectStatus(t, send("GET", "/auth/profile", ""), http.StatusOK)
+	
+	expectStatus(t, send("DELETE", "/auth/account", `{"password": "correct horse"}`), http.StatusOK)
+	expectStatus(t, send("GET", "/auth/profile", ""), http.StatusUnauthorized)
+	
+	if err := db.First(&models.Recipe{}, "id = ?", recipe.ID).Error; err == nil {
+		t.Error("expected the deleted user's recipe to be deleted")
+	}
+}
+
+func TestDeletedAccountsContentGoesToThePlaceholder(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.DeletedUserRecipes = "reassign"
+	h := NewAuthHandler(db, cfg)
+	user := createUser(t, db)
+	setPassword(t, db, &user, "correct horse")
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	
+	// The reserved name can't be registered to catch the reassigned content
+	w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "",
+		gin.H{"email": "squatter@example.com", "username": deletedUserUsername, "password": "secret123"})
+	expectStatus(t, w, http.StatusBadRequest)
+	
+	w = serve(h.DeleteAccount, "DELETE", "/auth/account", "/auth/account", user.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.UserID != deletedUserID {
+		t.Errorf("expected the recipe to belong to the placeholder, got %q", stored.UserID)
+	}
+	var placeholder models.User
+	if err := db.First(&placeholder, "id = ?", deletedUserID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if placeholder.Username != deletedUserUsername {
+		t.Errorf("expected the placeholder to be named %q, got %q", deletedUserUsername, placeholder.Username)
+	}
+}
+
+func TestAccountsAreUniqueAndFoundRegardlessOfCase(t *testing.T) {
+	db := testDB(t)
+	h := NewAuthHandler(db, testConfig())
+	signup := func(email, username string) *httptest.ResponseRecorder {
+		return serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "",
+			gin.H{"email": email, "username": username, "password": "secret123"})
+	}
+	
+	expectStatus(t, signup("Cook@Example.com", "ChefAnna"), http.StatusCreated)
+	expectStatus(t, signup("cook@example.COM", "SomeoneElse"), http.StatusConflict)
+	expectStatus(t, signup("other@example.com", "chefanna"), http.StatusConflict)
+	
+	// The unique indexes catch what slips past the handler's check
+	duplicate := models.User{Email: "COOK@example.com", Username: "Different", PasswordHash: "unused"}
+	if err := db.Create(&duplicate).Error; err == nil {
+		t.Error("expected the database to reject an email differing only in case")
+	}
+	
+	for _, email := range []string{"cook@example.com", "COOK@EXAMPLE.COM"} {
+		w := serve(h.Login, "POST", "/auth/login", "/auth/login", "", gin.H{"email": email, "password": "secret123"})
+		if w.Code != http.StatusOK {
+			t.Errorf("login as %q: expected 200, got %d: %s", email, w.Code, w.Body)
+		}
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	for _, username := range []string{"anna", "chef_anna", "anna.b-2"} {
+		if err := validateUsername(username); err != nil {
+			t.Errorf("%q: unexpected error %v", username, err)
+		}
+	}
+	for _, username := range []string{"", "ab", "anna b", "anna/admin", "ånna", "deleted-user", "Deleted-User", "deleted-anna"} {
+		if err := validateUsername(username); err == nil {
+			t.Errorf("%q: expected an error", username)
+		}
+	}
+}
+
+func TestUsernameAvailability(t *testing.T) {
+	db := testDB(t)
+	h := NewAuthHandler(db, testConfig())
+	taken := createUser(t, db)
+	
+	tests := []struct {
+		username  string
+		status    int
+		available bool
+	}{
+		{taken.Username, http.StatusOK, false},
+		{strings.ToUpper(taken.Username), http.StatusOK, false},
+		{"free_name", http.StatusOK, true},
+		{"no spaces", http.StatusBadRequest, false},
+		{"ab", http.StatusBadRequest, false},
+		{deletedUserUsername, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		target := "/auth/username-available?username=" + url.QueryEscape(tt.username)
+		w := serve(h.CheckUsernameAvailable, "GET", "/auth/username-available", target, "", nil)
+		if w.Code != tt.status {
+			t.Errorf("%q: expected %d, got %d: %s", tt.username, tt.status, w.Code, w.Body)
+			continue
+		}
+		var body struct {
+			Available bool              `json:"available"`
+			Fields    map[string]string `json:"fields"`
+		}
+		decode(t, w, &body)
+		if tt.status != http.StatusOK {
+			if body.Fields["username"] == "" {
+				t.Errorf("%q: expected username to be reported, got %s", tt.username, w.Body)
+			}
+			continue
+		}
+		if body.Available != tt.available {
+			t.Errorf("%q: expected available=%v", tt.username, tt.available)
+		}
+	}
+}
+
+func TestSignupRejectsInvalidProfileFields(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewAuthHandler(nil, testConfig())
+	
+	tests := map[string]gin.H{
+		"avatar_url": {"avatar_url": "javascript:alert(1)"},
+		"bio":        {"bio": strings.Repeat("a", 501)},
+		"username":   {"username": "no spaces"},
+	}
+	for field, extra := range tests {
+		body := gin.H{"email": "cook@example.com", "username": "chefanna", "password": "secret123"}
+		for key, value := range extra {
+			body[key] = value
+		}
+		
+		w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "", body)
+		expectStatus(t, w, http.StatusBadRequest)
+		var response struct {
+			Fields map[string]string `json:"fields"`
+		}
+		decode(t, w, &response)
+		if response.Fields[field] == "" {
+			t.Errorf("expected %s to be reported, got %v", field, response.Fields)
+		}
+	}
+}
+
+func TestSignupRejectsDisposableEmails(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	cfg := testConfig()
+	cfg.EmailBlocklist = []string{"mailinator.com"}
+	h := NewAuthHandler(nil, cfg)
+	
+	tests := map[string]string{
+		"Cook@Mailinator.com":    utils.ErrDisposableEmail.Error(),
+		"cook@eu.mailinator.com": utils.ErrDisposableEmail.Error(),
+		"cook@localhost":         utils.ErrInvalidEmail.Error(),
+	}
+	for email, want := range tests {
+		body := gin.H{"email": email, "username": "chefanna", "password": "secret123"}
+		w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "", body)
+		expectStatus(t, w, http.StatusBadRequest)
+		var response struct {
+			Fields map[string]string `json:"fields"`
+		}
+		decode(t, w, &response)
+		if response.Fields["email"] != want {
+			t.Errorf("%q: expected %q, got %v", email, want, response.Fields)
+		}
+	}
+}
+
+func TestSignupAcceptsValidEmails(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.EmailBlocklist = []string{"mailinator.com"}
+	h := NewAuthHandler(db, cfg)
+	
+	name := fixtureName("cook")
+	body := gin.H{"email": strings.ToUpper(name) + "@Example.com", "username": name, "password": "secret123"}
+	w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "", body)
+	expectStatus(t, w, http.StatusCreated)
+	
+	var user models.User
+	if err := db.First(&user, "username = ?", name).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ToLower(name) + "@example.com"; user.Email != want {
+		t.Errorf("expected the normalized email %q to be stored, got %q", want, user.Email)
+	}
+}
+
+func TestSignupStoresOptionalProfileFields(t *testing.T) {
+	db := testDB(t)
+	h := NewAuthHandler(db, testConfig())
+	
+	signup := func(body gin.H) models.User {
+		t.Helper()
+		body["email"] = fixtureName("cook") + "@example.com"
+		body["username"] = fixtureName("cook")
+		body["password"] = "secret123"
+		w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "", body)
+		expectStatus(t, w, http.StatusCreated)
+		
+		var user models.User
+		if err := db.First(&user, "email = ?", body["email"]).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	
+	user := signup(gin.H{"avatar_url": "https://cdn.example.com/anna.png", "bio": "  Baker from Addis  "})
+	if user.AvatarURL == nil || *user.AvatarURL != "https://cdn.example.com/anna.png" {
+		t.Errorf("expected the avatar to be stored, got %v", user.AvatarURL)
+	}
+	if user.Bio == nil || *user.Bio != "Baker from Addis" {
+		t.Errorf("expected the trimmed bio to be stored, got %v", user.Bio)
+	}
+	
+	user = signup(gin.H{"bio": "   "})
+	if user.AvatarURL != nil || user.Bio != nil {
+		t.Errorf("expected no profile fields, got avatar %v and bio %v", user.AvatarURL, user.Bio)
+	}
+}
+
+func TestLoginReportsTheTokenExpiry(t *testing.T) {
+	db := testDB(t)
+	h := NewAuthHandler(db, testConfig())
+	user := createUser(t, db)
+	setPassword(t, db, &user, "secret123")
+	
+	w := serve(h.Login, "POST", "/auth/login", "/auth/login", "", gin.H{"email": user.Email, "password": "secret123"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var response models.AuthResponse
+	decode(t, w, &response)
+	claims, err := utils.ValidateJWT(response.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.ExpiresAt.Equal(claims.ExpiresAt.Time) || !response.IssuedAt.Equal(claims.IssuedAt.Time) {
+		t.Errorf("expected issued_at %s and expires_at %s, got %s and %s",
+			claims.IssuedAt, claims.ExpiresAt, response.IssuedAt, response.ExpiresAt)
+	}
+	if lifetime := response.ExpiresAt.Sub(response.IssuedAt); lifetime != time.Duration(testConfig().JWTExpiryHours)*time.Hour {
+		t.Errorf("expected the configured lifetime, got %s", lifetime)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// avatarSize is the width and height of avatar thumbnails in pixels
+	avatarSize = 256
+	// maxAvatarPixels keeps a small file with huge dimensions from being
+	// decoded into gigabytes of memory. 4096x4096 decodes to about 64 MB.
+	maxAvatarPixels = 4096 * 4096
+	// maxAvatarDecodes bounds how many avatars are decoded at once, and so the
+	// memory the decoded images take together
+	maxAvatarDecodes = 2
+)
+
+// avatarDecodes holds a slot for each avatar being decoded.
+var avatarDecodes = make(chan struct{}, maxAvatarDecodes)
+
+// avatarTypes are the image types avatar thumbnails can be made from, with
+// the type the thumbnail is saved as. GIFs lose their animation.
+var avatarTypes = map[string]string{
+	"image/jpeg": "image/jpeg",
+	"image/png":  "image/png",
+	"image/gif":  "image/png",
+}
+
+var errAvatarDimensions = errors.New("image dimensions are too large")
+
+// UploadAvatar replaces the caller's avatar with a square thumbnail of the
+// uploaded "image" field. The user's avatar_url and the upload record change
+// in one transaction, and the previous avatar's file is deleted unless a
+// recipe or another user still shows it.
+func (h *UploadHandler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	tmpName, _, fileType, ok := h.receiveImage(c, userID.(string))
+	if !ok {
+		return
+	}
+	defer os.Remove(tmpName)
+	
+	thumbnailType, ok := avatarTypes[fileType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Avatars must be JPEG, PNG or GIF images"})
+		return
+	}
+	
+	// Wait for a decode slot, giving up when the request times out
+	select {
+	case avatarDecodes <- struct{}{}:
+	case <-c.Request.Context().Done():
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many avatar uploads, try again"})
+		return
+	}
+	thumbnail, err := avatarThumbnail(tmpName)
+	<-avatarDecodes
+	if errors.Is(err, errAvatarDimensions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Avatars can be at most %d megapixels", maxAvatarPixels/1_000_000)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+		return
+	}
+	
+	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), imageExtensions[thumbnailType])
+	size, err := writeThumbnail(filepath.Join(h.UploadDir, filename), thumbnail, thumbnailType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+	
+	upload := models.Upload{
+		UserID:   userID.(string),
+		Filename: filename,
+		MimeType: thumbnailType,
+		Size:     size,
+	}
+	avatarURL := fmt.Sprintf("/uploads/%s", filename)
+	
+	var user models.User
+	var replaced []models.Upload
+	err = h.db(c).Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent uploads each see the avatar they replace
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&upload).Error; err != nil {
+			return err
+		}
+		
+		previous := ""
+		if user.AvatarURL != nil {
+			previous = uploadedFilename(h.Config, *user.AvatarURL)
+		}
+		if err := tx.Model(&user).Update("avatar_url", avatarURL).Error; err != nil {
+			return err
+		}
+		if previous == "" {
+			return nil
+		}
+		
+		var err error
+		if replaced, err = orphanedUploads(tx.Where("uploads.filename = ? OR uploads.webp_filename = ?", previous, previous), user.ID); err != nil {
+			return err
+		}
+		if len(replaced) == 0 {
+			return nil
+		}
+		return tx.Delete(&replaced).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		removeUploadFiles(h.UploadDir, []models.Upload{upload})
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		removeUploadFiles(h.UploadDir, []models.Upload{upload})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
+		return
+	}
+	
+	removeUploadFiles(h.UploadDir, replaced)
+	
+	c.JSON(http.StatusOK, user)
+}
+
+// avatarThumbnail decodes an image file and scales its centred square down to
+// avatarSize. Smaller images are cropped but not enlarged.
+func avatarThumbnail(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		return nil, errAvatarDimensions
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(bounds.Min).
+		Add(image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2))
+	size := min(side, avatarSize)
+	
+	// Each thumbnail pixel averages the block of source pixels it covers
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0 := crop.Min.Y + y*side/size
+		y1 := crop.Min.Y + (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0 := crop.Min.X + x*side/size
+			x1 := crop.Min.X + (x+1)*side/size
+			
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			// RGBA returns alpha-premultiplied values, NRGBA stores them without
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r * 0xff / a)
+			dst.Pix[offset+1] = uint8(g * 0xff / a)
+			dst.Pix[offset+2] = uint8(b * 0xff / a)
+			dst.Pix[offset+3] = uint8((a / n) >> 8)
+		}
+	}
+	return dst, nil
+}
+
+// writeThumbnail encodes a thumbnail to path as JPEG or PNG and returns the
+// size of the file. The file is removed again if encoding fails.
+func writeThumbnail(path string, img image.Image, mimeType string) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	
+	if mimeType == "image/jpeg" {
+		err = jpeg.Encode(file, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(file, img)
+	}
+	var info os.FileInfo
+	if err == nil {
+		info, err = file.Stat()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return info.Size(), nil
+}
\ No newline at end of file
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+)
+
+func writePNG(t *testing.T, img image.Image) string {
+	t.Helper()
+	
+	path := filepath.Join(t.TempDir(), "image.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAvatarThumbnailCropsAndScales(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 600, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 600; x++ {
+			src.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	
+	thumbnail, err := avatarThumbnail(writePNG(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := thumbnail.Bounds(); bounds.Dx() != avatarSize || bounds.Dy() != avatarSize {
+		t.Errorf("expected %dx%d, got %v", avatarSize, avatarSize, bounds)
+	}
+	if got := color.NRGBAModel.Convert(thumbnail.At(10, 10)); got != (color.NRGBA{R: 200, G: 100, B: 50, A: 255}) {
+		t.Errorf("expected the source color, got %v", got)
+	}
+}
+
+func TestAvatarThumbnailRejectsHugeDimensions(t *testing.T) {
+	path := writePNG(t, image.NewGray(image.Rect(0, 0, 4097, 4096)))
+	if _, err := avatarThumbnail(path); !errors.Is(err, errAvatarDimensions) {
+		t.Errorf("expected errAvatarDimensions, got %v", err)
+	}
+}
+
+func TestAvatarsMustBeReadableImages(t *testing.T) {
+	// Invalid images are turned away before the database is used
+	h := newTestUploadHandler(t, nil, func(cfg *config.Config) {
+		cfg.UploadTypes = []string{"image/png", "image/webp"}
+	})
+	
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated PNG", pngHeader},
+		{"WebP", webpHeader},
+		{"text", []byte("not an image")},
+	}
+	for _, tt := range tests {
+		w := serveRequest(h.UploadAvatar, "/upload", uploadRequest(t, tt.data), "user-1")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.name, w.Code)
+		}
+	}
+	if files := uploadedFiles(t, h); len(files) != 0 {
+		t.Errorf("expected nothing to be saved, got %v", files)
+	}
+}
+
+func TestAvatarsAreSetAndReplaced(t *testing.T) {
+	db := testDB(t)
+	h := newTestUploadHandler(t, db, nil)
+	user := createUser(t, db)
+	avatar, err := os.ReadFile(writePNG(t, image.NewGray(image.Rect(0, 0, 300, 300))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	setAvatar := func() string {
+		t.Helper()
+		w := serveRequest(h.UploadAvatar, "/upload", uploadRequest(t, avatar), user.ID)
+		expectStatus(t, w, http.StatusOK)
+		var response models.User
+		decode(t, w, &response)
+		if response.AvatarURL == nil {
+			t.Fatal("expected the response to include the new avatar")
+		}
+		var stored models.User
+		if err := db.First(&stored, "id = ?", user.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.AvatarURL == nil || *stored.AvatarURL != *response.AvatarURL {
+			t.Errorf("expected the avatar %s to be stored, got %v", *response.AvatarURL, stored.AvatarURL)
+		}
+		return *response.AvatarURL
+	}
+	
+	first := setAvatar()
+	if files := uploadedFiles(t, h); len(files) != 1 || "/uploads/"+files[0] != first {
+		t.Errorf("expected only %s to be saved, got %v", first, files)
+	}
+	
+	second := setAvatar()
+	if second == first {
+		t.Fatal("expected the new avatar to get its own file")
+	}
+	// The previous avatar's file and upload record go away with it
+	if files := uploadedFiles(t, h); len(files) != 1 || "/uploads/"+files[0] != second {
+		t.Errorf("expected only %s to be left, got %v", second, files)
+	}
+	var uploads int64
+	db.Model(&models.Upload{}).Where("user_id = ?", user.ID).Count(&uploads)
+	if uploads != 1 {
+		t.Errorf("expected 1 upload record, got %d", uploads)
+	}
+	
+	// An avatar a recipe also shows is kept when it is replaced
+	createRecipe(t, db, user, createCategory(t, db), func(recipe *models.Recipe) {
+		recipe.Images = []models.RecipeImage{{ImageURL: second}}
+	})
+	third := setAvatar()
+	if files := uploadedFiles(t, h); len(files) != 2 {
+		t.Errorf("expected %s and %s to be kept, got %v", second, third, files)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// SaveBookmark bookmarks a recipe with a private note, or updates the note of
+// an existing bookmark. An empty note clears it.
+func (h *RecipeHandler) SaveBookmark(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var bookmarkInput struct {
+		Note string `json:"note" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&bookmarkInput); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	var note *string
+	if trimmed := strings.TrimSpace(bookmarkInput.Note); trimmed != "" {
+		note = &trimmed
+	}
+	
+	var bookmark models.Bookmark
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&bookmark).Error; err != nil {
+		bookmark = models.Bookmark{
+			UserID:   userID.(string),
+			RecipeID: recipeID,
+			Note:     note,
+		}
+		
+		if err := h.db(c).Create(&bookmark).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark recipe"})
+			return
+		}
+		
+		c.JSON(http.StatusCreated, bookmark)
+		return
+	}
+	
+	if err := h.db(c).Model(&bookmark).Update("note", note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookmark"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, bookmark)
+}
+
+// GetBookmarks lists the user's bookmarks with their notes, newest first.
+// Bookmarks of recipes that were deleted or unpublished are left out.
+func (h *RecipeHandler) GetBookmarks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	
+	query := h.db(c).Model(&models.Bookmark{}).
+		Joins("JOIN recipes ON recipes.id = bookmarks.recipe_id AND recipes.deleted_at IS NULL").
+		Where("bookmarks.user_id = ? AND (recipes.is_published = ? OR recipes.user_id = ?)", userID, true, userID)
+	
+	var total int64
+	query.Count(&total)
+	
+	var bookmarks []models.Bookmark
+	if err := query.Preload("Recipe").Preload("Recipe.User").Preload("Recipe.Images").
+		Order("bookmarks.created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&bookmarks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, newPaginatedResponse(bookmarks, total, page, limit))
+}
\ No newline at end of file
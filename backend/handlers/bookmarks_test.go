@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookmarkNotes(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	reader := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	
+	save := func(note string) *httptest.ResponseRecorder {
+		return serve(h.SaveBookmark, "PUT", "/bookmarks/:id", "/bookmarks/"+recipe.ID, reader.ID, gin.H{"note": note})
+	}
+	listedNote := func() *string {
+		t.Helper()
+		w := serve(h.GetBookmarks, "GET", "/bookmarks", "/bookmarks", reader.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+		
+		var bookmarks PaginatedResponse[models.Bookmark]
+		decode(t, w, &bookmarks)
+		if len(bookmarks.Data) != 1 || bookmarks.Data[0].RecipeID != recipe.ID {
+			t.Fatalf("expected the one bookmark to be listed, got %+v", bookmarks.Data)
+		}
+		return bookmarks.Data[0].Note
+	}
+	
+	expectStatus(t, save("  Try with lime  "), http.StatusCreated)
+	if note := listedNote(); note == nil || *note != "Try with lime" {
+		t.Errorf("expected the trimmed note, got %v", note)
+	}
+	
+	expectStatus(t, save("Halve the chili"), http.StatusOK)
+	if note := listedNote(); note == nil || *note != "Halve the chili" {
+		t.Errorf("expected the updated note, got %v", note)
+	}
+	
+	expectStatus(t, save(""), http.StatusOK)
+	if note := listedNote(); note != nil {
+		t.Errorf("expected an empty note to clear it, got %q", *note)
+	}
+	
+	var count int64
+	db.Model(&models.Bookmark{}).Where("user_id = ?", reader.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("expected saving again to keep a single bookmark, got %d", count)
+	}
+}
\ No newline at end of file
@@ -2,71 +2,267 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 	
+	"food-recipes-backend/config"
 	"food-recipes-backend/models"
 	
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"gorm.io/gorm"
 )
 
 type CategoryHandler struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Config *config.Config
+	
+	// Categories rarely change, so the list is cached for CategoryCacheTTL
+	cacheMu            sync.RWMutex
+	cached             []models.Category
+	cachedTranslations map[string]map[string]models.CategoryTranslation
+	cacheExpiresAt     time.Time
 }
 
-func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
-	return &CategoryHandler{DB: db}
+func NewCategoryHandler(db *gorm.DB, cfg *config.Config) *CategoryHandler {
+	return &CategoryHandler{DB: db, Config: cfg}
 }
 
-func (h *CategoryHandler) GetCategories(c *gin.Context) {
+// InvalidateCache drops the cached category list. Call it after categories are
+// created, updated or deleted.
+func (h *CategoryHandler) InvalidateCache() {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	
+	h.cached = nil
+	h.cachedTranslations = nil
+	h.cacheExpiresAt = time.Time{}
+}
+
+// loadCategories returns the cached category list and its translations keyed
+// by locale and category ID, refreshing both once the TTL has passed.
+func (h *CategoryHandler) loadCategories(c *gin.Context) ([]models.Category, map[string]map[string]models.CategoryTranslation, error) {
+	h.cacheMu.RLock()
+	if h.cached != nil && time.Now().Before(h.cacheExpiresAt) {
+		categories, translations := h.cached, h.cachedTranslations
+		h.cacheMu.RUnlock()
+		return categories, translations, nil
+	}
+	h.cacheMu.RUnlock()
+	
 	var categories []models.Category
+	if err := h.db(c).Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
 	
-	if err := h.DB.Find(&categories).Error; err != nil {
+	var rows []models.CategoryTranslation
+	if err := h.db(c).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	translations := make(map[string]map[string]models.CategoryTranslation)
+	for _, row := range rows {
+		if translations[row.Locale] == nil {
+			translations[row.Locale] = make(map[string]models.CategoryTranslation)
+		}
+		translations[row.Locale][row.CategoryID] = row
+	}
+	
+	h.cacheMu.Lock()
+	h.cached = categories
+	h.cachedTranslations = translations
+	h.cacheExpiresAt = time.Now().Add(time.Duration(h.Config.CategoryCacheTTL) * time.Second)
+	h.cacheMu.Unlock()
+	
+	return categories, translations, nil
+}
+
+// db returns the handler's database bound to the request context.
+func (h *CategoryHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, translations, err := h.loadCategories(c)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, categories)
+	c.Header("Vary", "Accept-Language")
+	
+	locale := pickLocale(requestedLocales(c), translations)
+	if locale == "" {
+		c.JSON(http.StatusOK, categories)
+		return
+	}
+	
+	// Translate a copy so the cached defaults stay untouched. Categories
+	// without a translation keep their default text.
+	localized := make([]models.Category, len(categories))
+	for i, category := range categories {
+		if translation, ok := translations[locale][category.ID]; ok {
+			category.Name = translation.Name
+			if translation.Description != nil {
+				category.Description = translation.Description
+			}
+		}
+		localized[i] = category
+	}
+	
+	c.Header("Content-Language", locale)
+	c.JSON(http.StatusOK, localized)
+}
+
+// categoryRecipesResponse is a page of a category's recipes along with the
+// category itself.
+type categoryRecipesResponse struct {
+	PaginatedResponse[recipeListItem]
+	Category models.Category `json:"category"`
 }
 
+// GetCategoryRecipes lists a category's published recipes. It accepts the same
+// filters, sorts and pagination as GetRecipes.
 func (h *CategoryHandler) GetCategoryRecipes(c *gin.Context) {
-	categoryID := c.Param("id")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
-	
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 50 {
-		limit = 12
+	var filters models.SearchFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	filters.CategoryID = c.Param("id")
 	
-	offset := (page - 1) * limit
+	order, err := prepareSearchFilters(h.Config, &filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	var category models.Category
-	if err := h.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+	if err := h.db(c).First(&category, "id = ?", filters.CategoryID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 	
-	var recipes []models.Recipe
-	var total int64
+	recipes, total, err := searchRecipes(h.db(c), publishedRecipes(h.db(c)), filters, order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
+	}
 	
-	h.DB.Model(&models.Recipe{}).Where("category_id = ? AND is_published = ?", categoryID, true).Count(&total)
+	userID, _ := c.Get("user_id")
 	
-	if err := h.DB.Preload("User").Preload("Category").Preload("Images").
-		Where("category_id = ? AND is_published = ?", categoryID, true).
-		Offset(offset).Limit(limit).
-		Order("created_at DESC").Find(&recipes).Error; err != nil {
+	setPaginationHeaders(c, total, filters.Page, filters.Limit)
+	c.JSON(http.StatusOK, categoryRecipesResponse{
+		PaginatedResponse: newPaginatedResponse(withOwnership(recipes, userID), total, filters.Page, filters.Limit),
+		Category:          category,
+	})
+}
+
+// GetRecipesByCategories returns the most recent published recipes of several
+// categories in one request, keyed by category ID, for pages showing a row per
+// category. Up to 20 categories can be requested; every one of them gets an
+// entry, possibly empty.
+func (h *CategoryHandler) GetRecipesByCategories(c *gin.Context) {
+	var input struct {
+		CategoryIDs []string `form:"category_ids" binding:"min=1,max=20,dive,uuid"`
+	}
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(c.Query("category_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			input.CategoryIDs = append(input.CategoryIDs, id)
+		}
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	_, limit = normalizePagination(h.Config, 1, limit)
+	
+	// Rank each category's recipes and keep the top ones in a single query
+	ranked := h.db(c).Model(&models.Recipe{}).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY category_id ORDER BY created_at DESC) AS position").
+		Where("is_published = ? AND category_id IN ?", true, input.CategoryIDs)
+	var recipes []models.Recipe
+	if err := h.db(c).Preload("User").Preload("Category").Preload("Images").
+		Where("id IN (?)", h.db(c).Table("(?) AS ranked", ranked).Select("id").Where("position <= ?", limit)).
+		Order("created_at DESC").
+		Find(&recipes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
 		return
 	}
 	
+	userID, _ := c.Get("user_id")
+	rows := make(map[string][]recipeListItem, len(input.CategoryIDs))
+	for _, id := range input.CategoryIDs {
+		rows[id] = []recipeListItem{}
+	}
+	for _, item := range withOwnership(recipes, userID) {
+		rows[item.CategoryID] = append(rows[item.CategoryID], item)
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
-		"category": category,
-		"recipes":  recipes,
-		"total":    total,
-		"page":     page,
-		"limit":    limit,
-		"pages":    (int(total) + limit - 1) / limit,
+		"categories": rows,
+		"limit":      limit,
 	})
+}
+
+// requestedLocales lists the locales a client asked for, most preferred first.
+// The lang query parameter wins over the Accept-Language header.
+func requestedLocales(c *gin.Context) []string {
+	if lang := strings.TrimSpace(c.Query("lang")); lang != "" {
+		return []string{strings.ToLower(lang)}
+	}
+	
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		fields := strings.Split(part, ";")
+		locale := strings.ToLower(strings.TrimSpace(fields[0]))
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			entries = append(entries, weighted{locale, q})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+	
+	locales := make([]string, len(entries))
+	for i, entry := range entries {
+		locales[i] = entry.locale
+	}
+	return locales
+}
+
+// pickLocale returns the first requested locale that has translations, trying
+// the base language ("fr" for "fr-ca") as well. It returns "" when none match.
+func pickLocale(requested []string, translations map[string]map[string]models.CategoryTranslation) string {
+	for _, locale := range requested {
+		if _, ok := translations[locale]; ok {
+			return locale
+		}
+		if base, _, found := strings.Cut(locale, "-"); found {
+			if _, ok := translations[base]; ok {
+				return base
+			}
+		}
+	}
+	return ""
 }
\ No newline at end of file
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCategoryListIsCachedUntilInvalidated(t *testing.T) {
+	db := testDB(t)
+	log := &statementLog{Interface: logger.Discard}
+	h := NewCategoryHandler(db.Session(&gorm.Session{Logger: log}), testConfig())
+	createCategory(t, db)
+	
+	list := func() []models.Category {
+		t.Helper()
+		w := serve(h.GetCategories, "GET", "/categories", "/categories", "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var categories []models.Category
+		decode(t, w, &categories)
+		return categories
+	}
+	
+	if got := list(); len(got) != 1 {
+		t.Fatalf("expected 1 category, got %d", len(got))
+	}
+	queries := len(log.statements)
+	if queries == 0 {
+		t.Fatal("expected the first listing to query the database")
+	}
+	
+	added := createCategory(t, db)
+	if got := list(); len(got) != 1 || len(log.statements) != queries {
+		t.Errorf("expected the cached list without new queries, got %d categories after %d queries",
+			len(got), len(log.statements)-queries)
+	}
+	
+	h.InvalidateCache()
+	got := list()
+	if len(got) != 2 || len(log.statements) == queries {
+		t.Fatalf("expected a fresh list after invalidation, got %d categories", len(got))
+	}
+	if got[0].ID != added.ID && got[1].ID != added.ID {
+		t.Errorf("expected %q in the refreshed list", added.Name)
+	}
+}
+
+func TestCategoryCacheExpires(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.CategoryCacheTTL = 0
+	h := NewCategoryHandler(db, cfg)
+	
+	createCategory(t, db)
+	expectStatus(t, serve(h.GetCategories, "GET", "/categories", "/categories", "", nil), http.StatusOK)
+	createCategory(t, db)
+	
+	w := serve(h.GetCategories, "GET", "/categories", "/categories", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var categories []models.Category
+	decode(t, w, &categories)
+	if len(categories) != 2 {
+		t.Errorf("expected an expired cache to be refreshed, got %d categories", len(categories))
+	}
+}
+
+func TestRequestedLocales(t *testing.T) {
+	tests := []struct {
+		target, header string
+		want           []string
+	}{
+		{"/categories", "am-ET, en;q=0.5, fr;q=0.8", []string{"am-et", "fr", "en"}},
+		{"/categories", "de;q=0, *, es", []string{"es"}},
+		{"/categories?lang=AM", "fr", []string{"am"}},
+		{"/categories", "", []string{}},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", tt.target, nil)
+		c.Request.Header.Set("Accept-Language", tt.header)
+		if got := requestedLocales(c); !slices.Equal(got, tt.want) {
+			t.Errorf("%s with %q: got %v, want %v", tt.target, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPickLocaleFallsBackToTheBaseLanguage(t *testing.T) {
+	translations := map[string]map[string]models.CategoryTranslation{"am": {}, "fr-ca": {}}
+	tests := []struct {
+		requested []string
+		want      string
+	}{
+		{[]string{"am-et"}, "am"},
+		{[]string{"fr-ca", "am"}, "fr-ca"},
+		{[]string{"fr", "am"}, "am"},
+		{[]string{"de"}, ""},
+	}
+	for _, tt := range tests {
+		if got := pickLocale(tt.requested, translations); got != tt.want {
+			t.Errorf("pickLocale(%v) = %q, want %q", tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestCategoriesAreTranslated(t *testing.T) {
+	db := testDB(t)
+	h := NewCategoryHandler(db, testConfig())
+	translated := createCategory(t, db)
+	untranslated := createCategory(t, db)
+	description := "የጾም ምግቦች"
+	if err := db.Create(&models.CategoryTranslation{CategoryID: translated.ID, Locale: "am", Name: "ጾም", Description: &description}).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	list := func(header string) (map[string]models.Category, *httptest.ResponseRecorder) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/categories", nil)
+		req.Header.Set("Accept-Language", header)
+		w := serveRequest(h.GetCategories, "/categories", req, "")
+		expectStatus(t, w, http.StatusOK)
+		
+		var categories []models.Category
+		decode(t, w, &categories)
+		byID := make(map[string]models.Category)
+		for _, category := range categories {
+			byID[category.ID] = category
+		}
+		return byID, w
+	}
+	
+	categories, w := list("am-ET, en;q=0.5")
+	if w.Header().Get("Content-Language") != "am" {
+		t.Errorf("expected Content-Language am, got %q", w.Header().Get("Content-Language"))
+	}
+	if got := categories[translated.ID]; got.Name != "ጾም" || got.Description == nil || *got.Description != description {
+		t.Errorf("expected the Amharic translation, got %+v", got)
+	}
+	if got := categories[untranslated.ID]; got.Name != untranslated.Name {
+		t.Errorf("expected an untranslated category to keep its name, got %q", got.Name)
+	}
+	
+	categories, w = list("de")
+	if w.Header().Get("Content-Language") != "" || categories[translated.ID].Name != translated.Name {
+		t.Errorf("expected the default names for an unsupported locale, got %q", categories[translated.ID].Name)
+	}
+}
+
+func TestCategoryRecipesShareTheSearchFilters(t *testing.T) {
+	db := testDB(t)
+	h := NewCategoryHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	priced := func(price float64, prep int) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) {
+			r.Price = price
+			r.PreparationTime = prep
+		})
+	}
+	free := priced(0, 10)
+	slowFree := priced(0, 90)
+	paid := priced(15, 10)
+	createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	list := func(query string) []string {
+		t.Helper()
+		target := "/categories/" + category.ID + "/recipes?" + query
+		w := serve(h.GetCategoryRecipes, "GET", "/categories/:id/recipes", target, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		
+		var page categoryRecipesResponse
+		decode(t, w, &page)
+		if page.Category.ID != category.ID {
+			t.Errorf("expected the category in the response, got %+v", page.Category)
+		}
+		ids := make([]string, len(page.Data))
+		for i, recipe := range page.Data {
+			ids[i] = recipe.ID
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	sorted := func(recipes ...models.Recipe) []string {
+		ids := make([]string, len(recipes))
+		for i, recipe := range recipes {
+			ids[i] = recipe.ID
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", sorted(free, slowFree, paid)},
+		{"free_only=true", sorted(free, slowFree)},
+		{"free_only=true&max_total_time=40", sorted(free)},
+		{"min_price=1", sorted(paid)},
+	}
+	for _, tt := range tests {
+		if got := list(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+	
+	w := serve(h.GetCategoryRecipes, "GET", "/categories/:id/recipes", "/categories/"+category.ID+"/recipes?min_price=9&max_price=1", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestRecipesByCategoriesRejectsBadIDs(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewCategoryHandler(nil, testConfig())
+	for _, query := range []string{"", "category_ids=", "category_ids=soups", "limit=3"} {
+		w := serve(h.GetRecipesByCategories, "GET", "/categories/recipes", "/categories/recipes?"+query, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestRecipesByCategoriesLimitsEachCategory(t *testing.T) {
+	db := testDB(t)
+	h := NewCategoryHandler(db, testConfig())
+	author := createUser(t, db)
+	soups := createCategory(t, db)
+	salads := createCategory(t, db)
+	empty := createCategory(t, db)
+	
+	start := time.Now().Add(-time.Hour)
+	created := func(category models.Category, minutes int, published bool) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) {
+			r.CreatedAt = start.Add(time.Duration(minutes) * time.Minute)
+			r.IsPublished = published
+		})
+	}
+	created(soups, 1, true)
+	created(soups, 2, true)
+	newer := created(soups, 3, true)
+	newest := created(soups, 4, true)
+	salad := created(salads, 1, true)
+	created(salads, 5, false)
+	
+	target := "/categories/recipes?limit=2&category_ids=" + soups.ID + "," + salads.ID + "," + empty.ID
+	w := serve(h.GetRecipesByCategories, "GET", "/categories/recipes", target, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var response struct {
+		Categories map[string][]recipeListItem `json:"categories"`
+		Limit      int                         `json:"limit"`
+	}
+	decode(t, w, &response)
+	
+	ids := func(items []recipeListItem) []string {
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		return ids
+	}
+	tests := []struct {
+		category models.Category
+		want     []string
+	}{
+		{soups, []string{newest.ID, newer.ID}},
+		{salads, []string{salad.ID}},
+		{empty, []string{}},
+	}
+	for _, tt := range tests {
+		items, ok := response.Categories[tt.category.ID]
+		if !ok {
+			t.Errorf("expected category %s in the response", tt.category.Name)
+			continue
+		}
+		if got := ids(items); !slices.Equal(got, tt.want) {
+			t.Errorf("category %s: expected %v, got %v", tt.category.Name, tt.want, got)
+		}
+	}
+	if response.Limit != 2 {
+		t.Errorf("expected a limit of 2, got %d", response.Limit)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// GetChecklist returns the ingredients and steps the user has checked off for
+// a recipe. A recipe they haven't started yet has empty lists.
+func (h *RecipeHandler) GetChecklist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	progress := models.UserRecipeProgress{
+		RecipeID:           recipeID,
+		CheckedIngredients: []string{},
+		CheckedSteps:       []string{},
+	}
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).Limit(1).Find(&progress).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, progress)
+}
+
+// SaveChecklist replaces the user's checklist for a recipe. Only IDs of the
+// recipe's own ingredients and steps are accepted.
+func (h *RecipeHandler) SaveChecklist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var checklistInput struct {
+		IngredientIDs []string `json:"checked_ingredients" binding:"max=500,dive,uuid"`
+		StepIDs       []string `json:"checked_steps" binding:"max=500,dive,uuid"`
+	}
+	if err := c.ShouldBindJSON(&checklistInput); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	ingredientIDs, err := h.recipeItemIDs(c, &models.Ingredient{}, recipeID, checklistInput.IngredientIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save checklist"})
+		return
+	}
+	stepIDs, err := h.recipeItemIDs(c, &models.Step{}, recipeID, checklistInput.StepIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save checklist"})
+		return
+	}
+	if len(ingredientIDs) != len(uniqueStrings(checklistInput.IngredientIDs)) || len(stepIDs) != len(uniqueStrings(checklistInput.StepIDs)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist contains ingredients or steps of another recipe"})
+		return
+	}
+	
+	progress := models.UserRecipeProgress{
+		UserID:             userID.(string),
+		RecipeID:           recipeID,
+		CheckedIngredients: ingredientIDs,
+		CheckedSteps:       stepIDs,
+	}
+	if err := h.db(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "recipe_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"checked_ingredients", "checked_steps", "updated_at"}),
+	}).Create(&progress).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save checklist"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, progress)
+}
+
+// recipeItemIDs returns which of ids belong to the recipe's ingredients or
+// steps, depending on model.
+func (h *RecipeHandler) recipeItemIDs(c *gin.Context, model interface{}, recipeID string, ids []string) ([]string, error) {
+	found := []string{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+	err := h.db(c).Model(model).Where("recipe_id = ? AND id IN ?", recipeID, ids).Pluck("id", &found).Error
+	return found, err
+}
+
+// uniqueStrings returns values without duplicates, keeping the first occurrence.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
\ No newline at end of file
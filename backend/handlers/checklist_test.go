@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestChecklistIsSavedPerUser(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	cook := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), category, func(r *models.Recipe) {
+		r.Ingredients = []models.Ingredient{{Name: "lentils"}, {Name: "onion"}}
+		r.Steps = []models.Step{{StepNumber: 1, Instruction: "Chop the onion"}, {StepNumber: 2, Instruction: "Simmer"}}
+	})
+	foreign := createRecipe(t, db, createUser(t, db), category, func(r *models.Recipe) {
+		r.Ingredients = []models.Ingredient{{Name: "rice"}}
+	})
+	lentils, onion := recipe.Ingredients[0].ID, recipe.Ingredients[1].ID
+	chop := recipe.Steps[0].ID
+	target := "/recipes/" + recipe.ID + "/checklist"
+	
+	save := func(userID string, ingredients, steps []string) int {
+		body := gin.H{"checked_ingredients": ingredients, "checked_steps": steps}
+		return serve(h.SaveChecklist, "POST", "/recipes/:id/checklist", target, userID, body).Code
+	}
+	expectChecklist := func(userID string, ingredients, steps []string) {
+		t.Helper()
+		w := serve(h.GetChecklist, "GET", "/recipes/:id/checklist", target, userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var progress models.UserRecipeProgress
+		decode(t, w, &progress)
+		slices.Sort(progress.CheckedIngredients)
+		slices.Sort(ingredients)
+		if !slices.Equal(progress.CheckedIngredients, ingredients) || !slices.Equal(progress.CheckedSteps, steps) {
+			t.Errorf("expected ingredients %v and steps %v checked, got %v and %v",
+				ingredients, steps, progress.CheckedIngredients, progress.CheckedSteps)
+		}
+	}
+	
+	expectChecklist(cook.ID, []string{}, []string{})
+	
+	if code := save(cook.ID, []string{lentils, lentils}, []string{chop}); code != http.StatusOK {
+		t.Fatalf("expected the checklist to be saved, got %d", code)
+	}
+	expectChecklist(cook.ID, []string{lentils}, []string{chop})
+	expectChecklist(other.ID, []string{}, []string{})
+	
+	if code := save(cook.ID, []string{lentils, onion}, nil); code != http.StatusOK {
+		t.Fatalf("expected the checklist to be replaced, got %d", code)
+	}
+	expectChecklist(cook.ID, []string{lentils, onion}, []string{})
+	
+	if code := save(cook.ID, []string{foreign.Ingredients[0].ID}, nil); code != http.StatusBadRequest {
+		t.Errorf("expected ingredients of another recipe to be rejected, got %d", code)
+	}
+	if code := save(cook.ID, []string{"lentils"}, nil); code != http.StatusBadRequest {
+		t.Errorf("expected malformed IDs to be rejected, got %d", code)
+	}
+	expectChecklist(cook.ID, []string{lentils, onion}, []string{})
+}
+
+func TestDeletingAnAccountRemovesItsChecklists(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	auth := NewAuthHandler(db, testConfig())
+	cook := createUser(t, db)
+	setPassword(t, db, &cook, "correct horse")
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), func(r *models.Recipe) {
+		r.Ingredients = []models.Ingredient{{Name: "lentils"}}
+	})
+	
+	body := gin.H{"checked_ingredients": []string{recipe.Ingredients[0].ID}, "checked_steps": []string{}}
+	w := serve(h.SaveChecklist, "POST", "/recipes/:id/checklist", "/recipes/"+recipe.ID+"/checklist", cook.ID, body)
+	expectStatus(t, w, http.StatusOK)
+	
+	w = serve(auth.DeleteAccount, "DELETE", "/auth/account", "/auth/account", cook.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var remaining int64
+	db.Model(&models.UserRecipeProgress{}).Where("user_id = ?", cook.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected the deleted account's checklists to be gone, got %d", remaining)
+	}
+}
\ No newline at end of file
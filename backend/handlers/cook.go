@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// cookStep is a step prepared for the hands-free cooking view.
+type cookStep struct {
+	models.Step
+	TimerSeconds *int                `json:"timer_seconds"`
+	Timers       []utils.Timer       `json:"timers"`
+	Ingredients  []models.Ingredient `json:"ingredients"`
+}
+
+// GetCookMode returns a recipe's steps for cooking mode. Each step carries the
+// timers found in its instruction, with timer_seconds set to the first one, and
+// the ingredients the instruction mentions by name.
+func (h *RecipeHandler) GetCookMode(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	
+	recipe, err := h.findVisibleRecipe(c, c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	if !h.hasAccess(c, recipe, userID) {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Purchase this recipe to use cooking mode"})
+		return
+	}
+	
+	if err := h.db(c).Model(recipe).Preload("Ingredients").Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("steps.step_number ASC")
+	}).First(recipe).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipe steps"})
+		return
+	}
+	
+	steps := make([]cookStep, 0, len(recipe.Steps))
+	for _, step := range recipe.Steps {
+		cook := cookStep{
+			Step:        step,
+			Timers:      utils.ParseTimers(step.Instruction),
+			Ingredients: []models.Ingredient{},
+		}
+		if len(cook.Timers) > 0 {
+			cook.TimerSeconds = &cook.Timers[0].Seconds
+		}
+		
+		instruction := strings.ToLower(step.Instruction)
+		for _, ingredient := range recipe.Ingredients {
+			name := strings.ToLower(strings.TrimSpace(ingredient.Name))
+			if name != "" && strings.Contains(instruction, name) {
+				cook.Ingredients = append(cook.Ingredients, ingredient)
+			}
+		}
+		
+		steps = append(steps, cook)
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipe_id":   recipe.ID,
+		"title":       recipe.Title,
+		"servings":    recipe.Servings,
+		"total_time":  recipe.TotalTime,
+		"ingredients": recipe.Ingredients,
+		"steps":       steps,
+	})
+}
\ No newline at end of file
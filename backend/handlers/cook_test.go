@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	
+	"food-recipes-backend/models"
+)
+
+func TestCookModeParsesTimersBehindThePaywall(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	buyer := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) {
+		r.Price = 20
+		r.Ingredients = []models.Ingredient{{Name: "Lentils"}, {Name: "onion"}}
+		r.Steps = []models.Step{
+			{StepNumber: 2, Instruction: "Add the lentils and simmer for 15 minutes"},
+			{StepNumber: 1, Instruction: "Chop the onion"},
+		}
+	})
+	target := "/recipes/" + recipe.ID + "/cook"
+	
+	w := serve(h.GetCookMode, "GET", "/recipes/:id/cook", target, "", nil)
+	expectStatus(t, w, http.StatusPaymentRequired)
+	w = serve(h.GetCookMode, "GET", "/recipes/:id/cook", target, buyer.ID, nil)
+	expectStatus(t, w, http.StatusPaymentRequired)
+	
+	createPurchase(t, db, buyer, recipe, "completed")
+	w = serve(h.GetCookMode, "GET", "/recipes/:id/cook", target, buyer.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	var body struct {
+		Steps []cookStep `json:"steps"`
+	}
+	decode(t, w, &body)
+	if len(body.Steps) != 2 || body.Steps[0].StepNumber != 1 {
+		t.Fatalf("expected both steps in order, got %+v", body.Steps)
+	}
+	
+	chop, simmer := body.Steps[0], body.Steps[1]
+	if chop.TimerSeconds != nil || len(chop.Timers) != 0 {
+		t.Errorf("expected no timer on a step without a duration, got %+v", chop.Timers)
+	}
+	if simmer.TimerSeconds == nil || *simmer.TimerSeconds != 900 {
+		t.Errorf("expected a 900 second timer, got %v", simmer.TimerSeconds)
+	}
+	if len(chop.Ingredients) != 1 || chop.Ingredients[0].Name != "onion" {
+		t.Errorf("expected the onion on the first step, got %+v", chop.Ingredients)
+	}
+	if len(simmer.Ingredients) != 1 || simmer.Ingredients[0].Name != "Lentils" {
+		t.Errorf("expected the lentils on the second step, got %+v", simmer.Ingredients)
+	}
+}
\ No newline at end of file
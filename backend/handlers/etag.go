@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// notModified sets an ETag derived from the JSON encoding of body and, when
+// the request's If-None-Match already names it, answers 304 Not Modified.
+// It reports whether the response has been written.
+func notModified(c *gin.Context, body interface{}) bool {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return false
+	}
+	
+	sum := sha256.Sum256(encoded)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	
+	return false
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+	for header, want := range map[string]bool{
+		`"abc"`:        true,
+		`W/"abc"`:      true,
+		`"xyz", "abc"`: true,
+		`*`:            true,
+		`"xyz"`:        false,
+		``:             false,
+		`abc`:          false,
+	} {
+		if got := etagMatches(header, etag); got != want {
+			t.Errorf("etagMatches(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestRecipeDetailAnswers304UntilEdited(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/recipes/"+recipe.ID, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return serveRequest(h.GetRecipe, "/recipes/:id", req, "")
+	}
+	
+	w := get("")
+	expectStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	
+	w = get(etag)
+	expectStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 body, got %q", w.Body)
+	}
+	
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, gin.H{"description": "Now with more garlic"})
+	expectStatus(t, w, http.StatusOK)
+	
+	w = get(etag)
+	expectStatus(t, w, http.StatusOK)
+	if w.Header().Get("ETag") == etag {
+		t.Error("expected the edit to change the ETag")
+	}
+}
\ No newline at end of file
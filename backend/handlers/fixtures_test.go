@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/testdb"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	
+	// Hashing at the lowest cost keeps the auth tests fast
+	if err := utils.SetBcryptCost(testConfig().BcryptCost); err != nil {
+		panic(err)
+	}
+	if err := utils.SetJWTExpiry(time.Duration(testConfig().JWTExpiryHours) * time.Hour); err != nil {
+		panic(err)
+	}
+}
+
+// testConfig returns fixed settings for the tests without reading the
+// environment, so tests don't depend on the shell they run in. Some values
+// differ from the defaults of config.Load, e.g. a low bcrypt cost keeps the
+// password tests fast.
+func testConfig() *config.Config {
+	return &config.Config{
+		JWTSecret:          "test-secret",
+		UploadDir:          "uploads",
+		DefaultPageSize:    20,
+		MaxPageSize:        100,
+		DeletedUserRecipes: "delete",
+		BcryptCost:         4,
+		CommentsPerMinute:  5,
+		CommentInterval:    10,
+		CommentMaxURLs:     2,
+		CommentFilterMode:  "reject",
+		UsernameCheckLimit: 30,
+		Features: map[string]bool{
+			config.FeatureComments:  true,
+			config.FeatureRatings:   true,
+			config.FeatureLikes:     true,
+			config.FeatureBookmarks: true,
+			config.FeaturePayments:  true,
+		},
+		CategoryCacheTTL: 60,
+		CompressMinBytes: 1024,
+		MaxIngredients:   100,
+		MaxSteps:         100,
+		UploadTypes:      []string{"image/jpeg", "image/png", "image/gif"},
+		MaxFeatured:      10,
+		JWTExpiryHours:   24,
+		ViewDedupMinutes: 30,
+		CommentMinLength: 1,
+		CommentMaxLength: 2000,
+		UploadsPerMinute: 10,
+		UploadsPerDay:    100,
+		WebPQuality:      80,
+		MaxUploadMB:      10,
+		MaxRecipePrice:   10000,
+		StatsCacheTTL:    300,
+	}
+}
+
+// testDB returns an empty, migrated database for the handler tests, skipping
+// the test when TEST_DATABASE_URL isn't set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return testdb.Open(t, "handlers_test")
+}
+
+// serve sends a request to a router holding only handler, registered on
+// route. A non-empty userID is set as the authenticated user, as
+// AuthMiddleware would, and a non-nil body is sent as JSON.
+func serve(handler gin.HandlerFunc, method, route, target, userID string, body interface{}) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serveRequest(handler, route, req, userID)
+}
+
+// serveRequest is serve for requests that need more than a JSON body, such as
+// extra headers.
+func serveRequest(handler gin.HandlerFunc, route string, req *http.Request, userID string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(req.Method, route, func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		handler(c)
+	})
+	
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// chain runs handlers in order like a route's middleware chain, stopping once
+// one of them aborts.
+func chain(handlers ...gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, handler := range handlers {
+			if c.IsAborted() {
+				return
+			}
+			handler(c)
+		}
+	}
+}
+
+// decode unmarshals a JSON response body, failing the test if it can't.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", w.Body, err)
+	}
+}
+
+// expectStatus fails the test unless the response has the given status.
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("expected %d, got %d: %s", status, w.Code, w.Body)
+	}
+}
+
+var fixtureSeq atomic.Int64
+
+// fixtureName returns a name no other fixture in the test run uses.
+func fixtureName(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, fixtureSeq.Add(1))
+}
+
+func createUser(t *testing.T, db *gorm.DB) models.User {
+	t.Helper()
+	
+	name := fixtureName("user")
+	user := models.User{Email: name + "@example.com", Username: name, PasswordHash: "unused"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// setPassword gives a fixture user a password they can log in with.
+func setPassword(t *testing.T, db *gorm.DB, user *models.User, password string) {
+	t.Helper()
+	
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.PasswordHash = hash
+	if err := db.Model(user).Update("password_hash", hash).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createCategory(t *testing.T, db *gorm.DB) models.Category {
+	t.Helper()
+	
+	category := models.Category{Name: fixtureName("Category ")}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	return category
+}
+
+// createRecipe stores a published recipe by user, after edit has had a chance
+// to change it.
+func createRecipe(t *testing.T, db *gorm.DB, user models.User, category models.Category, edit func(*models.Recipe)) models.Recipe {
+	t.Helper()
+	
+	recipe := models.Recipe{
+		Title:           fixtureName("Recipe "),
+		PreparationTime: 10,
+		CookingTime:     20,
+		Servings:        2,
+		DifficultyLevel: models.DifficultyEasy,
+		CategoryID:      category.ID,
+		UserID:          user.ID,
+		IsPublished:     true,
+		Allergens:       []string{},
+	}
+	if edit != nil {
+		edit(&recipe)
+	}
+	if err := db.Create(&recipe).Error; err != nil {
+		t.Fatal(err)
+	}
+	return recipe
+}
+
+// createPurchase records a purchase of recipe by user with the given status.
+func createPurchase(t *testing.T, db *gorm.DB, user models.User, recipe models.Recipe, status string) models.Purchase {
+	t.Helper()
+	
+	purchase := models.Purchase{UserID: user.ID, RecipeID: recipe.ID, Amount: recipe.Price, Status: status}
+	if err := db.Create(&purchase).Error; err != nil {
+		t.Fatal(err)
+	}
+	return purchase
+}
+
+// recipeRequest returns a valid body for CreateRecipe, which tests adjust to
+// exercise one field at a time.
+func recipeRequest(categoryID string) gin.H {
+	return gin.H{
+		"title":            fixtureName("Recipe "),
+		"description":      "A test recipe",
+		"preparation_time": 10,
+		"cooking_time":     20,
+		"servings":         2,
+		"difficulty_level": models.DifficultyEasy,
+		"category_id":      categoryID,
+		"ingredients":      []gin.H{{"name": "flour", "quantity": "200 g"}},
+		"steps":            []gin.H{{"instruction": "Mix everything"}},
+	}
+}
\ No newline at end of file
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout bounds the database ping of the health check.
+const healthCheckTimeout = 2 * time.Second
+
+// Health reports whether the server can reach its database. It stays available
+// during maintenance so monitoring can tell maintenance from an outage.
+func Health(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		defer cancel()
+		
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "database": "unreachable"})
+			return
+		}
+		
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "database": "ok"})
+	}
+}
\ No newline at end of file
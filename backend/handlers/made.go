@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// RecordMade records that the caller cooked a recipe. The optional photo must
+// be an image the caller uploaded.
+func (h *RecipeHandler) RecordMade(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var madeInput struct {
+		PhotoURL string `json:"photo_url"`
+		Note     string `json:"note" binding:"max=1000"`
+	}
+	// Both fields are optional, so an empty body just records the dish
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&madeInput); err != nil {
+			c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+			return
+		}
+	}
+	
+	recipe, err := h.findVisibleRecipe(c, recipeID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	made := models.Made{
+		UserID:   userID.(string),
+		RecipeID: recipe.ID,
+	}
+	if trimmed := strings.TrimSpace(madeInput.Note); trimmed != "" {
+		made.Note = &trimmed
+	}
+	if photoURL := strings.TrimSpace(madeInput.PhotoURL); photoURL != "" {
+		owners, err := uploaders(h.db(c), h.Config, []string{photoURL})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record recipe"})
+			return
+		}
+		if owners[photoURL] != made.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "photo_url must be an image you uploaded"})
+			return
+		}
+		made.PhotoURL = &photoURL
+	}
+	
+	if err := h.db(c).Create(&made).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record recipe"})
+		return
+	}
+	
+	var madeCount int
+	h.db(c).Model(&models.Recipe{}).Where("id = ?", recipe.ID).Select("made_count").Scan(&madeCount)
+	
+	h.db(c).Preload("User").First(&made, "id = ?", made.ID)
+	
+	c.JSON(http.StatusCreated, gin.H{
+		"made":       made,
+		"made_count": madeCount,
+	})
+}
+
+// madeGalleryResponse is a page of made-it photos with the recipe's made count.
+type madeGalleryResponse struct {
+	PaginatedResponse[models.Made]
+	MadeCount int `json:"made_count"`
+}
+
+// GetMadeGallery returns how many users made a recipe along with the photos
+// they shared, newest first.
+func (h *RecipeHandler) GetMadeGallery(c *gin.Context) {
+	recipeID := c.Param("id")
+	userID, _ := c.Get("user_id")
+	
+	recipe, err := h.findVisibleRecipe(c, recipeID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	
+	query := h.db(c).Model(&models.Made{}).Where("recipe_id = ? AND photo_url IS NOT NULL", recipe.ID)
+	
+	var total int64
+	query.Count(&total)
+	
+	photos := []models.Made{}
+	if err := query.Preload("User").Order("created_at DESC").Order("id").
+		Offset((page - 1) * limit).Limit(limit).Find(&photos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch photos"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, madeGalleryResponse{
+		PaginatedResponse: newPaginatedResponse(photos, total, page, limit),
+		MadeCount:         recipe.MadeCount,
+	})
+}
\ No newline at end of file
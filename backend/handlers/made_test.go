@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecordingMadeItEntries(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	cook := createUser(t, db)
+	photographer := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	target := "/recipes/" + recipe.ID + "/made"
+	
+	upload := models.Upload{UserID: photographer.ID, Filename: fixtureName("dish") + ".jpg", MimeType: "image/jpeg", Size: 1}
+	if err := db.Create(&upload).Error; err != nil {
+		t.Fatal(err)
+	}
+	photoURL := "/uploads/" + upload.Filename
+	
+	record := func(userID string, body interface{}, status, count int) {
+		t.Helper()
+		w := serve(h.RecordMade, "POST", "/recipes/:id/made", target, userID, body)
+		expectStatus(t, w, status)
+		if status != http.StatusCreated {
+			return
+		}
+		var response struct {
+			Made      models.Made `json:"made"`
+			MadeCount int         `json:"made_count"`
+		}
+		decode(t, w, &response)
+		if response.MadeCount != count || response.Made.UserID != userID {
+			t.Errorf("expected a made count of %d for an entry by %s, got %d for %s",
+				count, userID, response.MadeCount, response.Made.UserID)
+		}
+	}
+	
+	record(cook.ID, nil, http.StatusCreated, 1)
+	// Cooking it again doesn't count twice
+	record(cook.ID, gin.H{"note": "Even better the second time"}, http.StatusCreated, 1)
+	record(cook.ID, gin.H{"photo_url": photoURL}, http.StatusBadRequest, 0)
+	record(photographer.ID, gin.H{"photo_url": photoURL, "note": "  Added chili  "}, http.StatusCreated, 2)
+	
+	w := serve(h.GetMadeGallery, "GET", "/recipes/:id/made", target, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var gallery madeGalleryResponse
+	decode(t, w, &gallery)
+	if gallery.MadeCount != 2 {
+		t.Errorf("expected a made count of 2, got %d", gallery.MadeCount)
+	}
+	if len(gallery.Data) != 1 || gallery.Total != 1 {
+		t.Fatalf("expected only the entry with a photo in the gallery, got %+v", gallery.Data)
+	}
+	if entry := gallery.Data[0]; *entry.PhotoURL != photoURL || entry.Note == nil || *entry.Note != "Added chili" {
+		t.Errorf("expected the photo and trimmed note, got %+v", entry)
+	}
+	if entry := gallery.Data[0]; entry.User.Username != photographer.Username {
+		t.Errorf("expected the photo to show its cook, got %+v", entry.User)
+	}
+	// The gallery is public, so it must not reveal the cooks' emails
+	if strings.Contains(w.Body.String(), photographer.Email) {
+		t.Errorf("expected no emails in the gallery, got %s", w.Body)
+	}
+}
+
+func TestDeletingAnAccountRemovesItsMadeItEntries(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	auth := NewAuthHandler(db, testConfig())
+	cook := createUser(t, db)
+	setPassword(t, db, &cook, "correct horse")
+	other := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	target := "/recipes/" + recipe.ID + "/made"
+	
+	upload := models.Upload{UserID: cook.ID, Filename: fixtureName("dish") + ".jpg", MimeType: "image/jpeg", Size: 1}
+	if err := db.Create(&upload).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := serve(h.RecordMade, "POST", "/recipes/:id/made", target, cook.ID, gin.H{"photo_url": "/uploads/" + upload.Filename})
+	expectStatus(t, w, http.StatusCreated)
+	w = serve(h.RecordMade, "POST", "/recipes/:id/made", target, other.ID, nil)
+	expectStatus(t, w, http.StatusCreated)
+	
+	w = serve(auth.DeleteAccount, "DELETE", "/auth/account", "/auth/account", cook.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var entries int64
+	db.Unscoped().Model(&models.Made{}).Where("user_id = ?", cook.ID).Count(&entries)
+	if entries != 0 {
+		t.Errorf("expected the deleted account's entries to be gone, got %d", entries)
+	}
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.MadeCount != 1 {
+		t.Errorf("expected a made count of 1, got %d", stored.MadeCount)
+	}
+	// The photo isn't used anywhere else, so its upload goes with the account
+	if err := db.First(&models.Upload{}, "id = ?", upload.ID).Error; err == nil {
+		t.Error("expected the photo's upload to be cleaned up")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// nutrientNames lists the nutrients tracked per ingredient, in the order
+// ingredientNutrients returns them.
+var nutrientNames = [...]string{"calories", "protein", "fat", "carbs"}
+
+// ingredientNutrients returns the ingredient's nutrition values in the order of
+// nutrientNames.
+func ingredientNutrients(ingredient *models.Ingredient) [len(nutrientNames)]*float64 {
+	return [len(nutrientNames)]*float64{ingredient.Calories, ingredient.Protein, ingredient.Fat, ingredient.Carbs}
+}
+
+// nutritionFacts holds nutrient amounts. A nil value means it couldn't be
+// worked out because an ingredient has no data for it.
+type nutritionFacts struct {
+	Calories *float64 `json:"calories"`
+	Protein  *float64 `json:"protein"`
+	Fat      *float64 `json:"fat"`
+	Carbs    *float64 `json:"carbs"`
+}
+
+// nutritionGap is an ingredient that lacks some of the nutrition data.
+type nutritionGap struct {
+	IngredientID string   `json:"ingredient_id"`
+	Name         string   `json:"name"`
+	Missing      []string `json:"missing"`
+}
+
+// GetNutritionPerServing totals the nutrition of a recipe's ingredients and
+// divides it by the servings. A nutrient is null when any ingredient lacks a
+// value for it, since a partial sum would understate it; missing lists those
+// ingredients so the author can fill them in.
+func (h *RecipeHandler) GetNutritionPerServing(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	
+	recipe, err := h.findVisibleRecipe(c, c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	var ingredients []models.Ingredient
+	if err := h.db(c).Where("recipe_id = ?", recipe.ID).Order("created_at ASC").Find(&ingredients).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ingredients"})
+		return
+	}
+	
+	var totals [len(nutrientNames)]float64
+	var known [len(nutrientNames)]bool
+	for n := range known {
+		known[n] = len(ingredients) > 0
+	}
+	gaps := []nutritionGap{}
+	for i := range ingredients {
+		gap := nutritionGap{IngredientID: ingredients[i].ID, Name: ingredients[i].Name}
+		for n, value := range ingredientNutrients(&ingredients[i]) {
+			if value == nil {
+				known[n] = false
+				gap.Missing = append(gap.Missing, nutrientNames[n])
+				continue
+			}
+			totals[n] += *value
+		}
+		if len(gap.Missing) > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	
+	var total, perServing [len(nutrientNames)]*float64
+	for n := range totals {
+		if !known[n] {
+			continue
+		}
+		sum := totals[n]
+		total[n] = &sum
+		// Servings can't be divided by when a recipe somehow has none
+		if recipe.Servings > 0 {
+			share := sum / float64(recipe.Servings)
+			perServing[n] = &share
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipe_id":   recipe.ID,
+		"servings":    recipe.Servings,
+		"total":       nutritionFacts{total[0], total[1], total[2], total[3]},
+		"per_serving": nutritionFacts{perServing[0], perServing[1], perServing[2], perServing[3]},
+		"complete":    len(gaps) == 0 && len(ingredients) > 0,
+		"missing":     gaps,
+	})
+}
\ No newline at end of file
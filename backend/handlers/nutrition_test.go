@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	
+	"food-recipes-backend/models"
+)
+
+func TestNutritionPerServing(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	category := createCategory(t, db)
+	recipe := createRecipe(t, db, user, category, func(recipe *models.Recipe) {
+		recipe.Servings = 4
+	})
+	
+	ingredients := []models.Ingredient{
+		{RecipeID: recipe.ID, Name: "flour", Calories: float(730), Protein: float(20), Fat: float(2)},
+		{RecipeID: recipe.ID, Name: "butter", Calories: float(450), Protein: float(1), Fat: float(50)},
+	}
+	if err := db.Create(&ingredients).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	w := serve(h.GetNutritionPerServing, "GET", "/recipes/:id/nutrition-per-serving", "/recipes/"+recipe.ID+"/nutrition-per-serving", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var response struct {
+		Servings   int            `json:"servings"`
+		Total      nutritionFacts `json:"total"`
+		PerServing nutritionFacts `json:"per_serving"`
+		Complete   bool           `json:"complete"`
+		Missing    []nutritionGap `json:"missing"`
+	}
+	decode(t, w, &response)
+	
+	if response.Servings != 4 {
+		t.Errorf("expected 4 servings, got %d", response.Servings)
+	}
+	if response.Total.Calories == nil || *response.Total.Calories != 1180 {
+		t.Errorf("expected 1180 calories in total, got %v", response.Total.Calories)
+	}
+	if response.PerServing.Calories == nil || *response.PerServing.Calories != 295 {
+		t.Errorf("expected 295 calories per serving, got %v", response.PerServing.Calories)
+	}
+	if response.PerServing.Fat == nil || *response.PerServing.Fat != 13 {
+		t.Errorf("expected 13 g of fat per serving, got %v", response.PerServing.Fat)
+	}
+	// Neither ingredient knows its carbs, so no total can be given
+	if response.PerServing.Carbs != nil {
+		t.Errorf("expected carbs to be null, got %v", *response.PerServing.Carbs)
+	}
+	if response.Complete {
+		t.Error("expected the nutrition to be reported as incomplete")
+	}
+	if len(response.Missing) != 2 || len(response.Missing[0].Missing) != 1 || response.Missing[0].Missing[0] != "carbs" {
+		t.Errorf("expected both ingredients to be missing carbs, got %+v", response.Missing)
+	}
+}
+
+func TestNutritionOfARecipeWithoutIngredientsIsUnknown(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	
+	w := serve(h.GetNutritionPerServing, "GET", "/recipes/:id/nutrition-per-serving", "/recipes/"+recipe.ID+"/nutrition-per-serving", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var response struct {
+		PerServing nutritionFacts `json:"per_serving"`
+		Complete   bool           `json:"complete"`
+	}
+	decode(t, w, &response)
+	
+	if response.PerServing != (nutritionFacts{}) {
+		t.Errorf("expected every nutrient to be null, got %+v", response.PerServing)
+	}
+	if response.Complete {
+		t.Error("expected a recipe without ingredients to be incomplete")
+	}
+}
\ No newline at end of file
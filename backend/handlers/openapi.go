@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OpenAPIHandler serves an OpenAPI 3 description of the API. Paths are read
+// from the router so none can be left out; summaries, auth requirements and
+// schemas come from apiOperations, with schemas generated from the Go types.
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+// NewOpenAPIHandler builds the spec for the /api routes registered on the
+// router. Call it after every route has been added.
+func NewOpenAPIHandler(routes gin.RoutesInfo) (*OpenAPIHandler, error) {
+	spec, err := json.Marshal(buildOpenAPISpec(routes))
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIHandler{spec: spec}, nil
+}
+
+// GetSpec returns the OpenAPI document.
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// authLevel describes how an operation treats the bearer token.
+type authLevel int
+
+const (
+	authNone authLevel = iota
+	authOptional
+	authRequired
+	authAdmin
+)
+
+// apiOperation documents one route. Query and Body are zero values of the
+// types the handler binds, Response the type it returns on success. Upload
+// marks the multipart image upload.
+type apiOperation struct {
+	Summary  string
+	Auth     authLevel
+	Query    interface{}
+	Body     interface{}
+	Upload   bool
+	Response interface{}
+	Status   int
+}
+
+// schemaObject is a JSON Schema object as used by OpenAPI.
+type schemaObject map[string]interface{}
+
+func buildOpenAPISpec(routes gin.RoutesInfo) map[string]interface{} {
+	schemas := &schemaRegistry{components: make(map[string]schemaObject)}
+	paths := make(map[string]map[string]interface{})
+	operationIDs := make(map[string]bool)
+	
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		
+		op, documented := apiOperations[route.Method+" "+route.Path]
+		if !documented {
+			log.Printf("OpenAPI: %s %s is not documented", route.Method, route.Path)
+		}
+		if strings.HasPrefix(route.Path, "/api/admin/") {
+			op.Auth = authAdmin
+		}
+		
+		name := handlerName(route.Handler)
+		operationID := lowerFirst(name)
+		if operationIDs[operationID] {
+			operationID += strings.ToUpper(route.Method[:1]) + strings.ToLower(route.Method[1:])
+		}
+		operationIDs[operationID] = true
+		
+		summary := op.Summary
+		if summary == "" {
+			summary = humanize(name)
+		}
+		
+		path, params := openAPIPath(route.Path)
+		if op.Query != nil {
+			params = append(params, schemas.queryParameters(reflect.TypeOf(op.Query))...)
+		}
+		
+		operation := map[string]interface{}{
+			"operationId": operationID,
+			"summary":     summary,
+			"tags":        []string{routeTag(route.Path)},
+			"responses":   schemas.responses(op),
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schema(reflect.TypeOf(op.Body))},
+				},
+			}
+		}
+		if op.Upload {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"multipart/form-data": map[string]interface{}{
+						"schema": schemaObject{
+							"type":       "object",
+							"properties": map[string]schemaObject{"image": {"type": "string", "format": "binary"}},
+							"required":   []string{"image"},
+						},
+					},
+				},
+			}
+		}
+		switch op.Auth {
+		case authOptional:
+			// Anonymous callers are served too, a token only adds the caller's own data
+			operation["security"] = []map[string][]string{{}, {"bearerAuth": {}}}
+		case authRequired, authAdmin:
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+		
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(route.Method)] = operation
+	}
+	
+	schemas.components["Error"] = schemaObject{
+		"type":       "object",
+		"properties": map[string]schemaObject{"error": {"type": "string"}},
+		"required":   []string{"error"},
+	}
+	
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Food Recipes API",
+			"version":     "1.0.0",
+			"description": "Recipe sharing with comments, ratings, likes, bookmarks and paid recipes.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+}
+
+// responses documents the success response of op and the error responses
+// every operation can return.
+func (r *schemaRegistry) responses(op apiOperation) map[string]interface{} {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if status != http.StatusNoContent {
+		schema := schemaObject{"type": "object"}
+		if op.Response != nil {
+			schema = r.schema(reflect.TypeOf(op.Response))
+		}
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	
+	errorResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemaObject{"$ref": "#/components/schemas/Error"},
+				},
+			},
+		}
+	}
+	
+	responses := map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default":         errorResponse("Error"),
+	}
+	switch op.Auth {
+	case authRequired:
+		responses["401"] = errorResponse("Missing or invalid token")
+	case authAdmin:
+		responses["401"] = errorResponse("Missing or invalid token")
+		responses["403"] = errorResponse("Admin access required")
+	}
+	return responses
+}
+
+// schemaRegistry generates schemas from Go types. Named model types become
+// components referenced by $ref, which also keeps recursive types finite.
+type schemaRegistry struct {
+	components map[string]schemaObject
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	modelsPkgPath = reflect.TypeOf(models.Recipe{}).PkgPath()
+)
+
+func (r *schemaRegistry) schema(t reflect.Type) schemaObject {
+	switch t {
+	case timeType:
+		return schemaObject{"type": "string", "format": "date-time"}
+	case deletedAtType:
+		return schemaObject{"type": "string", "format": "date-time", "nullable": true}
+	}
+	
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := r.schema(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return schema
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return schemaObject{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schemaObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schemaObject{"type": "number"}
+	case reflect.String:
+		return schemaObject{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return schemaObject{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		return schemaObject{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.PkgPath() != modelsPkgPath || t.Name() == "" {
+			return r.objectSchema(t)
+		}
+		if _, exists := r.components[t.Name()]; !exists {
+			// Reserve the name first so self-references resolve to the $ref
+			r.components[t.Name()] = schemaObject{}
+			r.components[t.Name()] = r.objectSchema(t)
+		}
+		return schemaObject{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	
+	// interface{} and anything else accepts any value
+	return schemaObject{}
+}
+
+// objectSchema describes a struct by its JSON fields. Embedded structs without
+// a JSON name have their fields promoted, as encoding/json does.
+func (r *schemaRegistry) objectSchema(t reflect.Type) schemaObject {
+	properties := make(map[string]schemaObject)
+	var required []string
+	
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			
+			schema := r.schema(field.Type)
+			rules := bindingRules(field)
+			applyBindingRules(schema, rules)
+			properties[name] = schema
+			if rules["required"] != "" {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+	
+	schema := schemaObject{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// queryParameters documents a struct bound with ShouldBindQuery, one query
+// parameter per form field.
+func (r *schemaRegistry) queryParameters(t reflect.Type) []map[string]interface{} {
+	var params []map[string]interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			params = append(params, r.queryParameters(field.Type)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		
+		schema := r.schema(field.Type)
+		rules := bindingRules(field)
+		applyBindingRules(schema, rules)
+		param := map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": schema,
+		}
+		if rules["required"] != "" {
+			param["required"] = true
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// bindingRules parses a field's binding tag into rule names and parameters,
+// ignoring the rules after dive, which apply to the elements.
+func bindingRules(field reflect.StructField) map[string]string {
+	rules := make(map[string]string)
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "dive" {
+			break
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		if name != "" {
+			rules[name] = param
+			if param == "" {
+				rules[name] = name
+			}
+		}
+	}
+	return rules
+}
+
+// applyBindingRules copies the validation rules OpenAPI can express onto a
+// schema.
+func applyBindingRules(schema schemaObject, rules map[string]string) {
+	if _, isRef := schema["$ref"]; isRef {
+		return
+	}
+	
+	if values := rules["oneof"]; values != "" {
+		schema["enum"] = strings.Fields(values)
+	}
+	for _, format := range []string{"email", "uuid", "url"} {
+		if rules[format] != "" {
+			schema["format"] = format
+		}
+	}
+	
+	bounds := map[string][2]string{
+		"string": {"minLength", "maxLength"},
+		"array":  {"minItems", "maxItems"},
+		"number": {"minimum", "maximum"},
+	}
+	kind, _ := schema["type"].(string)
+	if kind == "integer" {
+		kind = "number"
+	}
+	keys, ok := bounds[kind]
+	if !ok {
+		return
+	}
+	for i, rule := range []string{"min", "max"} {
+		var limit float64
+		if err := json.Unmarshal([]byte(rules[rule]), &limit); err == nil {
+			schema[keys[i]] = limit
+		}
+	}
+}
+
+// openAPIPath turns a gin path such as /api/recipes/:id into /api/recipes/{id}
+// along with its path parameters.
+func openAPIPath(path string) (string, []map[string]interface{}) {
+	var params []map[string]interface{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schemaObject{"type": "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// routeTag groups operations by the first path segment after /api, or after
+// /api/admin for admin routes.
+func routeTag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	if segments[0] == "admin" {
+		return "admin"
+	}
+	return segments[0]
+}
+
+// handlerName extracts the method name from a handler's function name, e.g.
+// "food-recipes-backend/handlers.(*RecipeHandler).GetRecipes-fm".
+func handlerName(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	return handler[strings.LastIndexByte(handler, '.')+1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// humanize turns a handler name like GetRecipeLikes into "Get recipe likes".
+func humanize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
\ No newline at end of file
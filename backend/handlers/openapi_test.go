@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	path, params := openAPIPath("/api/recipes/:id/comments/*rest")
+	if path != "/api/recipes/{id}/comments/{rest}" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if len(params) != 2 || params[0]["name"] != "id" || params[1]["name"] != "rest" || params[0]["in"] != "path" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+}
+
+// specRouter registers a few of the API's routes, plus one outside /api.
+func specRouter() *gin.Engine {
+	recipes := NewRecipeHandler(nil, testConfig())
+	uploads := &UploadHandler{}
+	
+	router := gin.New()
+	router.GET("/uploads/:filename", uploads.ServeUploads)
+	router.GET("/api/recipes", recipes.GetRecipes)
+	router.POST("/api/recipes", recipes.CreateRecipe)
+	router.GET("/api/recipes/:id", recipes.GetRecipe)
+	router.PUT("/api/recipes/:id", recipes.UpdateRecipe)
+	router.GET("/api/recipes/:id/comments", recipes.GetComments)
+	router.POST("/api/upload", uploads.UploadImage)
+	return router
+}
+
+func TestOpenAPISpecIsValid(t *testing.T) {
+	h, err := NewOpenAPIHandler(specRouter().Routes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(h.GetSpec, "GET", "/api/openapi.json", "/api/openapi.json", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("expected JSON, got %q", contentType)
+	}
+	
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Info       map[string]interface{}                       `json:"info"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	decode(t, w, &spec)
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || spec.Info["title"] == "" || spec.Info["version"] == "" {
+		t.Errorf("expected an OpenAPI 3 document with title and version, got %q %v", spec.OpenAPI, spec.Info)
+	}
+	
+	wantPaths := []string{"/api/recipes", "/api/recipes/{id}", "/api/recipes/{id}/comments", "/api/upload"}
+	var gotPaths []string
+	for path := range spec.Paths {
+		gotPaths = append(gotPaths, path)
+	}
+	if len(gotPaths) != len(wantPaths) {
+		t.Errorf("expected paths %v, got %v", wantPaths, gotPaths)
+	}
+	if _, ok := spec.Paths["/api/recipes"]["get"]; !ok {
+		t.Fatalf("expected GET /api/recipes in the spec, got %v", gotPaths)
+	}
+	
+	// Every operation has an ID, responses and each of its path's parameters
+	operationIDs := make(map[string]bool)
+	pathParam := regexp.MustCompile(`\{(\w+)\}`)
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			id, _ := operation["operationId"].(string)
+			if id == "" || operationIDs[id] {
+				t.Errorf("%s %s: expected a unique operationId, got %q", method, path, id)
+			}
+			operationIDs[id] = true
+			if responses, _ := operation["responses"].(map[string]interface{}); len(responses) == 0 {
+				t.Errorf("%s %s: expected responses", method, path)
+			}
+			
+			declared := make(map[string]bool)
+			params, _ := operation["parameters"].([]interface{})
+			for _, p := range params {
+				param := p.(map[string]interface{})
+				if param["in"] == "path" && param["required"] == true {
+					declared[param["name"].(string)] = true
+				}
+			}
+			for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+				if !declared[match[1]] {
+					t.Errorf("%s %s: path parameter %s isn't declared", method, path, match[1])
+				}
+			}
+		}
+	}
+	
+	// Every reference points at a schema component
+	var refs []string
+	collectRefs(spec.Paths, &refs)
+	collectRefs(spec.Components.Schemas, &refs)
+	if len(refs) == 0 {
+		t.Error("expected schemas to be referenced")
+	}
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := spec.Components.Schemas[name]; !ok || name == ref {
+			t.Errorf("reference %q doesn't resolve", ref)
+		}
+	}
+	
+	search := spec.Paths["/api/recipes"]["get"]
+	if !hasParameter(search, "q", "query") || !hasParameter(search, "page", "query") {
+		t.Errorf("expected the search filters as query parameters, got %v", search["parameters"])
+	}
+	create := spec.Paths["/api/recipes"]["post"]
+	if !reflect.DeepEqual(create["security"], []interface{}{map[string]interface{}{"bearerAuth": []interface{}{}}}) {
+		t.Errorf("expected creating a recipe to require a token, got %v", create["security"])
+	}
+	if _, ok := create["responses"].(map[string]interface{})["201"]; !ok {
+		t.Errorf("expected a 201 response, got %v", create["responses"])
+	}
+	upload, _ := spec.Paths["/api/upload"]["post"]["requestBody"].(map[string]interface{})
+	if content, _ := upload["content"].(map[string]interface{}); content["multipart/form-data"] == nil {
+		t.Errorf("expected a multipart upload body, got %v", upload)
+	}
+}
+
+// collectRefs appends every $ref found in a decoded JSON value to refs.
+func collectRefs(value interface{}, refs *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectRefs(child, refs)
+		}
+	case map[string]map[string]map[string]interface{}:
+		for _, operations := range v {
+			for _, operation := range operations {
+				collectRefs(operation, refs)
+			}
+		}
+	}
+}
+
+// hasParameter reports whether an operation declares the named parameter.
+func hasParameter(operation map[string]interface{}, name, in string) bool {
+	params, _ := operation["parameters"].([]interface{})
+	for _, p := range params {
+		if param := p.(map[string]interface{}); param["name"] == name && param["in"] == in {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	
+	"food-recipes-backend/config"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// normalizePagination applies the configured default page size and clamps
+// oversize limits to the configured maximum.
+func normalizePagination(cfg *config.Config, page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = cfg.DefaultPageSize
+	}
+	if limit > cfg.MaxPageSize {
+		limit = cfg.MaxPageSize
+	}
+	
+	return page, limit
+}
+
+// PaginatedResponse is the envelope shared by the paginated listings. Pages is
+// zero when there are no results.
+type PaginatedResponse[T any] struct {
+	Data  []T   `json:"data"`
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Pages int   `json:"pages"`
+}
+
+// newPaginatedResponse wraps one page of results. A nil page is sent as an
+// empty list rather than null.
+func newPaginatedResponse[T any](data []T, total int64, page, limit int) PaginatedResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return PaginatedResponse[T]{
+		Data:  data,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+		Pages: (int(total) + limit - 1) / limit,
+	}
+}
+
+// setPaginationHeaders mirrors a paginated listing's total and neighbouring
+// pages in X-Total-Count and Link headers, for clients that page generically
+// without reading the body. There is no next link on the last page.
+func setPaginationHeaders(c *gin.Context, total int64, page, limit int) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	
+	lastPage := max((int(total)+limit-1)/limit, 1)
+	var links []string
+	if page > 1 {
+		// A page past the end links back to the last real page
+		links = append(links, paginationLink(c, "prev", "page", strconv.Itoa(min(page-1, lastPage))))
+	}
+	if page < lastPage {
+		links = append(links, paginationLink(c, "next", "page", strconv.Itoa(page+1)))
+	}
+	setLinkHeader(c, links)
+}
+
+// paginationLink formats a Link header entry pointing at the current request
+// with one query parameter replaced. The target is relative to the request,
+// so it stays valid behind proxies.
+func paginationLink(c *gin.Context, rel, param, value string) string {
+	target := *c.Request.URL
+	query := target.Query()
+	query.Set(param, value)
+	if param == "cursor" {
+		// A cursor takes the place of the page number
+		query.Del("page")
+	}
+	target.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, target.RequestURI(), rel)
+}
+
+func setLinkHeader(c *gin.Context, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
\ No newline at end of file
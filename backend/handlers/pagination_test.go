@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	
+	"food-recipes-backend/config"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizePaginationClampsToConfiguredLimits(t *testing.T) {
+	cfg := &config.Config{DefaultPageSize: 12, MaxPageSize: 30}
+	tests := []struct {
+		page, limit         int
+		wantPage, wantLimit int
+	}{
+		{1, 10, 1, 10},
+		{0, 0, 1, 12},
+		{-3, -1, 1, 12},
+		{2, 30, 2, 30},
+		{2, 31, 2, 30},
+		{1, 1000, 1, 30},
+	}
+	for _, tt := range tests {
+		page, limit := normalizePagination(cfg, tt.page, tt.limit)
+		if page != tt.wantPage || limit != tt.wantLimit {
+			t.Errorf("normalizePagination(%d, %d) = %d, %d, want %d, %d",
+				tt.page, tt.limit, page, limit, tt.wantPage, tt.wantLimit)
+		}
+	}
+}
+
+func TestRecipeListingClampsOversizeLimit(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.MaxPageSize = 2
+	h := NewRecipeHandler(db, cfg)
+	
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	for i := 0; i < 3; i++ {
+		createRecipe(t, db, author, category, nil)
+	}
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?limit=500", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var page PaginatedResponse[recipeListItem]
+	decode(t, w, &page)
+	if page.Limit != 2 || len(page.Data) != 2 || page.Total != 3 || page.Pages != 2 {
+		t.Errorf("expected 2 of 3 recipes on 2 pages, got limit %d, %d recipes, total %d, %d pages",
+			page.Limit, len(page.Data), page.Total, page.Pages)
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	tests := []struct {
+		page  int
+		total int64
+		link  string
+	}{
+		{1, 45, `</recipes?limit=20&page=2&q=soup>; rel="next"`},
+		{2, 45, `</recipes?limit=20&page=1&q=soup>; rel="prev", </recipes?limit=20&page=3&q=soup>; rel="next"`},
+		{3, 45, `</recipes?limit=20&page=2&q=soup>; rel="prev"`},
+		{5, 45, `</recipes?limit=20&page=3&q=soup>; rel="prev"`},
+		{1, 20, ""},
+		{1, 0, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/recipes?q=soup&page=%d&limit=20", tt.page), nil)
+		
+		setPaginationHeaders(c, tt.total, tt.page, 20)
+		
+		if got := w.Header().Get("X-Total-Count"); got != strconv.FormatInt(tt.total, 10) {
+			t.Errorf("page %d of %d: expected X-Total-Count %d, got %q", tt.page, tt.total, tt.total, got)
+		}
+		if got := w.Header().Get("Link"); got != tt.link {
+			t.Errorf("page %d of %d: expected Link %q, got %q", tt.page, tt.total, tt.link, got)
+		}
+	}
+}
+
+func TestRecipeListingLinksToTheNextPage(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	for i := 0; i < 3; i++ {
+		createRecipe(t, db, author, category, nil)
+	}
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?limit=2", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if got, want := w.Header().Get("Link"), `</recipes?limit=2&page=2>; rel="next"`; got != want {
+		t.Errorf("expected Link %q, got %q", want, got)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("expected X-Total-Count 3, got %q", got)
+	}
+	
+	w = serve(h.GetRecipes, "GET", "/recipes", "/recipes?limit=2&page=2", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Link"); strings.Contains(got, `rel="next"`) {
+		t.Errorf("expected no next link on the last page, got %q", got)
+	}
+}
+
+func TestNewPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		total       int64
+		limit, want int
+	}{
+		{0, 20, 0},
+		{1, 20, 1},
+		{20, 20, 1},
+		{21, 20, 2},
+		{45, 20, 3},
+	}
+	for _, tt := range tests {
+		if got := newPaginatedResponse([]int{}, tt.total, 1, tt.limit).Pages; got != tt.want {
+			t.Errorf("%d results of %d per page: expected %d pages, got %d", tt.total, tt.limit, tt.want, got)
+		}
+	}
+	
+	body, err := json.Marshal(newPaginatedResponse[int](nil, 0, 1, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":[],"total":0,"page":1,"limit":20,"pages":0}`; string(body) != want {
+		t.Errorf("expected an empty page to encode as %s, got %s", want, body)
+	}
+}
+
+func TestListingsShareTheEnvelope(t *testing.T) {
+	db := testDB(t)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	createRecipe(t, db, author, category, nil)
+	
+	envelope := func(w *httptest.ResponseRecorder) map[string]json.RawMessage {
+		t.Helper()
+		expectStatus(t, w, http.StatusOK)
+		var fields map[string]json.RawMessage
+		decode(t, w, &fields)
+		return fields
+	}
+	recipes := envelope(serve(NewRecipeHandler(db, testConfig()).GetRecipes,
+		"GET", "/recipes", "/recipes?category_id="+category.ID, "", nil))
+	categoryRecipes := envelope(serve(NewCategoryHandler(db, testConfig()).GetCategoryRecipes,
+		"GET", "/categories/:id/recipes", "/categories/"+category.ID+"/recipes", "", nil))
+	
+	for _, key := range []string{"data", "total", "page", "limit", "pages"} {
+		if _, ok := recipes[key]; !ok {
+			t.Errorf("expected the recipe listing to have %q", key)
+		}
+		if _, ok := categoryRecipes[key]; !ok {
+			t.Errorf("expected the category listing to have %q", key)
+		}
+		if key != "data" && string(recipes[key]) != string(categoryRecipes[key]) {
+			t.Errorf("expected both listings to agree on %q, got %s and %s", key, recipes[key], categoryRecipes[key])
+		}
+	}
+	// The category listing only adds the category itself
+	if len(recipes) != 5 || len(categoryRecipes) != 6 {
+		t.Errorf("expected 5 and 6 fields, got %d and %d", len(recipes), len(categoryRecipes))
+	}
+	if _, ok := categoryRecipes["category"]; !ok {
+		t.Error("expected the category listing to include the category")
+	}
+}
\ No newline at end of file
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 	
+	"food-recipes-backend/metrics"
 	"food-recipes-backend/models"
 	
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,11 @@ func NewChapaPaymentHandler(db *gorm.DB, chapaSecret string) *ChapaPaymentHandle
 	}
 }
 
+// db returns the handler's database bound to the request context.
+func (h *ChapaPaymentHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
 type ChapaInitializeRequest struct {
 	Amount         string `json:"amount"`
 	Currency       string `json:"currency"`
@@ -78,21 +84,21 @@ func (h *ChapaPaymentHandler) InitializePayment(c *gin.Context) {
 	
 	// Check if recipe exists and get details
 	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ?", paymentRequest.RecipeID).Error; err != nil {
+	if err := h.db(c).First(&recipe, "id = ?", paymentRequest.RecipeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		return
 	}
 	
 	// Check if user already purchased this recipe
 	var existingPurchase models.Purchase
-	if err := h.DB.Where("user_id = ? AND recipe_id = ?", userID, paymentRequest.RecipeID).First(&existingPurchase).Error; err == nil {
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, paymentRequest.RecipeID).First(&existingPurchase).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "You have already purchased this recipe"})
 		return
 	}
 	
 	// Get user details
 	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
+	if err := h.db(c).First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -108,11 +114,17 @@ func (h *ChapaPaymentHandler) InitializePayment(c *gin.Context) {
 		Status:     "pending",
 	}
 	
-	if err := h.DB.Create(&purchase).Error; err != nil {
+	if err := h.db(c).Create(&purchase).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create purchase record"})
 		return
 	}
 	
+	// From here on every early return is a failed payment attempt
+	outcome := "failure"
+	defer func() {
+		metrics.Payments.WithLabelValues("initialize", outcome).Inc()
+	}()
+	
 	// Initialize Chapa payment
 	chapaRequest := ChapaInitializeRequest{
 		Amount:      fmt.Sprintf("%.2f", paymentRequest.Amount),
@@ -129,12 +141,12 @@ func (h *ChapaPaymentHandler) InitializePayment(c *gin.Context) {
 	
 	jsonData, err := json.Marshal(chapaRequest)
 	if err != nil {
-		h.DB.Delete(&purchase) // Clean up failed purchase record
+		h.DB.Delete(&purchase) // Clean up failed purchase record, even if the request was cancelled
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare payment"})
 		return
 	}
 	
-	req, err := http.NewRequest("POST", "https://api.chapa.co/v1/transaction/initialize", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(c.Request.Context(), "POST", "https://api.chapa.co/v1/transaction/initialize", bytes.NewBuffer(jsonData))
 	if err != nil {
 		h.DB.Delete(&purchase)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize payment"})
@@ -175,7 +187,8 @@ func (h *ChapaPaymentHandler) InitializePayment(c *gin.Context) {
 	
 	// Update purchase with transaction reference
 	purchase.ChapaTransactionID = &txRef
-	h.DB.Save(&purchase)
+	h.db(c).Save(&purchase)
+	outcome = "success"
 	
 	c.JSON(http.StatusOK, gin.H{
 		"checkout_url": chapaResponse.Data.CheckoutURL,
@@ -192,7 +205,7 @@ func (h *ChapaPaymentHandler) VerifyPayment(c *gin.Context) {
 	}
 	
 	// Verify payment with Chapa
-	req, err := http.NewRequest("GET", "https://api.chapa.co/v1/transaction/verify/"+txRef, nil)
+	req, err := http.NewRequestWithContext(c.Request.Context(), "GET", "https://api.chapa.co/v1/transaction/verify/"+txRef, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify payment"})
 		return
@@ -222,7 +235,7 @@ func (h *ChapaPaymentHandler) VerifyPayment(c *gin.Context) {
 	
 	// Find and update purchase record
 	var purchase models.Purchase
-	if err := h.DB.Where("chapa_transaction_id = ?", txRef).First(&purchase).Error; err != nil {
+	if err := h.db(c).Where("chapa_transaction_id = ?", txRef).First(&purchase).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase record not found"})
 		return
 	}
@@ -233,7 +246,8 @@ func (h *ChapaPaymentHandler) VerifyPayment(c *gin.Context) {
 		purchase.Status = "failed"
 	}
 	
-	h.DB.Save(&purchase)
+	h.db(c).Save(&purchase)
+	metrics.Payments.WithLabelValues("verify", purchase.Status).Inc()
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":  purchase.Status,
@@ -249,7 +263,7 @@ func (h *ChapaPaymentHandler) GetUserPurchases(c *gin.Context) {
 	}
 	
 	var purchases []models.Purchase
-	if err := h.DB.Preload("Recipe").Preload("Recipe.User").
+	if err := h.db(c).Preload("Recipe").Preload("Recipe.User").
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Find(&purchases).Error; err != nil {
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxQueueSize caps how many recipes a user can have queued.
+const maxQueueSize = 100
+
+var errQueueFull = errors.New("queue is full")
+
+// visibleQueue scopes a user's queue to recipes they can still open, in queue
+// order. Entries of deleted or unpublished recipes stay stored, so they come
+// back if the recipe does.
+func visibleQueue(db *gorm.DB, userID interface{}) *gorm.DB {
+	return db.Model(&models.Queue{}).
+		Joins("JOIN recipes ON recipes.id = queues.recipe_id AND recipes.deleted_at IS NULL").
+		Where("queues.user_id = ? AND (recipes.is_published = ? OR recipes.user_id = ?)", userID, true, userID).
+		Order("queues.position ASC").Order("queues.created_at ASC")
+}
+
+// GetQueue lists the recipes the user has queued to cook, in order.
+func (h *RecipeHandler) GetQueue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	queue := []models.Queue{}
+	if err := visibleQueue(h.db(c), userID).
+		Preload("Recipe").Preload("Recipe.User").Preload("Recipe.Images").
+		Find(&queue).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"queue": queue})
+}
+
+// EnqueueRecipe adds a recipe to the end of the user's queue. Queueing a
+// recipe that is already queued leaves it where it is.
+func (h *RecipeHandler) EnqueueRecipe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipe, err := h.findVisibleRecipe(c, c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	// Locking the user's row makes concurrent enqueues take turns, so they
+	// can't share a position or overrun maxQueueSize together
+	var entry models.Queue
+	var created bool
+	err = h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR NO KEY UPDATE", userID).Error; err != nil {
+			return err
+		}
+		
+		result := tx.Exec(`INSERT INTO queues (user_id, recipe_id, position, created_at)
+			SELECT ?, ?, COALESCE(MAX(position), 0) + 1, ? FROM queues WHERE user_id = ?
+			HAVING COUNT(*) < ?
+			ON CONFLICT (user_id, recipe_id) DO NOTHING`,
+			userID, recipe.ID, time.Now(), userID, maxQueueSize)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+		
+		// Nothing is inserted when the recipe is already queued or the queue is full
+		err := tx.Where("user_id = ? AND recipe_id = ?", userID, recipe.ID).First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errQueueFull
+		}
+		return err
+	})
+	if errors.Is(err, errQueueFull) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("At most %d recipes can be queued, cook or remove one first", maxQueueSize)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue recipe"})
+		return
+	}
+	
+	if !created {
+		c.JSON(http.StatusOK, entry)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// DequeueRecipe removes a recipe from the user's queue without cooking it.
+func (h *RecipeHandler) DequeueRecipe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	result := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, c.Param("id")).Delete(&models.Queue{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove recipe from queue"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe is not queued"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe removed from queue"})
+}
+
+// ReorderQueue puts the user's queue in the order of recipe_ids, which must
+// list every recipe in the queue exactly once.
+func (h *RecipeHandler) ReorderQueue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	var reorderInput struct {
+		RecipeIDs []string `json:"recipe_ids" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&reorderInput); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	var queue []models.Queue
+	if err := visibleQueue(h.db(c), userID).Find(&queue).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue"})
+		return
+	}
+	
+	remaining := make(map[string]bool, len(queue))
+	for _, entry := range queue {
+		remaining[entry.RecipeID] = true
+	}
+	for _, id := range reorderInput.RecipeIDs {
+		if !remaining[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recipe_ids contains an unqueued or duplicate recipe: " + id})
+			return
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipe_ids must include every recipe in the queue"})
+		return
+	}
+	
+	// Entries hidden from the listing keep their relative order behind the rest
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		for i, id := range reorderInput.RecipeIDs {
+			if err := tx.Model(&models.Queue{}).Where("user_id = ? AND recipe_id = ?", userID, id).
+				Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		if len(reorderInput.RecipeIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Queue{}).Where("user_id = ? AND recipe_id NOT IN ?", userID, reorderInput.RecipeIDs).
+			Update("position", gorm.Expr("position + ?", len(reorderInput.RecipeIDs))).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder queue"})
+		return
+	}
+	
+	h.GetQueue(c)
+}
+
+// MarkQueuedCooked takes a recipe off the user's queue and records that they
+// made it, as RecordMade does without a photo.
+func (h *RecipeHandler) MarkQueuedCooked(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipe, err := h.findVisibleRecipe(c, c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	made := models.Made{
+		UserID:   userID.(string),
+		RecipeID: recipe.ID,
+	}
+	err = h.db(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND recipe_id = ?", userID, recipe.ID).Delete(&models.Queue{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(&made).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe is not queued"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark recipe as cooked"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"made": made})
+}
\ No newline at end of file
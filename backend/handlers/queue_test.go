@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestReorderQueueRequiresRecipeIDs(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	w := serve(h.ReorderQueue, "PUT", "/queue/order", "/queue/order", "user-1", gin.H{})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestQueueEnqueueReorderAndCook(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	cook := createUser(t, db)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	a := createRecipe(t, db, author, category, nil)
+	b := createRecipe(t, db, author, category, nil)
+	c := createRecipe(t, db, author, category, nil)
+	
+	queued := func(w *httptest.ResponseRecorder) []string {
+		t.Helper()
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Queue []models.Queue `json:"queue"`
+		}
+		decode(t, w, &response)
+		ids := make([]string, len(response.Queue))
+		for i, entry := range response.Queue {
+			ids[i] = entry.RecipeID
+		}
+		return ids
+	}
+	queue := func() []string {
+		t.Helper()
+		return queued(serve(h.GetQueue, "GET", "/queue", "/queue", cook.ID, nil))
+	}
+	enqueue := func(recipe models.Recipe, status int) models.Queue {
+		t.Helper()
+		w := serve(h.EnqueueRecipe, "POST", "/recipes/:id/queue", "/recipes/"+recipe.ID+"/queue", cook.ID, nil)
+		expectStatus(t, w, status)
+		var entry models.Queue
+		decode(t, w, &entry)
+		return entry
+	}
+	reorder := func(ids ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(h.ReorderQueue, "PUT", "/queue/order", "/queue/order", cook.ID, gin.H{"recipe_ids": ids})
+	}
+	
+	for i, recipe := range []models.Recipe{a, b, c} {
+		if entry := enqueue(recipe, http.StatusCreated); entry.Position != i+1 {
+			t.Errorf("expected recipe %d to be queued at position %d, got %d", i, i+1, entry.Position)
+		}
+	}
+	// Queueing a recipe again leaves it where it is
+	if entry := enqueue(a, http.StatusOK); entry.Position != 1 {
+		t.Errorf("expected the queued recipe to stay first, got position %d", entry.Position)
+	}
+	if got, want := queue(), []string{a.ID, b.ID, c.ID}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	
+	want := []string{c.ID, a.ID, b.ID}
+	if got := queued(reorder(want...)); !slices.Equal(got, want) {
+		t.Errorf("expected the reordered queue %v, got %v", want, got)
+	}
+	other := createRecipe(t, db, author, category, nil)
+	for _, ids := range [][]string{{c.ID, a.ID}, {c.ID, a.ID, a.ID}, {c.ID, a.ID, b.ID, other.ID}} {
+		if w := reorder(ids...); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", ids, w.Code)
+		}
+	}
+	if got := queue(); !slices.Equal(got, want) {
+		t.Errorf("expected rejected orders to change nothing, got %v", got)
+	}
+	
+	// Cooking a recipe takes it off the queue and records it as made
+	markCooked := func(recipe models.Recipe) *httptest.ResponseRecorder {
+		return serve(h.MarkQueuedCooked, "POST", "/recipes/:id/queue/cooked", "/recipes/"+recipe.ID+"/queue/cooked", cook.ID, nil)
+	}
+	expectStatus(t, markCooked(a), http.StatusOK)
+	if got, want := queue(), []string{c.ID, b.ID}; !slices.Equal(got, want) {
+		t.Errorf("expected %v after cooking, got %v", want, got)
+	}
+	var made int64
+	db.Model(&models.Made{}).Where("user_id = ? AND recipe_id = ?", cook.ID, a.ID).Count(&made)
+	if made != 1 {
+		t.Errorf("expected the cooked recipe to be recorded as made once, got %d", made)
+	}
+	expectStatus(t, markCooked(a), http.StatusNotFound)
+	
+	w := serve(h.DequeueRecipe, "DELETE", "/recipes/:id/queue", "/recipes/"+c.ID+"/queue", cook.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	w = serve(h.DequeueRecipe, "DELETE", "/recipes/:id/queue", "/recipes/"+c.ID+"/queue", cook.ID, nil)
+	expectStatus(t, w, http.StatusNotFound)
+	if got, want := queue(), []string{b.ID}; !slices.Equal(got, want) {
+		t.Errorf("expected %v after removing a recipe, got %v", want, got)
+	}
+}
\ No newline at end of file
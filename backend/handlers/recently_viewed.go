@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recentlyViewedLimit is how many recently viewed recipes are kept per user.
+const recentlyViewedLimit = 20
+
+// recentlyViewedItem is a recipe in the recently viewed list.
+type recentlyViewedItem struct {
+	recipeListItem
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+// rememberRecentView moves the recipe to the front of the user's recently
+// viewed list and drops whatever falls off the end. Failures are only logged,
+// the recipe is served either way.
+func (h *RecipeHandler) rememberRecentView(c *gin.Context, recipeID, userID string) {
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		entry := models.RecentlyViewed{UserID: userID, RecipeID: recipeID, ViewedAt: time.Now()}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "recipe_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+		}).Create(&entry).Error; err != nil {
+			return err
+		}
+		
+		return tx.Where("user_id = ? AND recipe_id NOT IN (?)", userID,
+			tx.Model(&models.RecentlyViewed{}).Select("recipe_id").Where("user_id = ?", userID).
+				Order("viewed_at DESC").Limit(recentlyViewedLimit)).
+			Delete(&models.RecentlyViewed{}).Error
+	})
+	if err != nil {
+		log.Printf("Failed to remember recently viewed recipe %s: %v", recipeID, err)
+	}
+}
+
+// GetRecentlyViewed lists the caller's recently viewed recipes, most recent
+// first. Recipes deleted or unpublished since are left out.
+func (h *RecipeHandler) GetRecentlyViewed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	var entries []models.RecentlyViewed
+	if err := h.db(c).Joins("JOIN recipes ON recipes.id = recently_vieweds.recipe_id").
+		Where("recently_vieweds.user_id = ?", userID).
+		Where("recipes.is_published = ? AND recipes.deleted_at IS NULL", true).
+		Order("recently_vieweds.viewed_at DESC").
+		Limit(recentlyViewedLimit).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recently viewed recipes"})
+		return
+	}
+	
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.RecipeID
+	}
+	
+	var recipes []models.Recipe
+	if len(ids) > 0 {
+		if err := h.db(c).Preload("User").Preload("Category").Preload("Images").
+			Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recently viewed recipes"})
+			return
+		}
+	}
+	
+	byID := make(map[string]recipeListItem, len(recipes))
+	for _, item := range withOwnership(recipes, userID) {
+		byID[item.ID] = item
+	}
+	
+	items := make([]recentlyViewedItem, 0, len(entries))
+	for _, entry := range entries {
+		if item, ok := byID[entry.RecipeID]; ok {
+			items = append(items, recentlyViewedItem{recipeListItem: item, ViewedAt: entry.ViewedAt})
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"recipes": items})
+}
\ No newline at end of file
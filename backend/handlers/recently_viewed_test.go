@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecentlyViewedKeepsTheLatestViewsFirst(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	viewer := createUser(t, db)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	
+	recipes := make([]models.Recipe, recentlyViewedLimit+2)
+	for i := range recipes {
+		recipes[i] = createRecipe(t, db, author, category, nil)
+	}
+	view := func(recipe models.Recipe) {
+		t.Helper()
+		w := serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, viewer.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+	}
+	recent := func(userID string) []string {
+		t.Helper()
+		w := serve(h.GetRecentlyViewed, "GET", "/me/recently-viewed", "/me/recently-viewed", userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Recipes []recentlyViewedItem `json:"recipes"`
+		}
+		decode(t, w, &response)
+		ids := make([]string, len(response.Recipes))
+		for i, item := range response.Recipes {
+			ids[i] = item.ID
+		}
+		return ids
+	}
+	
+	for _, recipe := range recipes {
+		view(recipe)
+	}
+	// Viewing a recipe again moves it to the front instead of repeating it
+	view(recipes[0])
+	
+	want := []string{recipes[0].ID}
+	for i := len(recipes) - 1; len(want) < recentlyViewedLimit; i-- {
+		want = append(want, recipes[i].ID)
+	}
+	if got := recent(viewer.ID); !slices.Equal(got, want) {
+		t.Errorf("expected the %d latest views newest first:\n%v\ngot\n%v", recentlyViewedLimit, want, got)
+	}
+	var stored int64
+	db.Model(&models.RecentlyViewed{}).Where("user_id = ?", viewer.ID).Count(&stored)
+	if stored != recentlyViewedLimit {
+		t.Errorf("expected %d stored views, got %d", recentlyViewedLimit, stored)
+	}
+	
+	// Unpublished and deleted recipes drop out of the list
+	if err := db.Model(&recipes[len(recipes)-1]).Update("is_published", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&recipes[len(recipes)-2]).Error; err != nil {
+		t.Fatal(err)
+	}
+	want = append(want[:1], want[3:]...)
+	if got := recent(viewer.ID); !slices.Equal(got, want) {
+		t.Errorf("expected unpublished and deleted recipes to be left out:\n%v\ngot\n%v", want, got)
+	}
+	
+	if got := recent(author.ID); len(got) != 0 {
+		t.Errorf("expected other users' lists to be empty, got %v", got)
+	}
+}
+
+func TestDeletingAnAccountRemovesItsRecentlyViewedRecipes(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	auth := NewAuthHandler(db, testConfig())
+	viewer := createUser(t, db)
+	setPassword(t, db, &viewer, "correct horse")
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	
+	w := serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, viewer.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	w = serve(auth.DeleteAccount, "DELETE", "/auth/account", "/auth/account", viewer.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var remaining int64
+	db.Model(&models.RecentlyViewed{}).Where("user_id = ?", viewer.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected the deleted account's views to be gone, got %d", remaining)
+	}
+}
\ No newline at end of file
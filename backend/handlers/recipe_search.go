@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"gorm.io/gorm"
+)
+
+// recipeListItem wraps a recipe in a listing with fields that depend on who is
+// asking, so they stay out of the Recipe model itself.
+type recipeListItem struct {
+	models.Recipe
+	IsOwner    bool              `json:"is_owner"`
+	Highlights *recipeHighlights `json:"highlights,omitempty"`
+}
+
+// recipeHighlights holds the search query's matches in a listed recipe, with
+// the query wrapped in utils.HighlightStart/HighlightEnd. Fields are empty when
+// they don't contain the query.
+type recipeHighlights struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// highlightSnippetLength is the approximate length of description snippets.
+const highlightSnippetLength = 160
+
+// withOwnership wraps listed recipes, marking the ones written by userID.
+// userID is nil for anonymous requests.
+func withOwnership(recipes []models.Recipe, userID interface{}) []recipeListItem {
+	items := make([]recipeListItem, len(recipes))
+	for i, recipe := range recipes {
+		items[i] = recipeListItem{Recipe: recipe, IsOwner: userID != nil && recipe.UserID == userID}
+	}
+	return items
+}
+
+// withHighlights marks where query matches the title and description of each
+// listed recipe. Only the response is touched, the recipes aren't modified.
+func withHighlights(items []recipeListItem, query string) {
+	for i := range items {
+		title, inTitle := utils.Highlight(items[i].Title, query, 0)
+		description, inDescription := utils.Highlight(items[i].Description, query, highlightSnippetLength)
+		if inTitle || inDescription {
+			items[i].Highlights = &recipeHighlights{Title: title, Description: description}
+		}
+	}
+}
+
+// prepareSearchFilters validates the filters shared by the recipe listings,
+// normalizes their pagination and returns the ORDER BY clause for the sort.
+func prepareSearchFilters(cfg *config.Config, filters *models.SearchFilters) (string, error) {
+	if filters.MinPrice != nil && filters.MaxPrice != nil && *filters.MinPrice > *filters.MaxPrice {
+		return "", errors.New("min_price must not be greater than max_price")
+	}
+	
+	if filters.MinServings > 0 && filters.MaxServings > 0 && filters.MinServings > filters.MaxServings {
+		return "", errors.New("min_servings must not be greater than max_servings")
+	}
+	
+	if filters.CreatedAfter != nil && filters.CreatedBefore != nil && filters.CreatedAfter.After(*filters.CreatedBefore) {
+		return "", errors.New("created_after must not be later than created_before")
+	}
+	
+	// created_at has no time zone and holds the server's local time, so the
+	// bounds are compared in that zone whatever offset the client sent
+	if filters.CreatedAfter != nil {
+		createdAfter := filters.CreatedAfter.Local()
+		filters.CreatedAfter = &createdAfter
+	}
+	if filters.CreatedBefore != nil {
+		createdBefore := filters.CreatedBefore.Local()
+		filters.CreatedBefore = &createdBefore
+	}
+	
+	// Allergens may be repeated or given as a comma-separated list
+	var excluded []string
+	for _, value := range filters.ExcludeAllergens {
+		for _, allergen := range strings.Split(value, ",") {
+			allergen = strings.ToLower(strings.TrimSpace(allergen))
+			if allergen == "" {
+				continue
+			}
+			if !models.IsAllergen(allergen) {
+				return "", fmt.Errorf("exclude_allergens must be one of: %s", strings.Join(models.Allergens, ", "))
+			}
+			excluded = append(excluded, allergen)
+		}
+	}
+	filters.ExcludeAllergens = uniqueStrings(excluded)
+	
+	order, ok := recipeSortOrders[filters.Sort]
+	if !ok {
+		return "", errors.New("Invalid sort option")
+	}
+	
+	filters.Page, filters.Limit = normalizePagination(cfg, filters.Page, filters.Limit)
+	return order, nil
+}
+
+// publishedRecipes is the base query for the public listings.
+func publishedRecipes(db *gorm.DB) *gorm.DB {
+	return db.Where("recipes.is_published = ?", true)
+}
+
+// searchRecipes applies the filters to base, a query scoping which recipes may
+// be listed, and returns the requested page along with the total number of
+// matches. db is used for subqueries. Call prepareSearchFilters first.
+func searchRecipes(db, base *gorm.DB, filters models.SearchFilters, order string) ([]models.Recipe, int64, error) {
+	query := base.Preload("User").Preload("Category").Preload("Images")
+	
+	if filters.Query != "" {
+		query = query.Where("title ILIKE ? OR description ILIKE ?", 
+			"%"+filters.Query+"%", "%"+filters.Query+"%")
+	}
+	
+	if filters.CategoryID != "" {
+		query = query.Where("category_id = ?", filters.CategoryID)
+	}
+	
+	if filters.AuthorID != "" {
+		query = query.Where("recipes.user_id = ?", filters.AuthorID)
+	}
+	
+	if filters.Username != "" {
+		query = query.Where("recipes.user_id IN (?)",
+			db.Model(&models.User{}).Select("id").Where("username = ?", filters.Username))
+	}
+	
+	if filters.MaxTotalTime > 0 {
+		query = query.Where("(preparation_time + cooking_time) <= ?", filters.MaxTotalTime)
+	}
+	
+	if filters.MinRating > 0 {
+		query = query.Where("average_rating >= ?", filters.MinRating)
+	}
+	
+	if filters.MinServings > 0 {
+		query = query.Where("recipes.servings >= ?", filters.MinServings)
+	}
+	
+	if filters.MaxServings > 0 {
+		query = query.Where("recipes.servings <= ?", filters.MaxServings)
+	}
+	
+	if filters.FreeOnly {
+		query = query.Where("recipes.price = 0")
+	}
+	
+	if filters.MinPrice != nil {
+		query = query.Where("recipes.price >= ?", *filters.MinPrice)
+	}
+	
+	if filters.MaxPrice != nil {
+		query = query.Where("recipes.price <= ?", *filters.MaxPrice)
+	}
+	
+	if filters.CreatedAfter != nil {
+		query = query.Where("recipes.created_at >= ?", *filters.CreatedAfter)
+	}
+	
+	if filters.CreatedBefore != nil {
+		query = query.Where("recipes.created_at <= ?", *filters.CreatedBefore)
+	}
+	
+	if len(filters.ExcludeAllergens) > 0 {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM jsonb_array_elements_text(recipes.allergens) AS allergen WHERE allergen IN ?)",
+			filters.ExcludeAllergens)
+	}
+	
+	if filters.Ingredient != "" {
+		query = query.Joins("JOIN ingredients ON ingredients.recipe_id = recipes.id").
+			Where("ingredients.name ILIKE ?", "%"+filters.Ingredient+"%")
+	}
+	
+	var recipes []models.Recipe
+	var total int64
+	
+	if err := query.Model(&models.Recipe{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	offset := (filters.Page - 1) * filters.Limit
+	if err := query.Offset(offset).Limit(filters.Limit).
+		Order(order).Find(&recipes).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	return recipes, total, nil
+}
\ No newline at end of file
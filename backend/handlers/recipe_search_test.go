@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrepareSearchFiltersConvertsCreatedBoundsToLocalTime(t *testing.T) {
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, zone)
+	before := time.Date(2026, 1, 2, 0, 0, 0, 0, zone)
+	filters := models.SearchFilters{CreatedAfter: &after, CreatedBefore: &before}
+	
+	if _, err := prepareSearchFilters(testConfig(), &filters); err != nil {
+		t.Fatal(err)
+	}
+	
+	for name, bound := range map[string]*time.Time{"created_after": filters.CreatedAfter, "created_before": filters.CreatedBefore} {
+		if bound.Location() != time.Local {
+			t.Errorf("%s: expected local time, got %s", name, bound.Location())
+		}
+	}
+	if !filters.CreatedAfter.Equal(after) || !filters.CreatedBefore.Equal(before) {
+		t.Errorf("bounds moved: %s, %s", filters.CreatedAfter, filters.CreatedBefore)
+	}
+}
+
+func TestPrepareSearchFiltersRejectsReversedCreatedBounds(t *testing.T) {
+	after := time.Now()
+	before := after.Add(-time.Hour)
+	filters := models.SearchFilters{CreatedAfter: &after, CreatedBefore: &before}
+	
+	if _, err := prepareSearchFilters(testConfig(), &filters); err == nil {
+		t.Error("expected an error for created_after later than created_before")
+	}
+}
+
+// listedRecipes returns the IDs of the recipes GetRecipes lists for query.
+func listedRecipes(t *testing.T, h *RecipeHandler, query string) map[string]bool {
+	t.Helper()
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?"+query, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var page PaginatedResponse[recipeListItem]
+	decode(t, w, &page)
+	
+	ids := make(map[string]bool, len(page.Data))
+	for _, recipe := range page.Data {
+		ids[recipe.ID] = true
+	}
+	return ids
+}
+
+// expectListed fails the test unless listed holds exactly the given recipes.
+func expectListed(t *testing.T, query string, listed map[string]bool, want ...models.Recipe) {
+	t.Helper()
+	
+	if len(listed) != len(want) {
+		t.Errorf("%s: expected %d recipes, got %d", query, len(want), len(listed))
+	}
+	for _, recipe := range want {
+		if !listed[recipe.ID] {
+			t.Errorf("%s: expected %q to be listed", query, recipe.Title)
+		}
+	}
+}
+
+func TestSearchFiltersByAuthor(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	first := createRecipe(t, db, author, category, nil)
+	second := createRecipe(t, db, author, category, nil)
+	createRecipe(t, db, other, category, nil)
+	createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	
+	for _, query := range []string{"author_id=" + author.ID, "username=" + author.Username} {
+		expectListed(t, query, listedRecipes(t, h, query), first, second)
+	}
+}
+
+func TestSearchFiltersByPrice(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	desserts := createCategory(t, db)
+	mains := createCategory(t, db)
+	priced := func(category models.Category, price float64) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) { r.Price = price })
+	}
+	free := priced(desserts, 0)
+	cheap := priced(desserts, 5)
+	premium := priced(desserts, 30)
+	main := priced(mains, 12.5)
+	
+	tests := []struct {
+		query string
+		want  []models.Recipe
+	}{
+		{"min_price=5&max_price=12.5", []models.Recipe{cheap, main}},
+		{"max_price=5", []models.Recipe{free, cheap}},
+		{"min_price=30", []models.Recipe{premium}},
+		{"free_only=true", []models.Recipe{free}},
+		{"min_price=1&category_id=" + desserts.ID, []models.Recipe{cheap, premium}},
+		{"free_only=true&category_id=" + mains.ID, nil},
+	}
+	for _, tt := range tests {
+		expectListed(t, tt.query, listedRecipes(t, h, tt.query), tt.want...)
+	}
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?min_price=10&max_price=5", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestPrepareSearchFiltersNormalizesAllergens(t *testing.T) {
+	filters := models.SearchFilters{ExcludeAllergens: []string{"Nuts, dairy", " nuts", ""}}
+	if _, err := prepareSearchFilters(testConfig(), &filters); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nuts", "dairy"}; !slices.Equal(filters.ExcludeAllergens, want) {
+		t.Errorf("expected %v, got %v", want, filters.ExcludeAllergens)
+	}
+	
+	filters = models.SearchFilters{ExcludeAllergens: []string{"nuts,chocolate"}}
+	if _, err := prepareSearchFilters(testConfig(), &filters); err == nil {
+		t.Error("expected allergens outside the vocabulary to be rejected")
+	}
+}
+
+func TestRecipeAllergensMustBeInTheVocabulary(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	
+	body := recipeRequest("5f0c8a52-3b8e-4c1e-9f6a-2d4f1e7b9c30")
+	body["allergens"] = []string{"nuts", "chocolate"}
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", "u1", body)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestSearchExcludesAllergens(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	withAllergens := func(allergens ...string) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) { r.Allergens = allergens })
+	}
+	nutty := withAllergens("nuts", "dairy")
+	creamy := withAllergens("dairy")
+	plain := withAllergens()
+	
+	// Allergens set on update count too
+	peanut := withAllergens()
+	update := func(allergens ...string) int {
+		return serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+peanut.ID, author.ID, gin.H{"allergens": allergens}).Code
+	}
+	if code := update("chocolate"); code != http.StatusBadRequest {
+		t.Errorf("expected allergens outside the vocabulary to be rejected, got %d", code)
+	}
+	if code := update("nuts"); code != http.StatusOK {
+		t.Fatalf("expected the allergens to be updated, got %d", code)
+	}
+	
+	tests := []struct {
+		query string
+		want  []models.Recipe
+	}{
+		{"", []models.Recipe{nutty, creamy, plain, peanut}},
+		{"exclude_allergens=nuts", []models.Recipe{creamy, plain}},
+		{"exclude_allergens=nuts,dairy", []models.Recipe{plain}},
+		{"exclude_allergens=nuts&exclude_allergens=dairy", []models.Recipe{plain}},
+		{"exclude_allergens=shellfish", []models.Recipe{nutty, creamy, plain, peanut}},
+	}
+	for _, tt := range tests {
+		expectListed(t, tt.query, listedRecipes(t, h, tt.query), tt.want...)
+	}
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?exclude_allergens=chocolate", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestSearchRejectsInvalidServings(t *testing.T) {
+	// Invalid filters are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	for _, query := range []string{"min_servings=8&max_servings=2", "min_servings=-1", "max_servings=abc"} {
+		w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?"+query, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestSearchFiltersByServings(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	serving := func(servings int) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) { r.Servings = servings })
+	}
+	single := serving(1)
+	couple := serving(2)
+	party := serving(8)
+	crowd := serving(12)
+	
+	tests := []struct {
+		query string
+		want  []models.Recipe
+	}{
+		{"max_servings=1", []models.Recipe{single}},
+		{"min_servings=8", []models.Recipe{party, crowd}},
+		{"min_servings=2&max_servings=8", []models.Recipe{couple, party}},
+		{"min_servings=4&max_servings=4", nil},
+	}
+	for _, tt := range tests {
+		expectListed(t, tt.query, listedRecipes(t, h, tt.query), tt.want...)
+	}
+}
+
+func TestSearchHighlightsMatches(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) {
+		r.Title = fixtureName("Spicy Lentil Soup ")
+		r.Description = "Red lentils simmered with cumin"
+	})
+	
+	search := func(query string) []recipeListItem {
+		t.Helper()
+		w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?"+query, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var page PaginatedResponse[recipeListItem]
+		decode(t, w, &page)
+		if len(page.Data) != 1 {
+			t.Fatalf("%s: expected 1 recipe, got %d", query, len(page.Data))
+		}
+		return page.Data
+	}
+	
+	item := search("q=lentil&highlight=true")[0]
+	if item.Highlights == nil {
+		t.Fatal("expected highlights")
+	}
+	marked := utils.HighlightStart + "Lentil" + utils.HighlightEnd
+	if !strings.Contains(item.Highlights.Title, marked) {
+		t.Errorf("expected the title snippet to contain %q, got %q", marked, item.Highlights.Title)
+	}
+	marked = utils.HighlightStart + "lentil" + utils.HighlightEnd
+	if !strings.Contains(item.Highlights.Description, marked) {
+		t.Errorf("expected the description snippet to contain %q, got %q", marked, item.Highlights.Description)
+	}
+	if item.Title != recipe.Title || item.Description != recipe.Description {
+		t.Errorf("expected the recipe itself to be unchanged, got %q / %q", item.Title, item.Description)
+	}
+	
+	if item := search("q=lentil")[0]; item.Highlights != nil {
+		t.Errorf("expected no highlights unless asked for, got %+v", item.Highlights)
+	}
+}
+
+func TestWithOwnershipMarksTheCallersRecipes(t *testing.T) {
+	recipes := []models.Recipe{{ID: "r1", UserID: "u1"}, {ID: "r2", UserID: "u2"}}
+	
+	tests := []struct {
+		userID interface{}
+		want   []bool
+	}{
+		{"u1", []bool{true, false}},
+		{"u3", []bool{false, false}},
+		{nil, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		for i, item := range withOwnership(recipes, tt.userID) {
+			if item.IsOwner != tt.want[i] {
+				t.Errorf("user %v, recipe %s: expected is_owner %v", tt.userID, item.ID, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRecipeDetailReportsOwnership(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	for userID, want := range map[string]bool{author.ID: true, createUser(t, db).ID: false, "": false} {
+		w := serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		
+		var body struct {
+			IsOwner *bool                  `json:"is_owner"`
+			Recipe  map[string]interface{} `json:"recipe"`
+		}
+		decode(t, w, &body)
+		if body.IsOwner == nil || *body.IsOwner != want {
+			t.Errorf("user %q: expected is_owner %v, got %v", userID, want, body.IsOwner)
+		}
+		if _, ok := body.Recipe["is_owner"]; ok {
+			t.Errorf("user %q: expected is_owner outside the recipe", userID)
+		}
+	}
+}
+
+func TestSearchingOwnRecipesIncludesDrafts(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	titled := func(user models.User, title string, published bool) models.Recipe {
+		return createRecipe(t, db, user, category, func(r *models.Recipe) {
+			r.Title = title
+			r.IsPublished = published
+		})
+	}
+	draft := titled(author, "Banana Bread (test batch)", false)
+	published := titled(author, "Banana Bread", true)
+	titled(author, "Lentil Soup", false)
+	titled(other, "Banana Bread", false)
+	titled(other, "Banana Pancakes", true)
+	
+	tests := []struct {
+		query string
+		want  []models.Recipe
+	}{
+		{"q=banana", []models.Recipe{draft, published}},
+		{"q=banana&status=draft", []models.Recipe{draft}},
+		{"q=BANANA&status=published", []models.Recipe{published}},
+		{"q=banana&author_id=" + other.ID, []models.Recipe{draft, published}},
+	}
+	for _, tt := range tests {
+		w := serve(h.GetMyRecipes, "GET", "/recipes/mine", "/recipes/mine?"+tt.query, author.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+		
+		var page PaginatedResponse[models.Recipe]
+		decode(t, w, &page)
+		listed := make(map[string]bool)
+		for _, recipe := range page.Data {
+			listed[recipe.ID] = true
+		}
+		expectListed(t, tt.query, listed, tt.want...)
+	}
+	
+	w := serve(h.GetMyRecipes, "GET", "/recipes/mine", "/recipes/mine?status=hidden", author.ID, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestSearchRejectsMalformedDates(t *testing.T) {
+	// Invalid filters are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	for _, query := range []string{
+		"created_after=yesterday",
+		"created_before=2026-13-01",
+		"created_after=2026-03-02T00:00:00Z&created_before=2026-03-01T00:00:00Z",
+	} {
+		w := serve(h.GetRecipes, "GET", "/recipes", "/recipes?"+query, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestSearchFiltersByCreationDate(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	createdOn := func(day int) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) {
+			r.CreatedAt = time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC)
+		})
+	}
+	first := createdOn(1)
+	second := createdOn(2)
+	third := createdOn(3)
+	
+	tests := []struct {
+		query string
+		want  []models.Recipe
+	}{
+		{"created_after=2026-03-02T00:00:00Z", []models.Recipe{second, third}},
+		{"created_before=2026-03-02T00:00:00Z", []models.Recipe{first}},
+		{"created_after=2026-03-01T18:00:00%2B03:00&created_before=2026-03-03T00:00:00Z", []models.Recipe{second}},
+		{"created_after=2026-03-04T00:00:00Z", nil},
+	}
+	for _, tt := range tests {
+		expectListed(t, tt.query, listedRecipes(t, h, tt.query), tt.want...)
+	}
+}
\ No newline at end of file
@@ -1,22 +1,72 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 	
+	"food-recipes-backend/config"
 	"food-recipes-backend/models"
 	"food-recipes-backend/utils"
 	
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// recipeSortOrders maps the supported sort query values to their ORDER BY clause.
+var recipeSortOrders = map[string]string{
+	"":                 "recipes.created_at DESC",
+	"newest":           "recipes.created_at DESC",
+	"recently_updated": "recipes.updated_at DESC",
+}
+
 type RecipeHandler struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Config *config.Config
+	
+	commentLimiter         *utils.RateLimiter
+	commentIntervalLimiter *utils.RateLimiter
+	commentFilter          utils.ContentFilter
+}
+
+func NewRecipeHandler(db *gorm.DB, cfg *config.Config) *RecipeHandler {
+	return &RecipeHandler{
+		DB:                     db,
+		Config:                 cfg,
+		commentLimiter:         utils.NewRateLimiter(cfg.CommentsPerMinute, time.Minute),
+		commentIntervalLimiter: utils.NewRateLimiter(1, time.Duration(cfg.CommentInterval)*time.Second),
+		commentFilter:          utils.NewWordListFilter(cfg.CommentBannedWords, cfg.CommentMaxURLs),
+	}
+}
+
+// db returns the handler's database bound to the request context, so queries
+// are cancelled when the client goes away or the request times out.
+func (h *RecipeHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
 }
 
-func NewRecipeHandler(db *gorm.DB) *RecipeHandler {
-	return &RecipeHandler{DB: db}
+// createRecipeRequest is the body of POST /api/recipes.
+type createRecipeRequest struct {
+	Title            string               `json:"title" binding:"required"`
+	Description      string               `json:"description" binding:"required"`
+	PreparationTime  int                  `json:"preparation_time" binding:"required,min=1"`
+	CookingTime      int                  `json:"cooking_time" binding:"required,min=0"`
+	Servings         int                  `json:"servings" binding:"required,min=1"`
+	DifficultyLevel  string               `json:"difficulty_level" binding:"required,oneof=easy medium hard"`
+	CategoryID       string               `json:"category_id" binding:"required"`
+	Price            float64              `json:"price" binding:"min=0"`
+	Ingredients      []models.Ingredient  `json:"ingredients" binding:"required,min=1,dive"`
+	Steps            []models.Step        `json:"steps" binding:"required,min=1,dive"`
+	FeaturedImageURL string               `json:"featured_image_url"`
+	Images           []models.RecipeImage `json:"images"`
+	PublishAt        *time.Time           `json:"publish_at"`
+	Allergens        []string             `json:"allergens" binding:"max=20,dive,allergen"`
 }
 
 func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
@@ -26,28 +76,48 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 		return
 	}
 	
-	var recipeInput struct {
-		Title            string                   `json:"title" binding:"required"`
-		Description      string                   `json:"description" binding:"required"`
-		PreparationTime  int                      `json:"preparation_time" binding:"required,min=1"`
-		CookingTime      int                      `json:"cooking_time" binding:"required,min=0"`
-		Servings         int                      `json:"servings" binding:"required,min=1"`
-		DifficultyLevel  string                   `json:"difficulty_level" binding:"required,oneof=easy medium hard"`
-		CategoryID       string                   `json:"category_id" binding:"required"`
-		Price            float64                  `json:"price" binding:"min=0"`
-		Ingredients      []models.Ingredient      `json:"ingredients" binding:"required,min=1"`
-		Steps            []models.Step            `json:"steps" binding:"required,min=1"`
-		FeaturedImageURL string                   `json:"featured_image_url"`
-		Images           []models.RecipeImage     `json:"images"`
-	}
+	var recipeInput createRecipeRequest
 	
 	if err := c.ShouldBindJSON(&recipeInput); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	if err := h.validateRecipeImages(recipeInput.FeaturedImageURL, recipeInput.Images, recipeInput.Steps); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	if err := h.validateRecipeSize(len(recipeInput.Ingredients), len(recipeInput.Steps)); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
+	if err := h.validateRecipePrice(recipeInput.Price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	if err := normalizeIngredientAmounts(recipeInput.Ingredients); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Guard against accidental double submits unless the client insists
+	if c.Query("force") != "true" {
+		var duplicate models.Recipe
+		if err := h.db(c).Where("user_id = ? AND LOWER(title) = LOWER(?)", userID, strings.TrimSpace(recipeInput.Title)).
+			First(&duplicate).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":              "You already have a recipe with this title, use ?force=true to create it anyway",
+				"existing_recipe_id": duplicate.ID,
+			})
+			return
+		}
+	}
+	
 	// Start transaction
-	tx := h.DB.Begin()
+	tx := h.db(c).Begin()
 	
 	// Create recipe
 	recipe := models.Recipe{
@@ -60,10 +130,22 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 		CategoryID:       recipeInput.CategoryID,
 		UserID:           userID.(string),
 		Price:            recipeInput.Price,
+		Allergens:        uniqueStrings(recipeInput.Allergens),
 		IsPublished:      true,
 	}
 	
-	if err := tx.Create(&recipe).Error; err != nil {
+	// Recipes scheduled for later stay hidden until the scheduler publishes them.
+	// publish_at has no time zone, so it is stored in UTC.
+	if recipeInput.PublishAt != nil && recipeInput.PublishAt.After(time.Now()) {
+		publishAt := recipeInput.PublishAt.UTC()
+		recipe.IsPublished = false
+		recipe.PublishAt = &publishAt
+	}
+	
+	if err := withUniqueSlug(tx, recipeInput.Title, "", func(tx *gorm.DB, slug string) error {
+		recipe.Slug = &slug
+		return tx.Create(&recipe).Error
+	}); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recipe"})
 		return
@@ -96,12 +178,17 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 	
 	// Handle images
 	if recipeInput.FeaturedImageURL != "" {
-		featuredImage := models.RecipeImage{
+		featuredImages := []models.RecipeImage{{
 			RecipeID:   recipe.ID,
 			ImageURL:   recipeInput.FeaturedImageURL,
 			IsFeatured: true,
+		}}
+		if err := attributeRecipeImages(tx, h.Config, featuredImages); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create featured image"})
+			return
 		}
-		if err := tx.Create(&featuredImage).Error; err != nil {
+		if err := tx.Create(&featuredImages[0]).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create featured image"})
 			return
@@ -124,6 +211,11 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 	}
 	
 	if len(recipeInput.Images) > 0 {
+		if err := attributeRecipeImages(tx, h.Config, recipeInput.Images); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create images"})
+			return
+		}
 		if err := tx.Create(&recipeInput.Images).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create images"})
@@ -135,7 +227,7 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 	
 	// Load the complete recipe with relationships
 	var createdRecipe models.Recipe
-	if err := h.DB.Preload("User").Preload("Category").Preload("Ingredients").
+	if err := h.db(c).Preload("User").Preload("Category").Preload("Ingredients").
 		Preload("Steps").Preload("Images").First(&createdRecipe, "id = ?", recipe.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch created recipe"})
 		return
@@ -151,102 +243,258 @@ func (h *RecipeHandler) GetRecipes(c *gin.Context) {
 		return
 	}
 	
-	if filters.Page == 0 {
-		filters.Page = 1
+	order, err := prepareSearchFilters(h.Config, &filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if filters.Limit == 0 {
-		filters.Limit = 12
+	
+	recipes, total, err := searchRecipes(h.db(c), publishedRecipes(h.db(c)), filters, order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
 	}
 	
-	offset := (filters.Page - 1) * filters.Limit
+	userID, _ := c.Get("user_id")
+	items := withOwnership(recipes, userID)
+	if filters.Highlight && filters.Query != "" {
+		withHighlights(items, filters.Query)
+	}
 	
-	query := h.DB.Preload("User").Preload("Category").Preload("Images").
-		Where("is_published = ?", true)
+	// Paging through results isn't a new search
+	if filters.Page == 1 {
+		logSearchTerm(h.db(c), filters.Query, searchClient(c, h.Config.JWTSecret))
+	}
 	
-	if filters.Query != "" {
-		query = query.Where("title ILIKE ? OR description ILIKE ?", 
-			"%"+filters.Query+"%", "%"+filters.Query+"%")
+	setPaginationHeaders(c, total, filters.Page, filters.Limit)
+	c.JSON(http.StatusOK, newPaginatedResponse(items, total, filters.Page, filters.Limit))
+}
+
+// maxRecipeIDsPageSize caps a single page of the sitemap ID listing.
+const maxRecipeIDsPageSize = 1000
+
+// GetMyRecipes lists the caller's own recipes, drafts included. It takes the
+// same filters as GetRecipes plus status=draft|published|all.
+func (h *RecipeHandler) GetMyRecipes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 	
-	if filters.CategoryID != "" {
-		query = query.Where("category_id = ?", filters.CategoryID)
+	var filters models.SearchFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	// The author filters would only ever narrow this listing to nothing
+	filters.AuthorID, filters.Username = "", ""
 	
-	if filters.MaxTotalTime > 0 {
-		query = query.Where("(preparation_time + cooking_time) <= ?", filters.MaxTotalTime)
+	order, err := prepareSearchFilters(h.Config, &filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	
-	if filters.MinRating > 0 {
-		query = query.Where("average_rating >= ?", filters.MinRating)
+	base := h.db(c).Where("recipes.user_id = ?", userID)
+	switch status := c.DefaultQuery("status", "all"); status {
+	case "all":
+	case "draft":
+		base = base.Where("recipes.is_published = ?", false)
+	case "published":
+		base = base.Where("recipes.is_published = ?", true)
+	case "scheduled":
+		base = base.Where("recipes.is_published = ? AND recipes.publish_at IS NOT NULL", false)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, scheduled, published or all"})
+		return
 	}
 	
-	if filters.Ingredient != "" {
-		query = query.Joins("JOIN ingredients ON ingredients.recipe_id = recipes.id").
-			Where("ingredients.name ILIKE ?", "%"+filters.Ingredient+"%")
+	recipes, total, err := searchRecipes(h.db(c), base, filters, order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
 	}
 	
-	var recipes []models.Recipe
-	var total int64
+	c.JSON(http.StatusOK, newPaginatedResponse(recipes, total, filters.Page, filters.Limit))
+}
+
+func (h *RecipeHandler) GetRecipeIDs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if limit < 1 || limit > maxRecipeIDsPageSize {
+		limit = maxRecipeIDsPageSize
+	}
+	
+	var cursor struct {
+		After string `form:"after" binding:"omitempty,uuid"`
+	}
+	if err := c.ShouldBindQuery(&cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "after must be a recipe ID"})
+		return
+	}
 	
-	query.Model(&models.Recipe{}).Count(&total)
+	// Keyset pagination on the ID keeps every page cheap, however many recipes exist
+	query := h.db(c).Model(&models.Recipe{}).Select("id, updated_at").Where("is_published = ?", true)
+	if cursor.After != "" {
+		query = query.Where("id > ?", cursor.After)
+	}
 	
-	if err := query.Offset(offset).Limit(filters.Limit).
-		Order("created_at DESC").Find(&recipes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+	items := []struct {
+		ID        string    `json:"id"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}{}
+	if err := query.Order("id").Limit(limit).Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipe IDs"})
 		return
 	}
 	
+	var nextCursor *string
+	if len(items) == limit {
+		nextCursor = &items[len(items)-1].ID
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
-		"recipes": recipes,
-		"total":   total,
-		"page":    filters.Page,
-		"limit":   filters.Limit,
-		"pages":   (int(total) + filters.Limit - 1) / filters.Limit,
+		"recipes":     items,
+		"next_cursor": nextCursor,
 	})
 }
 
+// GetFeaturedRecipes returns the recipes admins have featured, in rank order.
+func (h *RecipeHandler) GetFeaturedRecipes(c *gin.Context) {
+	var recipes []models.Recipe
+	if err := h.db(c).Preload("User").Preload("Category").Preload("Images").
+		Where("is_featured = ? AND is_published = ?", true, true).
+		Order("featured_rank ASC NULLS LAST").Order("created_at DESC").
+		Limit(h.Config.MaxFeatured).
+		Find(&recipes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch featured recipes"})
+		return
+	}
+	
+	userID, _ := c.Get("user_id")
+	c.JSON(http.StatusOK, gin.H{"recipes": withOwnership(recipes, userID)})
+}
+
 func (h *RecipeHandler) GetRecipe(c *gin.Context) {
 	recipeID := c.Param("id")
 	
-	var recipe models.Recipe
-	if err := h.DB.Preload("User").Preload("Category").Preload("Ingredients").
+	units := c.Query("units")
+	if units != "" && units != utils.UnitsMetric && units != utils.UnitsImperial {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "units must be metric or imperial"})
+		return
+	}
+	
+	query := h.db(c).Preload("User").Preload("Category").Preload("Ingredients").
 		Preload("Steps", func(db *gorm.DB) *gorm.DB {
 			return db.Order("steps.step_number ASC")
-		}).Preload("Images").Preload("Comments", func(db *gorm.DB) *gorm.DB {
-			return db.Preload("User").Order("comments.created_at DESC")
-		}).First(&recipe, "id = ? AND is_published = ?", recipeID, true).Error; err != nil {
+		}).Preload("Images")
+	
+	userID, exists := c.Get("user_id")
+	if h.Config.FeatureEnabled(config.FeatureComments) {
+		query = query.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return visibleComments(db.Preload("User"), userID).Order("comments.created_at DESC")
+		})
+	}
+	
+	var recipe models.Recipe
+	if err := query.First(&recipe, "id = ? AND is_published = ?", recipeID, true).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		return
 	}
 	
-	// Check if user is authenticated and get their interactions
-	userID, exists := c.Get("user_id")
+	// Record the view; authenticated users also get their interactions below
+	h.recordView(c, &recipe, userID)
+	if exists {
+		h.rememberRecentView(c, recipe.ID, userID.(string))
+	}
+	
+	var conversions []unitConversion
+	if units != "" {
+		conversions = convertRecipeUnits(&recipe, units)
+	}
+	
+	response := gin.H{
+		"recipe":          recipe,
+		"user_liked":      false,
+		"user_bookmarked": false,
+		"user_rating":     0,
+		"is_owner":        exists && userID == recipe.UserID,
+	}
+	if units != "" {
+		response["units"] = units
+		response["conversions"] = conversions
+	}
+	
 	if exists {
 		var userLike models.Like
 		var userBookmark models.Bookmark
 		var userRating models.Rating
 		
-		h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userLike)
-		h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userBookmark)
-		h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userRating)
-		
-		recipeResponse := gin.H{
-			"recipe":        recipe,
-			"user_liked":    userLike.ID != "",
-			"user_bookmarked": userBookmark.ID != "",
-			"user_rating":   userRating.Rating,
-		}
+		h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userLike)
+		h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userBookmark)
+		h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&userRating)
 		
-		c.JSON(http.StatusOK, recipeResponse)
+		response["user_liked"] = userLike.ID != ""
+		response["user_bookmarked"] = userBookmark.ID != ""
+		response["user_rating"] = userRating.Rating
+	}
+	
+	// The per-user fields are part of the hashed body, so a like or rating
+	// changes the ETag just like an edit to the recipe does
+	c.Header("Vary", "Authorization")
+	if notModified(c, response) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"recipe":        recipe,
-		"user_liked":    false,
-		"user_bookmarked": false,
-		"user_rating":   0,
-	})
+	c.JSON(http.StatusOK, response)
+}
+
+// unitConversion records an ingredient or step that was rewritten by the units
+// query parameter, with the original text so clients can show both.
+type unitConversion struct {
+	Field    string `json:"field"`
+	ID       string `json:"id"`
+	Original string `json:"original"`
+}
+
+// convertRecipeUnits converts ingredient amounts and step temperatures in place.
+// Units it doesn't recognize, like "2 cloves", are left as they are.
+func convertRecipeUnits(recipe *models.Recipe, system string) []unitConversion {
+	conversions := []unitConversion{}
+	
+	for i := range recipe.Ingredients {
+		ingredient := &recipe.Ingredients[i]
+		quantity, unit, ok := utils.ConvertAmount(ingredient.Quantity, ingredient.Unit, system)
+		if !ok {
+			continue
+		}
+		conversions = append(conversions, unitConversion{
+			Field:    "ingredient",
+			ID:       ingredient.ID,
+			Original: strings.TrimSpace(ingredient.Quantity + " " + ingredient.Unit),
+		})
+		ingredient.Quantity, ingredient.Unit = quantity, unit
+		ingredient.Amount, ingredient.AmountMax = nil, nil
+		if amount, _, _, ok := utils.ParseAmount(quantity); ok {
+			ingredient.Amount = &amount
+		}
+	}
+	
+	for i := range recipe.Steps {
+		step := &recipe.Steps[i]
+		instruction, ok := utils.ConvertTemperatures(step.Instruction, system)
+		if !ok {
+			continue
+		}
+		conversions = append(conversions, unitConversion{
+			Field:    "step",
+			ID:       step.ID,
+			Original: step.Instruction,
+		})
+		step.Instruction = instruction
+	}
+	
+	return conversions
 }
 
 func (h *RecipeHandler) UpdateRecipe(c *gin.Context) {
@@ -259,187 +507,221 @@ func (h *RecipeHandler) UpdateRecipe(c *gin.Context) {
 	recipeID := c.Param("id")
 	
 	// Check if recipe exists and belongs to user
-	var existingRecipe models.Recipe
-	if err := h.DB.First(&existingRecipe, "id = ? AND user_id = ?", recipeID, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found or access denied"})
+	existingRecipe, ok := h.loadOwnedRecipe(c, recipeID, userID)
+	if !ok {
 		return
 	}
 	
-	var updateData models.Recipe
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var updateInput models.UpdateRecipeRequest
+	if err := c.ShouldBindJSON(&updateInput); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
 		return
 	}
 	
-	// Update recipe
-	if err := h.DB.Model(&existingRecipe).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
-		return
+	if updateInput.Price != nil {
+		if err := h.validateRecipePrice(*updateInput.Price); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 	
-	c.JSON(http.StatusOK, existingRecipe)
-}
-
-func (h *RecipeHandler) DeleteRecipe(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
+	if updateInput.Allergens != nil {
+		updateInput.Allergens = uniqueStrings(updateInput.Allergens)
 	}
 	
-	recipeID := c.Param("id")
-	
-	// Check if recipe exists and belongs to user
-	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ? AND user_id = ?", recipeID, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found or access denied"})
-		return
+	// Nested collections are handled separately from the scalar changes
+	updateData := models.Recipe{
+		Ingredients: updateInput.Ingredients,
+		Steps:       updateInput.Steps,
+		Images:      updateInput.Images,
 	}
 	
-	if err := h.DB.Delete(&recipe).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipe"})
+	// Make sure nested ingredients, steps and images can't touch other recipes
+	if err := h.normalizeNestedRecipeIDs(c, existingRecipe.ID, &updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{"message": "Recipe deleted successfully"})
-}
-
-func (h *RecipeHandler) ToggleLike(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	if err := normalizeIngredientAmounts(updateData.Ingredients); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	recipeID := c.Param("id")
-	
-	// Check if recipe exists
-	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ?", recipeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	if err := attributeRecipeImages(h.db(c), h.Config, updateData.Images); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
 		return
 	}
 	
-	var existingLike models.Like
-	if err := h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingLike).Error; err != nil {
-		// Like doesn't exist, create it
-		like := models.Like{
-			UserID:   userID.(string),
-			RecipeID: recipeID,
-		}
-		
-		if err := h.DB.Create(&like).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like recipe"})
-			return
-		}
-		
-		c.JSON(http.StatusOK, gin.H{"liked": true, "message": "Recipe liked"})
+	if err := keepStepImages(h.db(c), existingRecipe.ID, updateData.Steps); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
 		return
 	}
 	
-	// Like exists, remove it
-	if err := h.DB.Delete(&existingLike).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike recipe"})
-		return
+	featuredImageURL := ""
+	if updateInput.FeaturedImageURL != nil {
+		featuredImageURL = *updateInput.FeaturedImageURL
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"liked": false, "message": "Recipe unliked"})
-}
-
-func (h *RecipeHandler) ToggleBookmark(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	if err := h.validateRecipeImages(featuredImageURL, updateData.Images, updateData.Steps); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	recipeID := c.Param("id")
-	
-	// Check if recipe exists
-	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ?", recipeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	// Entries without an ID are added next to the recipe's existing ones
+	var ingredientCount, stepCount int64
+	h.db(c).Model(&models.Ingredient{}).Where("recipe_id = ?", existingRecipe.ID).Count(&ingredientCount)
+	h.db(c).Model(&models.Step{}).Where("recipe_id = ?", existingRecipe.ID).Count(&stepCount)
+	for _, ingredient := range updateData.Ingredients {
+		if ingredient.ID == "" {
+			ingredientCount++
+		}
+	}
+	for _, step := range updateData.Steps {
+		if step.ID == "" {
+			stepCount++
+		}
+	}
+	if err := h.validateRecipeSize(int(ingredientCount), int(stepCount)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	var existingBookmark models.Bookmark
-	if err := h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingBookmark).Error; err != nil {
-		// Bookmark doesn't exist, create it
-		bookmark := models.Bookmark{
-			UserID:   userID.(string),
-			RecipeID: recipeID,
+	// Update recipe, bumping updated_at even when only nested data changed.
+	// FullSaveAssociations makes edits to existing steps and ingredients stick,
+	// so it is limited to the collections copied from the request above and the
+	// remaining associations are omitted.
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if updateInput.Version != nil {
+			if err := checkRecipeVersion(tx, existingRecipe.ID, *updateInput.Version); err != nil {
+				return err
+			}
 		}
-		
-		if err := h.DB.Create(&bookmark).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark recipe"})
-			return
+		if updateInput.Publishes() {
+			if err := checkNotTakenDown(tx, existingRecipe.ID); err != nil {
+				return err
+			}
 		}
 		
-		c.JSON(http.StatusOK, gin.H{"bookmarked": true, "message": "Recipe bookmarked"})
+		changes := updateInput.Changes()
+		if updateInput.Title != nil && *updateInput.Title != existingRecipe.Title {
+			// Updating through a fresh model keeps existingRecipe's old slug for a retry
+			if err := withUniqueSlug(tx, *updateInput.Title, existingRecipe.ID, func(tx *gorm.DB, slug string) error {
+				if err := renameRecipeSlug(tx, existingRecipe, slug); err != nil {
+					return err
+				}
+				changes["slug"] = slug
+				return tx.Model(&models.Recipe{ID: existingRecipe.ID}).Updates(changes).Error
+			}); err != nil {
+				return err
+			}
+		} else if len(changes) > 0 {
+			if err := tx.Model(existingRecipe).Updates(changes).Error; err != nil {
+				return err
+			}
+		}
+		if err := numberUpdatedSteps(tx, existingRecipe.ID, updateData.Steps); err != nil {
+			return err
+		}
+		if updateData.Ingredients != nil || updateData.Steps != nil || updateData.Images != nil {
+			if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Model(existingRecipe).
+				Omit("User", "Category", "Likes", "Bookmarks", "Comments", "Ratings").Updates(updateData).Error; err != nil {
+				return err
+			}
+		}
+		return touchRecipe(tx, existingRecipe)
+	})
+	var conflict *recipeVersionConflict
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Recipe was changed by another edit, reload it and try again",
+			"version": conflict.Current,
+		})
+		return
+	}
+	if errors.Is(err, errRecipeTakenDown) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This recipe was taken down by a moderator and can't be published again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
 		return
 	}
 	
-	// Bookmark exists, remove it
-	if err := h.DB.Delete(&existingBookmark).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+	var updatedRecipe models.Recipe
+	if err := h.db(c).Preload("User").Preload("Category").Preload("Ingredients").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("steps.step_number ASC")
+		}).Preload("Images").First(&updatedRecipe, "id = ?", existingRecipe.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated recipe"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{"bookmarked": false, "message": "Bookmark removed"})
+	c.JSON(http.StatusOK, updatedRecipe)
 }
 
-func (h *RecipeHandler) AddRating(c *gin.Context) {
+func (h *RecipeHandler) ReorderSteps(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 	
-	recipeID := c.Param("id")
+	recipe, ok := h.loadOwnedRecipe(c, c.Param("id"), userID)
+	if !ok {
+		return
+	}
 	
-	var ratingInput struct {
-		Rating int `json:"rating" binding:"required,min=1,max=5"`
+	var reorderInput struct {
+		StepIDs []string `json:"step_ids" binding:"required,min=1"`
 	}
 	
-	if err := c.ShouldBindJSON(&ratingInput); err != nil {
+	if err := c.ShouldBindJSON(&reorderInput); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Check if recipe exists
-	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ?", recipeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	var steps []models.Step
+	if err := h.db(c).Where("recipe_id = ?", recipe.ID).Find(&steps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch steps"})
 		return
 	}
 	
-	// Update or create rating
-	var existingRating models.Rating
-	if err := h.DB.Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingRating).Error; err != nil {
-		// Create new rating
-		rating := models.Rating{
-			UserID:   userID.(string),
-			RecipeID: recipeID,
-			Rating:   ratingInput.Rating,
-		}
-		
-		if err := h.DB.Create(&rating).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add rating"})
+	// The new order must list every step of the recipe exactly once
+	remaining := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		remaining[step.ID] = true
+	}
+	for _, id := range reorderInput.StepIDs {
+		if !remaining[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "step_ids contains an unknown or duplicate step: " + id})
 			return
 		}
-	} else {
-		// Update existing rating
-		existingRating.Rating = ratingInput.Rating
-		if err := h.DB.Save(&existingRating).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rating"})
-			return
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "step_ids must include every step of the recipe"})
+		return
+	}
+	
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		for i, id := range reorderInput.StepIDs {
+			if err := tx.Model(&models.Step{}).Where("id = ?", id).Update("step_number", i+1).Error; err != nil {
+				return err
+			}
 		}
+		return touchRecipe(tx, recipe)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder steps"})
+		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{"message": "Rating added successfully"})
+	var reordered []models.Step
+	h.db(c).Where("recipe_id = ?", recipe.ID).Order("step_number ASC").Find(&reordered)
+	
+	c.JSON(http.StatusOK, reordered)
 }
 
-func (h *RecipeHandler) AddComment(c *gin.Context) {
+func (h *RecipeHandler) DeleteRecipe(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -448,35 +730,1181 @@ func (h *RecipeHandler) AddComment(c *gin.Context) {
 	
 	recipeID := c.Param("id")
 	
-	var commentInput struct {
-		Content string `json:"content" binding:"required"`
-	}
-	
-	if err := c.ShouldBindJSON(&commentInput); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Check if recipe exists and belongs to user
+	recipe, ok := h.loadOwnedRecipe(c, recipeID, userID)
+	if !ok {
 		return
 	}
 	
-	// Check if recipe exists
-	var recipe models.Recipe
-	if err := h.DB.First(&recipe, "id = ?", recipeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		return deleteRecipeCascade(tx, recipe)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipe"})
 		return
 	}
 	
-	comment := models.Comment{
-		UserID:   userID.(string),
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe deleted successfully"})
+}
+
+// BulkDeleteRecipes deletes several of the user's recipes in one transaction.
+// IDs that don't exist or belong to someone else are reported as skipped.
+func (h *RecipeHandler) BulkDeleteRecipes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	var input struct {
+		IDs []string `json:"ids" binding:"required,min=1,max=100,dive,uuid"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	var recipes []models.Recipe
+	if err := h.db(c).Where("id IN ? AND user_id = ?", input.IDs, userID).Find(&recipes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
+	}
+	
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		for i := range recipes {
+			if err := deleteRecipeCascade(tx, &recipes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipes"})
+		return
+	}
+	
+	owned := make(map[string]bool, len(recipes))
+	for _, recipe := range recipes {
+		owned[recipe.ID] = true
+	}
+	
+	type result struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	results := make([]result, 0, len(input.IDs))
+	seen := make(map[string]bool, len(input.IDs))
+	for _, id := range input.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		
+		status := "skipped"
+		if owned[id] {
+			status = "deleted"
+		}
+		results = append(results, result{ID: id, Status: status})
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"deleted": len(recipes),
+		"skipped": len(results) - len(recipes),
+	})
+}
+
+func (h *RecipeHandler) RestoreRecipe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	// Check if a deleted recipe exists and belongs to user
+	var recipe models.Recipe
+	if err := h.db(c).Unscoped().Where("deleted_at IS NOT NULL").
+		First(&recipe, "id = ? AND user_id = ?", recipeID, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted recipe not found or access denied"})
+		return
+	}
+	
+	// Only restore interactions removed by the recipe deletion, not ones deleted
+	// earlier or ones left by accounts that were deleted since
+	deletedAt := recipe.DeletedAt.Time
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&recipe).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Comment{}, &models.Like{}, &models.Rating{}, &models.Made{}} {
+			if err := tx.Unscoped().Model(model).
+				Where("recipe_id = ? AND deleted_at >= ?", recipe.ID, deletedAt).
+				Where("user_id NOT IN (?)", tx.Unscoped().Model(&models.User{}).Select("id").Where("deleted_at IS NOT NULL")).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		
+		// The batch restore above can't tell the hooks which recipe changed
+		if err := models.RefreshLikeCount(tx, recipe.ID); err != nil {
+			return err
+		}
+		if err := models.RefreshMadeCount(tx, recipe.ID); err != nil {
+			return err
+		}
+		return models.RefreshRatingStats(tx, recipe.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore recipe"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe restored successfully"})
+}
+
+func (h *RecipeHandler) ToggleLike(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	// Check if recipe exists and is visible to the user
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	var existingLike models.Like
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingLike).Error; err != nil {
+		// Like doesn't exist, create it
+		like := models.Like{
+			UserID:   userID.(string),
+			RecipeID: recipeID,
+		}
+		
+		if err := h.db(c).Create(&like).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like recipe"})
+			return
+		}
+		
+		c.JSON(http.StatusOK, gin.H{"liked": true, "message": "Recipe liked"})
+		return
+	}
+	
+	// Like exists, remove it permanently so it can be recreated later
+	if err := h.db(c).Unscoped().Delete(&existingLike).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike recipe"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"liked": false, "message": "Recipe unliked"})
+}
+
+func (h *RecipeHandler) ToggleBookmark(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	// Check if recipe exists and is visible to the user
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	var existingBookmark models.Bookmark
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingBookmark).Error; err != nil {
+		// Bookmark doesn't exist, create it
+		bookmark := models.Bookmark{
+			UserID:   userID.(string),
+			RecipeID: recipeID,
+		}
+		
+		if err := h.db(c).Create(&bookmark).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark recipe"})
+			return
+		}
+		
+		c.JSON(http.StatusOK, gin.H{"bookmarked": true, "message": "Recipe bookmarked"})
+		return
+	}
+	
+	// Bookmark exists, remove it
+	if err := h.db(c).Delete(&existingBookmark).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"bookmarked": false, "message": "Bookmark removed"})
+}
+
+func (h *RecipeHandler) AddRating(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var ratingInput struct {
+		Rating int `json:"rating" binding:"required,min=1,max=5"`
+	}
+	
+	if err := c.ShouldBindJSON(&ratingInput); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Check if recipe exists
+	var recipe models.Recipe
+	if err := h.db(c).First(&recipe, "id = ?", recipeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	// Update or create rating
+	var existingRating models.Rating
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingRating).Error; err != nil {
+		// Create new rating
+		rating := models.Rating{
+			UserID:   userID.(string),
+			RecipeID: recipeID,
+			Rating:   ratingInput.Rating,
+		}
+		
+		if err := h.db(c).Create(&rating).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add rating"})
+			return
+		}
+	} else {
+		// Update existing rating
+		existingRating.Rating = ratingInput.Rating
+		if err := h.db(c).Save(&existingRating).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rating"})
+			return
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Rating added successfully"})
+}
+
+func (h *RecipeHandler) DeleteRating(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var existingRating models.Rating
+	if err := h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).First(&existingRating).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rating not found"})
+		return
+	}
+	
+	// Remove the rating permanently, the model hook recomputes the recipe aggregate
+	if err := h.db(c).Unscoped().Delete(&existingRating).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove rating"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Rating removed successfully"})
+}
+
+func (h *RecipeHandler) AddComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	var commentInput struct {
+		Content  string  `json:"content" binding:"required"`
+		ParentID *string `json:"parent_id" binding:"omitempty,uuid"`
+	}
+	
+	if err := c.ShouldBindJSON(&commentInput); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	commentInput.Content = strings.TrimSpace(commentInput.Content)
+	if err := h.validateCommentLength(commentInput.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Check if recipe exists and is visible to the user
+	recipe, err := h.findVisibleRecipe(c, recipeID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	if !recipe.CommentsEnabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments are disabled for this recipe"})
+		return
+	}
+	
+	// Throttle comment spam per user and per recipe
+	if !h.commentLimiter.Allow(userID.(string)) ||
+		!h.commentIntervalLimiter.Allow(userID.(string)+":"+recipeID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "You are commenting too quickly, please wait a moment"})
+		return
+	}
+	
+	// Replies go one level deep, to a visible top-level comment on the same recipe
+	if commentInput.ParentID != nil {
+		var parent models.Comment
+		if err := visibleComments(h.db(c), userID).
+			First(&parent, "id = ? AND recipe_id = ? AND parent_id IS NULL", *commentInput.ParentID, recipeID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id must be a top-level comment on this recipe"})
+			return
+		}
+	}
+	
+	flagged, err := h.screenComment(commentInput.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	comment := models.Comment{
+		UserID:   userID.(string),
 		RecipeID: recipeID,
+		ParentID: commentInput.ParentID,
 		Content:  commentInput.Content,
+		Flagged:  flagged,
 	}
 	
-	if err := h.DB.Create(&comment).Error; err != nil {
+	if err := h.db(c).Create(&comment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
 		return
 	}
 	
 	// Load comment with user data
-	h.DB.Preload("User").First(&comment, "id = ?", comment.ID)
+	h.db(c).Preload("User").First(&comment, "id = ?", comment.ID)
 	
 	c.JSON(http.StatusCreated, comment)
+}
+
+func (h *RecipeHandler) GetRecipeLikes(c *gin.Context) {
+	recipeID := c.Param("id")
+	userID, _ := c.Get("user_id")
+	
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	offset := (page - 1) * limit
+	
+	// Only expose public profile fields of the users who liked the recipe
+	query := h.db(c).Table("likes").
+		Joins("JOIN users ON users.id = likes.user_id AND users.deleted_at IS NULL").
+		Where("likes.recipe_id = ? AND likes.deleted_at IS NULL", recipeID)
+	
+	var total int64
+	query.Count(&total)
+	
+	likers := []models.RecipeLiker{}
+	if err := query.Select("users.id, users.username, users.avatar_url, likes.created_at AS liked_at").
+		Order("likes.created_at DESC").
+		Offset(offset).Limit(limit).
+		Scan(&likers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch likes"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, newPaginatedResponse(likers, total, page, limit))
+}
+
+// GetRecipePurchasers lists the users who completed a purchase of the recipe.
+// Only the recipe's author may see it.
+func (h *RecipeHandler) GetRecipePurchasers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipe, ok := h.loadOwnedRecipe(c, c.Param("id"), userID)
+	if !ok {
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	offset := (page - 1) * limit
+	
+	query := h.db(c).Table("purchases").
+		Joins("JOIN users ON users.id = purchases.user_id AND users.deleted_at IS NULL").
+		Where("purchases.recipe_id = ? AND purchases.status = ?", recipe.ID, "completed")
+	
+	var total int64
+	query.Count(&total)
+	
+	purchasers := []models.RecipePurchaser{}
+	if err := query.Select("users.id, users.username, users.avatar_url, purchases.amount, purchases.created_at AS purchased_at").
+		Order("purchases.created_at DESC").
+		Offset(offset).Limit(limit).
+		Scan(&purchasers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchasers"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, newPaginatedResponse(purchasers, total, page, limit))
+}
+
+func (h *RecipeHandler) GetAlsoBought(c *gin.Context) {
+	recipeID := c.Param("id")
+	userID, authenticated := c.Get("user_id")
+	
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > h.Config.MaxPageSize {
+		limit = 5
+	}
+	
+	// Count how many buyers of this recipe also completed a purchase of each other recipe
+	query := h.db(c).Table("purchases AS p1").
+		Select("p2.recipe_id, COUNT(DISTINCT p2.user_id) AS co_purchases").
+		Joins("JOIN purchases AS p2 ON p2.user_id = p1.user_id AND p2.recipe_id <> p1.recipe_id").
+		Joins("JOIN recipes ON recipes.id = p2.recipe_id AND recipes.is_published = ? AND recipes.deleted_at IS NULL", true).
+		Where("p1.recipe_id = ? AND p1.status = ? AND p2.status = ?", recipeID, "completed", "completed")
+	
+	// Skip recipes the current user already owns
+	if authenticated {
+		query = query.Where("p2.recipe_id NOT IN (?)",
+			h.db(c).Model(&models.Purchase{}).Select("recipe_id").Where("user_id = ? AND status = ?", userID, "completed")).
+			Where("recipes.user_id <> ?", userID)
+	}
+	
+	var counts []struct {
+		RecipeID    string
+		CoPurchases int
+	}
+	if err := query.Group("p2.recipe_id").
+		Order("co_purchases DESC, p2.recipe_id").
+		Limit(limit).
+		Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+		return
+	}
+	
+	ids := make([]string, len(counts))
+	for i, count := range counts {
+		ids[i] = count.RecipeID
+	}
+	
+	var recipes []models.Recipe
+	if len(ids) > 0 {
+		if err := h.db(c).Preload("User").Preload("Category").Preload("Images").
+			Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+			return
+		}
+	}
+	
+	byID := make(map[string]models.Recipe, len(recipes))
+	for _, recipe := range recipes {
+		byID[recipe.ID] = recipe
+	}
+	
+	// Keep the co-purchase ranking order
+	results := []gin.H{}
+	for _, count := range counts {
+		if recipe, ok := byID[count.RecipeID]; ok {
+			results = append(results, gin.H{
+				"recipe":       recipe,
+				"co_purchases": count.CoPurchases,
+			})
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"recipes": results})
+}
+
+// commentSortOrders maps the supported comment sort values to their ORDER BY
+// clause. The ID breaks ties between comments posted at the same instant, so
+// the order is total and cursors can resume from any comment.
+var commentSortOrders = map[string]string{
+	"newest": "comments.created_at DESC, comments.id DESC",
+	"oldest": "comments.created_at ASC, comments.id ASC",
+}
+
+// commentListItem is a comment as listed on a recipe, with its visible replies
+// counted.
+type commentListItem struct {
+	models.Comment
+	ReplyCount int64 `json:"reply_count"`
+}
+
+// commentListResponse is a page of comments. NextCursor is set while there are
+// more comments to fetch with the cursor parameter.
+type commentListResponse struct {
+	PaginatedResponse[commentListItem]
+	Sort       string  `json:"sort"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// GetComments lists a recipe's top-level comments, or the replies to one of
+// them when parent_id is given. Pages are addressed either by page number or,
+// to stay stable while comments are being posted, by the next_cursor returned
+// with the previous page.
+func (h *RecipeHandler) GetComments(c *gin.Context) {
+	recipeID := c.Param("id")
+	userID, _ := c.Get("user_id")
+	
+	if _, err := h.findVisibleRecipe(c, recipeID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	sort := c.DefaultQuery("sort", "newest")
+	order, ok := commentSortOrders[sort]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of: newest, oldest"})
+		return
+	}
+	
+	var thread struct {
+		ParentID string `form:"parent_id" binding:"omitempty,uuid"`
+	}
+	if err := c.ShouldBindQuery(&thread); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id must be a comment ID"})
+		return
+	}
+	
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, limit = normalizePagination(h.Config, page, limit)
+	offset := (page - 1) * limit
+	
+	query := visibleComments(h.db(c).Model(&models.Comment{}).Where("recipe_id = ?", recipeID), userID)
+	if thread.ParentID != "" {
+		query = query.Where("comments.parent_id = ?", thread.ParentID)
+	} else {
+		query = query.Where("comments.parent_id IS NULL")
+	}
+	
+	var total int64
+	query.Count(&total)
+	
+	listQuery := query.Session(&gorm.Session{})
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCommentCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		// Rows strictly past the cursor, so comments posted meanwhile don't shift the page
+		if sort == "newest" {
+			listQuery = listQuery.Where("(comments.created_at, comments.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		} else {
+			listQuery = listQuery.Where("(comments.created_at, comments.id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		offset = 0
+	}
+	
+	comments := []models.Comment{}
+	if err := listQuery.Preload("User").Order(order).
+		Offset(offset).Limit(limit + 1).Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	
+	var nextCursor *string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		encoded := encodeCommentCursor(commentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		nextCursor = &encoded
+	}
+	
+	items, err := h.withReplyCounts(c, comments, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	
+	if c.Query("cursor") != "" {
+		// Cursor pages have no numbers, only a way forward
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+		if nextCursor != nil {
+			setLinkHeader(c, []string{paginationLink(c, "next", "cursor", *nextCursor)})
+		}
+	} else {
+		setPaginationHeaders(c, total, page, limit)
+	}
+	
+	c.JSON(http.StatusOK, commentListResponse{
+		PaginatedResponse: newPaginatedResponse(items, total, page, limit),
+		Sort:              sort,
+		NextCursor:        nextCursor,
+	})
+}
+
+// withReplyCounts counts the replies the viewer can see under each comment.
+func (h *RecipeHandler) withReplyCounts(c *gin.Context, comments []models.Comment, userID interface{}) ([]commentListItem, error) {
+	items := make([]commentListItem, len(comments))
+	ids := make([]string, 0, len(comments))
+	for i, comment := range comments {
+		items[i] = commentListItem{Comment: comment}
+		if comment.ParentID == nil {
+			ids = append(ids, comment.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return items, nil
+	}
+	
+	var counts []struct {
+		ParentID string
+		Count    int64
+	}
+	if err := visibleComments(h.db(c).Model(&models.Comment{}), userID).
+		Select("comments.parent_id, COUNT(*) AS count").
+		Where("comments.parent_id IN ?", ids).
+		Group("comments.parent_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	
+	byParent := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		byParent[count.ParentID] = count.Count
+	}
+	for i := range items {
+		items[i].ReplyCount = byParent[items[i].ID]
+	}
+	return items, nil
+}
+
+// commentCursor is the position of the last comment on a page.
+type commentCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// encodeCommentCursor packs a cursor into an opaque URL-safe token.
+func encodeCommentCursor(cursor commentCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCommentCursor(token string) (commentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return commentCursor{}, err
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return commentCursor{}, errors.New("malformed cursor")
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return commentCursor{}, err
+	}
+	return commentCursor{CreatedAt: parsed, ID: id}, nil
+}
+
+func (h *RecipeHandler) UpdateComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	commentID := c.Param("id")
+	
+	var commentInput struct {
+		Content string `json:"content" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&commentInput); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	commentInput.Content = strings.TrimSpace(commentInput.Content)
+	if err := h.validateCommentLength(commentInput.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Check if comment exists and belongs to user
+	var comment models.Comment
+	if err := h.db(c).First(&comment, "id = ? AND user_id = ?", commentID, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	
+	flagged, err := h.screenComment(commentInput.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	if err := h.db(c).Model(&comment).Updates(map[string]interface{}{
+		"content": commentInput.Content,
+		"flagged": flagged,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	
+	// Load comment with user data
+	h.db(c).Preload("User").First(&comment, "id = ?", comment.ID)
+	
+	c.JSON(http.StatusOK, comment)
+}
+
+// SetCommentVisibility lets the author of a comment hide it from everyone else
+// without deleting it, or show it again.
+func (h *RecipeHandler) SetCommentVisibility(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	var input struct {
+		Hidden *bool `json:"hidden" binding:"required"`
+	}
+	
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	var comment models.Comment
+	if err := h.db(c).First(&comment, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	
+	if err := h.db(c).Model(&comment).Update("hidden", *input.Hidden).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"id": comment.ID, "hidden": *input.Hidden})
+}
+
+// visibleComments limits a comment query to what the viewer may see: comments
+// that are neither flagged nor hidden, plus the viewer's own hidden ones.
+// userID is nil for anonymous requests.
+func visibleComments(db *gorm.DB, userID interface{}) *gorm.DB {
+	db = db.Where("comments.flagged = ?", false)
+	if userID == nil {
+		return db.Where("comments.hidden = ?", false)
+	}
+	return db.Where("(comments.hidden = ? OR comments.user_id = ?)", false, userID)
+}
+
+// validateCommentLength enforces COMMENT_MIN_LENGTH and COMMENT_MAX_LENGTH on
+// trimmed comment text, counted in characters.
+func (h *RecipeHandler) validateCommentLength(content string) error {
+	length := utf8.RuneCountInString(content)
+	if length == 0 || length < h.Config.CommentMinLength {
+		return fmt.Errorf("Comment must be at least %d characters", max(h.Config.CommentMinLength, 1))
+	}
+	if h.Config.CommentMaxLength > 0 && length > h.Config.CommentMaxLength {
+		return fmt.Errorf("Comment must be at most %d characters", h.Config.CommentMaxLength)
+	}
+	return nil
+}
+
+// screenComment runs the configured content filter. Depending on the filter mode
+// offending comments are either rejected with an error or accepted as flagged.
+func (h *RecipeHandler) screenComment(content string) (bool, error) {
+	if err := h.commentFilter.Check(content); err != nil {
+		if h.Config.CommentFilterMode == "flag" {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+func (h *RecipeHandler) GetMyInteractions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	
+	recipeID := c.Param("id")
+	
+	recipe, err := h.findVisibleRecipe(c, recipeID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	var likes, bookmarks int64
+	var rating models.Rating
+	h.db(c).Model(&models.Like{}).Where("user_id = ? AND recipe_id = ?", userID, recipeID).Count(&likes)
+	h.db(c).Model(&models.Bookmark{}).Where("user_id = ? AND recipe_id = ?", userID, recipeID).Count(&bookmarks)
+	h.db(c).Where("user_id = ? AND recipe_id = ?", userID, recipeID).Limit(1).Find(&rating)
+	
+	c.JSON(http.StatusOK, gin.H{
+		"liked":      likes > 0,
+		"bookmarked": bookmarks > 0,
+		"rating":     rating.Rating,
+		"has_access": h.hasAccess(c, recipe, userID),
+	})
+}
+
+// hasAccess reports whether the user may see the full content of a recipe: free
+// recipes are open to everyone, paid ones to their author and buyers.
+func (h *RecipeHandler) hasAccess(c *gin.Context, recipe *models.Recipe, userID interface{}) bool {
+	if recipe.Price <= 0 || recipe.UserID == userID {
+		return true
+	}
+	if userID == nil {
+		return false
+	}
+	
+	var count int64
+	h.db(c).Model(&models.Purchase{}).
+		Where("user_id = ? AND recipe_id = ? AND status = ?", userID, recipe.ID, "completed").
+		Count(&count)
+	return count > 0
+}
+
+// findVisibleRecipe loads a recipe that is published or owned by the given user.
+// Drafts of other authors are reported as not found to avoid revealing them.
+func (h *RecipeHandler) findVisibleRecipe(c *gin.Context, recipeID string, userID interface{}) (*models.Recipe, error) {
+	var recipe models.Recipe
+	if err := h.db(c).Where("(is_published = ? OR user_id = ?)", true, userID).
+		First(&recipe, "id = ?", recipeID).Error; err != nil {
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// loadOwnedRecipe loads a recipe the user is about to modify. It responds with 404 when
+// the recipe doesn't exist or is someone else's draft, and 403 when it is another
+// author's published recipe. The returned bool is false if a response was written.
+func (h *RecipeHandler) loadOwnedRecipe(c *gin.Context, recipeID string, userID interface{}) (*models.Recipe, bool) {
+	var recipe models.Recipe
+	if err := h.db(c).First(&recipe, "id = ?", recipeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return nil, false
+	}
+	
+	if recipe.UserID != userID {
+		if !recipe.IsPublished {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to modify this recipe"})
+		return nil, false
+	}
+	
+	return &recipe, true
+}
+
+// normalizeNestedRecipeIDs forces every nested ingredient, step and image onto the
+// recipe being updated. Entities that reference another recipe, either through
+// their recipe_id or an ID belonging to a different recipe, are rejected.
+func (h *RecipeHandler) normalizeNestedRecipeIDs(c *gin.Context, recipeID string, recipe *models.Recipe) error {
+	var ingredientIDs, stepIDs, imageIDs []string
+	
+	for i := range recipe.Ingredients {
+		if recipe.Ingredients[i].RecipeID != "" && recipe.Ingredients[i].RecipeID != recipeID {
+			return fmt.Errorf("ingredients[%d] belongs to a different recipe", i)
+		}
+		recipe.Ingredients[i].RecipeID = recipeID
+		if recipe.Ingredients[i].ID != "" {
+			ingredientIDs = append(ingredientIDs, recipe.Ingredients[i].ID)
+		}
+	}
+	for i := range recipe.Steps {
+		if recipe.Steps[i].RecipeID != "" && recipe.Steps[i].RecipeID != recipeID {
+			return fmt.Errorf("steps[%d] belongs to a different recipe", i)
+		}
+		recipe.Steps[i].RecipeID = recipeID
+		if recipe.Steps[i].ID != "" {
+			stepIDs = append(stepIDs, recipe.Steps[i].ID)
+		}
+	}
+	for i := range recipe.Images {
+		if recipe.Images[i].RecipeID != "" && recipe.Images[i].RecipeID != recipeID {
+			return fmt.Errorf("images[%d] belongs to a different recipe", i)
+		}
+		recipe.Images[i].RecipeID = recipeID
+		if recipe.Images[i].ID != "" {
+			imageIDs = append(imageIDs, recipe.Images[i].ID)
+		}
+	}
+	
+	// Existing entities must already belong to this recipe
+	checks := []struct {
+		name  string
+		model interface{}
+		ids   []string
+	}{
+		{"ingredients", &models.Ingredient{}, ingredientIDs},
+		{"steps", &models.Step{}, stepIDs},
+		{"images", &models.RecipeImage{}, imageIDs},
+	}
+	for _, check := range checks {
+		if len(check.ids) == 0 {
+			continue
+		}
+		var count int64
+		if err := h.db(c).Model(check.model).Where("id IN ? AND recipe_id = ?", check.ids, recipeID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(check.ids) {
+			return fmt.Errorf("%s contain entries that belong to a different recipe", check.name)
+		}
+	}
+	
+	return nil
+}
+
+// numberUpdatedSteps keeps the position of the existing steps in a partial
+// update and appends new steps after the recipe's last step, in request order.
+// Steps are reordered through ReorderSteps.
+func numberUpdatedSteps(tx *gorm.DB, recipeID string, steps []models.Step) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	
+	var existing []models.Step
+	if err := tx.Select("id", "step_number").Where("recipe_id = ?", recipeID).Find(&existing).Error; err != nil {
+		return err
+	}
+	
+	numbers := make(map[string]int, len(existing))
+	last := 0
+	for _, step := range existing {
+		numbers[step.ID] = step.StepNumber
+		if step.StepNumber > last {
+			last = step.StepNumber
+		}
+	}
+	
+	for i := range steps {
+		if number, ok := numbers[steps[i].ID]; ok {
+			steps[i].StepNumber = number
+			continue
+		}
+		last++
+		steps[i].StepNumber = last
+	}
+	return nil
+}
+
+// keepStepImages copies the stored image onto existing steps sent without an
+// image_url, since the full association save would otherwise clear it. A blank
+// image_url still removes the image.
+func keepStepImages(db *gorm.DB, recipeID string, steps []models.Step) error {
+	var ids []string
+	for _, step := range steps {
+		if step.ID != "" && step.ImageURL == nil {
+			ids = append(ids, step.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	
+	var stored []models.Step
+	if err := db.Select("id", "image_url").Where("id IN ? AND recipe_id = ?", ids, recipeID).Find(&stored).Error; err != nil {
+		return err
+	}
+	images := make(map[string]*string, len(stored))
+	for _, step := range stored {
+		images[step.ID] = step.ImageURL
+	}
+	for i := range steps {
+		if steps[i].ID != "" && steps[i].ImageURL == nil {
+			steps[i].ImageURL = images[steps[i].ID]
+		}
+	}
+	return nil
+}
+
+// validateRecipeImages checks that the featured, gallery and step images all
+// point at uploaded files. Blank step image URLs are cleared.
+func (h *RecipeHandler) validateRecipeImages(featuredImageURL string, images []models.RecipeImage, steps []models.Step) error {
+	if featuredImageURL != "" && !isUploadURL(h.Config, featuredImageURL) {
+		return errors.New("featured_image_url must point to an uploaded image")
+	}
+	for i, image := range images {
+		if !isUploadURL(h.Config, image.ImageURL) {
+			return fmt.Errorf("images[%d].image_url must point to an uploaded image", i)
+		}
+	}
+	for i := range steps {
+		if steps[i].ImageURL == nil {
+			continue
+		}
+		url := strings.TrimSpace(*steps[i].ImageURL)
+		if url == "" {
+			steps[i].ImageURL = nil
+			continue
+		}
+		if !isUploadURL(h.Config, url) {
+			return fmt.Errorf("steps[%d].image_url must point to an uploaded image", i)
+		}
+		steps[i].ImageURL = &url
+	}
+	return nil
+}
+
+// validateRecipePrice rejects negative prices and prices above the configured
+// maximum. A price of 0 makes the recipe free.
+func (h *RecipeHandler) validateRecipePrice(price float64) error {
+	if price < 0 {
+		return errors.New("price must not be negative")
+	}
+	if price > h.Config.MaxRecipePrice {
+		return fmt.Errorf("price must not be more than %.2f", h.Config.MaxRecipePrice)
+	}
+	return nil
+}
+
+// validateRecipeSize enforces the configured caps on ingredients and steps.
+func (h *RecipeHandler) validateRecipeSize(ingredients, steps int) error {
+	if ingredients > h.Config.MaxIngredients {
+		return fmt.Errorf("a recipe can have at most %d ingredients", h.Config.MaxIngredients)
+	}
+	if steps > h.Config.MaxSteps {
+		return fmt.Errorf("a recipe can have at most %d steps", h.Config.MaxSteps)
+	}
+	return nil
+}
+
+// normalizeIngredientAmounts fills in the structured amount of each ingredient
+// from its quantity text, replacing any amount the client sent so the two can't
+// disagree. Quantities that aren't numbers, like "to taste", keep the client's
+// amount, which must not be negative, and a range must not end below where it
+// starts. Nutrition values are optional but must not be negative either.
+func normalizeIngredientAmounts(ingredients []models.Ingredient) error {
+	for i := range ingredients {
+		ingredient := &ingredients[i]
+		
+		for n, value := range ingredientNutrients(ingredient) {
+			if value != nil && *value < 0 {
+				return fmt.Errorf("ingredients[%d].%s must not be negative", i, nutrientNames[n])
+			}
+		}
+		
+		if low, high, _, ok := utils.ParseAmount(ingredient.Quantity); ok {
+			ingredient.Amount = &low
+			ingredient.AmountMax = nil
+			if high != low {
+				ingredient.AmountMax = &high
+			}
+			continue
+		}
+		
+		if ingredient.Amount == nil {
+			ingredient.AmountMax = nil
+			continue
+		}
+		if *ingredient.Amount < 0 {
+			return fmt.Errorf("ingredients[%d].amount must not be negative", i)
+		}
+		if ingredient.AmountMax != nil && *ingredient.AmountMax < *ingredient.Amount {
+			return fmt.Errorf("ingredients[%d].amount_max must not be less than amount", i)
+		}
+	}
+	return nil
+}
+
+// touchRecipe marks a recipe as updated and bumps its version. It is used
+// whenever nested data such as ingredients or steps change, since those edits
+// don't bump the recipe row itself.
+func touchRecipe(tx *gorm.DB, recipe *models.Recipe) error {
+	return tx.Model(recipe).UpdateColumns(map[string]interface{}{
+		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
+	}).Error
+}
+
+// recipeVersionConflict is returned when an edit is based on an outdated
+// version of a recipe.
+type recipeVersionConflict struct {
+	Current int
+}
+
+func (e *recipeVersionConflict) Error() string {
+	return fmt.Sprintf("recipe is at version %d", e.Current)
+}
+
+// checkRecipeVersion locks the recipe row for the rest of the transaction and
+// makes sure it is still at the expected version.
+func checkRecipeVersion(tx *gorm.DB, recipeID string, expected int) error {
+	var current models.Recipe
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("version").
+		First(&current, "id = ?", recipeID).Error; err != nil {
+		return err
+	}
+	if current.Version != expected {
+		return &recipeVersionConflict{Current: current.Version}
+	}
+	return nil
+}
+
+// errRecipeTakenDown is returned when an author tries to publish a recipe a
+// moderator took down.
+var errRecipeTakenDown = errors.New("recipe was taken down by a moderator")
+
+// checkNotTakenDown locks the recipe row for the rest of the transaction and
+// makes sure no moderator takedown is in effect.
+func checkNotTakenDown(tx *gorm.DB, recipeID string) error {
+	var current models.Recipe
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("moderation_hidden").
+		First(&current, "id = ?", recipeID).Error; err != nil {
+		return err
+	}
+	if current.ModerationHidden {
+		return errRecipeTakenDown
+	}
+	return nil
+}
+
+// deleteRecipeCascade soft deletes a recipe together with its comments, likes and
+// ratings so they can be restored as a unit.
+func deleteRecipeCascade(tx *gorm.DB, recipe *models.Recipe) error {
+	if err := tx.Delete(recipe).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&models.Rating{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("recipe_id = ?", recipe.ID).Delete(&models.Made{}).Error
 }
\ No newline at end of file
@@ -0,0 +1,1512 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"time"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestNormalizeIngredientAmountsDerivesAmountFromQuantity(t *testing.T) {
+	// A client that edits the quantity but resends the old amount
+	ingredients := []models.Ingredient{
+		{Name: "flour", Quantity: "3 cups", Amount: float(2)},
+		{Name: "eggs", Quantity: "2-3", Amount: float(1), AmountMax: float(1)},
+	}
+	if err := normalizeIngredientAmounts(ingredients); err != nil {
+		t.Fatal(err)
+	}
+	
+	if *ingredients[0].Amount != 3 || ingredients[0].AmountMax != nil {
+		t.Errorf("flour: expected amount 3, got %v-%v", *ingredients[0].Amount, ingredients[0].AmountMax)
+	}
+	if *ingredients[1].Amount != 2 || ingredients[1].AmountMax == nil || *ingredients[1].AmountMax != 3 {
+		t.Errorf("eggs: expected amount 2-3, got %v-%v", *ingredients[1].Amount, ingredients[1].AmountMax)
+	}
+}
+
+func TestNormalizeIngredientAmountsKeepsAmountOfTextQuantities(t *testing.T) {
+	ingredients := []models.Ingredient{{Name: "salt", Quantity: "a pinch", Amount: float(0.5)}}
+	if err := normalizeIngredientAmounts(ingredients); err != nil {
+		t.Fatal(err)
+	}
+	if ingredients[0].Amount == nil || *ingredients[0].Amount != 0.5 {
+		t.Errorf("expected the client's amount to be kept, got %v", ingredients[0].Amount)
+	}
+}
+
+func TestNormalizeIngredientAmountsRejectsInvalidAmounts(t *testing.T) {
+	tests := map[string]models.Ingredient{
+		"negative amount":   {Name: "salt", Quantity: "to taste", Amount: float(-1)},
+		"reversed range":    {Name: "salt", Quantity: "to taste", Amount: float(2), AmountMax: float(1)},
+		"negative calories": {Name: "salt", Quantity: "1 tsp", Calories: float(-5)},
+	}
+	for name, ingredient := range tests {
+		if err := normalizeIngredientAmounts([]models.Ingredient{ingredient}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNormalizeIngredientAmountsParsesFractionsAndRanges(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{Name: "butter", Quantity: "1/2", Unit: "cup"},
+		{Name: "milk", Quantity: "1-2 cups"},
+	}
+	if err := normalizeIngredientAmounts(ingredients); err != nil {
+		t.Fatal(err)
+	}
+	
+	if ingredients[0].Amount == nil || *ingredients[0].Amount != 0.5 || ingredients[0].AmountMax != nil {
+		t.Errorf("butter: expected amount 0.5, got %v-%v", ingredients[0].Amount, ingredients[0].AmountMax)
+	}
+	if ingredients[1].Amount == nil || *ingredients[1].Amount != 1 || ingredients[1].AmountMax == nil || *ingredients[1].AmountMax != 2 {
+		t.Errorf("milk: expected amount 1-2, got %v-%v", ingredients[1].Amount, ingredients[1].AmountMax)
+	}
+	if ingredients[1].Quantity != "1-2 cups" {
+		t.Errorf("expected the display text to be kept, got %q", ingredients[1].Quantity)
+	}
+}
+
+func TestGetRecipeIDsRejectsMalformedCursor(t *testing.T) {
+	db, log := dryRun(t)
+	h := &RecipeHandler{DB: db, Config: testConfig()}
+	
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/recipes/ids?after=not-a-uuid", nil)
+	h.GetRecipeIDs(c)
+	
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body)
+	}
+	if len(log.statements) != 0 {
+		t.Errorf("expected no query, got %q", log.statements)
+	}
+}
+
+func TestDeletedRecipeHidesCommentsUntilRestored(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	kept := models.Comment{UserID: author.ID, RecipeID: recipe.ID, Content: "Lovely"}
+	removed := models.Comment{UserID: author.ID, RecipeID: recipe.ID, Content: "Deleted before the recipe"}
+	if err := db.Create(&[]*models.Comment{&kept, &removed}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&removed).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	w := serve(h.DeleteRecipe, "DELETE", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	var count int64
+	db.Model(&models.Comment{}).Where("recipe_id = ?", recipe.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the recipe's comments to be hidden, %d remain", count)
+	}
+	w = serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments", "", nil)
+	expectStatus(t, w, http.StatusNotFound)
+	
+	w = serve(h.RestoreRecipe, "POST", "/recipes/:id/restore", "/recipes/"+recipe.ID+"/restore", author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	w = serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var page commentListResponse
+	decode(t, w, &page)
+	if len(page.Data) != 1 || page.Data[0].ID != kept.ID {
+		t.Errorf("expected only the comment removed with the recipe to come back, got %+v", page.Data)
+	}
+}
+
+func TestRestoringARecipeLeavesDeletedAccountsReactionsGone(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	auth := NewAuthHandler(db, testConfig())
+	author := createUser(t, db)
+	fan := createUser(t, db)
+	setPassword(t, db, &fan, "correct horse")
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	w := serve(h.ToggleLike, "POST", "/recipes/:id/like", "/recipes/"+recipe.ID+"/like", fan.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if err := db.Create(&models.Comment{UserID: fan.ID, RecipeID: recipe.ID, Content: "Lovely"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	// The fan leaves while the recipe is in the author's trash
+	w = serve(h.DeleteRecipe, "DELETE", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	w = serve(auth.DeleteAccount, "DELETE", "/auth/account", "/auth/account", fan.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	w = serve(h.RestoreRecipe, "POST", "/recipes/:id/restore", "/recipes/"+recipe.ID+"/restore", author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	var likes, comments int64
+	db.Model(&models.Like{}).Where("recipe_id = ?", recipe.ID).Count(&likes)
+	db.Model(&models.Comment{}).Where("recipe_id = ?", recipe.ID).Count(&comments)
+	if likes != 0 || comments != 0 {
+		t.Errorf("expected the deleted account's like and comment to stay gone, got %d and %d", likes, comments)
+	}
+	var restored models.Recipe
+	db.First(&restored, "id = ?", recipe.ID)
+	if restored.LikeCount != 0 {
+		t.Errorf("expected a like count of 0, got %d", restored.LikeCount)
+	}
+}
+
+func TestOnlyTheAuthorCanLikeOrBookmarkADraft(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	draft := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) { r.IsPublished = false })
+	
+	for _, action := range []struct {
+		path    string
+		handler gin.HandlerFunc
+	}{
+		{"/recipes/:id/like", h.ToggleLike},
+		{"/recipes/:id/bookmark", h.ToggleBookmark},
+	} {
+		target := strings.Replace(action.path, ":id", draft.ID, 1)
+		
+		w := serve(action.handler, "POST", action.path, target, other.ID, nil)
+		expectStatus(t, w, http.StatusNotFound)
+		
+		w = serve(action.handler, "POST", action.path, target, author.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+	}
+	
+	var likes, bookmarks int64
+	db.Model(&models.Like{}).Where("user_id = ?", other.ID).Count(&likes)
+	db.Model(&models.Bookmark{}).Where("user_id = ?", other.ID).Count(&bookmarks)
+	if likes != 0 || bookmarks != 0 {
+		t.Errorf("expected no likes or bookmarks by the non-author, got %d and %d", likes, bookmarks)
+	}
+}
+
+func TestOnlyTheAuthorCanCommentOnADraft(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	draft := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) { r.IsPublished = false })
+	target := "/recipes/" + draft.ID + "/comments"
+	
+	w := serve(h.AddComment, "POST", "/recipes/:id/comments", target, other.ID, gin.H{"content": "Looks great"})
+	expectStatus(t, w, http.StatusNotFound)
+	
+	w = serve(h.AddComment, "POST", "/recipes/:id/comments", target, author.ID, gin.H{"content": "Note to self: more salt"})
+	expectStatus(t, w, http.StatusCreated)
+	
+	var count int64
+	db.Model(&models.Comment{}).Where("user_id = ?", other.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no comment by the non-author, got %d", count)
+	}
+}
+
+func TestAuthorsCanDisableComments(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	reader := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	target := "/recipes/" + recipe.ID
+	
+	setEnabled := func(enabled bool) {
+		t.Helper()
+		w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", target, author.ID, gin.H{"comments_enabled": enabled})
+		expectStatus(t, w, http.StatusOK)
+		
+		w = serve(h.GetRecipe, "GET", "/recipes/:id", target, reader.ID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var detail struct {
+			Recipe models.Recipe `json:"recipe"`
+		}
+		decode(t, w, &detail)
+		if detail.Recipe.CommentsEnabled != enabled {
+			t.Errorf("expected comments_enabled %v in the recipe, got %v", enabled, detail.Recipe.CommentsEnabled)
+		}
+	}
+	comment := func(content string) *httptest.ResponseRecorder {
+		return serve(h.AddComment, "POST", "/recipes/:id/comments", target+"/comments", reader.ID, gin.H{"content": content})
+	}
+	
+	setEnabled(false)
+	expectStatus(t, comment("Can I use red lentils?"), http.StatusForbidden)
+	
+	setEnabled(true)
+	expectStatus(t, comment("Can I use red lentils?"), http.StatusCreated)
+}
+
+func TestHiddenCommentsOnlyShowToTheirAuthor(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	commenter := createUser(t, db)
+	reader := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	comment := models.Comment{UserID: commenter.ID, RecipeID: recipe.ID, Content: "Too salty for me"}
+	if err := db.Create(&comment).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	setHidden := func(userID string, hidden bool) *httptest.ResponseRecorder {
+		target := "/comments/" + comment.ID + "/visibility"
+		return serve(h.SetCommentVisibility, "PUT", "/comments/:id/visibility", target, userID, gin.H{"hidden": hidden})
+	}
+	visible := func(userID string) []models.Comment {
+		t.Helper()
+		w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments", userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var page commentListResponse
+		decode(t, w, &page)
+		
+		w = serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var detail struct {
+			Recipe models.Recipe `json:"recipe"`
+		}
+		decode(t, w, &detail)
+		if len(detail.Recipe.Comments) != len(page.Data) {
+			t.Errorf("user %q: the recipe shows %d comments but the listing %d", userID, len(detail.Recipe.Comments), len(page.Data))
+		}
+		return detail.Recipe.Comments
+	}
+	
+	expectStatus(t, setHidden(reader.ID, true), http.StatusNotFound)
+	expectStatus(t, setHidden(commenter.ID, true), http.StatusOK)
+	
+	for _, userID := range []string{reader.ID, ""} {
+		if comments := visible(userID); len(comments) != 0 {
+			t.Errorf("user %q: expected the hidden comment to disappear, got %+v", userID, comments)
+		}
+	}
+	if comments := visible(commenter.ID); len(comments) != 1 || !comments[0].Hidden {
+		t.Errorf("expected the author to see their comment marked hidden, got %+v", comments)
+	}
+	
+	expectStatus(t, setHidden(commenter.ID, false), http.StatusOK)
+	if comments := visible(reader.ID); len(comments) != 1 || comments[0].Hidden {
+		t.Errorf("expected the comment to be shown again, got %+v", comments)
+	}
+}
+
+func TestValidateCommentLength(t *testing.T) {
+	cfg := testConfig()
+	cfg.CommentMinLength = 3
+	cfg.CommentMaxLength = 10
+	h := NewRecipeHandler(nil, cfg)
+	
+	tests := map[string]bool{
+		"":            false,
+		"ok":          false,
+		"yum":         true,
+		"ጣፋጭ":         true,
+		"ten chars!":  true,
+		"eleven char": false,
+		"ጣፋጭ ምግብ ነው!": false,
+	}
+	for content, valid := range tests {
+		if err := h.validateCommentLength(content); (err == nil) != valid {
+			t.Errorf("validateCommentLength(%q) = %v, want valid %v", content, err, valid)
+		}
+	}
+}
+
+func TestCommentsOfInvalidLengthAreRejected(t *testing.T) {
+	// Invalid comments are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	
+	for _, content := range []string{"   ", "\n\t", strings.Repeat("a", 2001)} {
+		body := gin.H{"content": content}
+		w := serve(h.AddComment, "POST", "/recipes/:id/comments", "/recipes/r1/comments", "u1", body)
+		expectStatus(t, w, http.StatusBadRequest)
+		w = serve(h.UpdateComment, "PUT", "/comments/:id", "/comments/c1", "u1", body)
+		expectStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestCommentsAtTheMaximumLengthAreAccepted(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	commenter := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	
+	content := strings.Repeat("é", 2000)
+	w := serve(h.AddComment, "POST", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments", commenter.ID,
+		gin.H{"content": "  " + content + "  "})
+	expectStatus(t, w, http.StatusCreated)
+	
+	var stored models.Comment
+	if err := db.First(&stored, "recipe_id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != content {
+		t.Errorf("expected the trimmed comment to be stored, got %d characters", utf8.RuneCountInString(stored.Content))
+	}
+}
+
+func TestModifyingAnotherAuthorsRecipe(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	published := createRecipe(t, db, author, category, nil)
+	draft := createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"published recipe of another author", published.ID, http.StatusForbidden},
+		{"draft of another author", draft.ID, http.StatusNotFound},
+		{"missing recipe", "00000000-0000-0000-0000-000000000000", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := serve(h.DeleteRecipe, "DELETE", "/recipes/:id", "/recipes/"+tt.id, other.ID, nil)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body)
+		}
+	}
+	
+	w := serve(h.DeleteRecipe, "DELETE", "/recipes/:id", "/recipes/"+draft.ID, author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestUpdateRecipeRejectsStepsOfAnotherRecipe(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	recipe := createRecipe(t, db, author, category, nil)
+	foreign := createRecipe(t, db, createUser(t, db), category, nil)
+	
+	foreignStep := models.Step{RecipeID: foreign.ID, StepNumber: 1, Instruction: "Not yours"}
+	if err := db.Create(&foreignStep).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := map[string]gin.H{
+		"foreign recipe_id":            {"steps": []gin.H{{"recipe_id": foreign.ID, "instruction": "Stir"}}},
+		"foreign step ID":              {"steps": []gin.H{{"id": foreignStep.ID, "instruction": "Hijacked"}}},
+		"foreign ingredient recipe_id": {"ingredients": []gin.H{{"recipe_id": foreign.ID, "name": "salt"}}},
+	}
+	for name, body := range tests {
+		w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body)
+		}
+	}
+	
+	var step models.Step
+	if err := db.First(&step, "id = ?", foreignStep.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if step.RecipeID != foreign.ID || step.Instruction != "Not yours" {
+		t.Errorf("expected the other recipe's step to be untouched, got %+v", step)
+	}
+	
+	// Entries without a recipe_id are attached to the recipe being updated
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID,
+		gin.H{"steps": []gin.H{{"instruction": "Stir"}}})
+	expectStatus(t, w, http.StatusOK)
+	var count int64
+	db.Model(&models.Step{}).Where("recipe_id = ?", recipe.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("expected the new step on the updated recipe, found %d", count)
+	}
+}
+
+func TestTotalTimeIsReportedInListAndDetail(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), func(r *models.Recipe) {
+		r.PreparationTime = 15
+		r.CookingTime = 40
+	})
+	
+	w := serve(h.GetRecipes, "GET", "/recipes", "/recipes", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var list PaginatedResponse[recipeListItem]
+	decode(t, w, &list)
+	if len(list.Data) != 1 || list.Data[0].TotalTime != 55 {
+		t.Errorf("expected a listed total_time of 55, got %+v", list.Data)
+	}
+	
+	w = serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var detail struct {
+		Recipe models.Recipe `json:"recipe"`
+	}
+	decode(t, w, &detail)
+	if detail.Recipe.TotalTime != 55 {
+		t.Errorf("expected a total_time of 55 in the detail, got %d", detail.Recipe.TotalTime)
+	}
+}
+
+func TestEditingStepsBumpsUpdatedAt(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	edited := createRecipe(t, db, author, category, nil)
+	untouched := createRecipe(t, db, author, category, nil)
+	
+	// Both were last updated a while ago, the edited recipe first
+	past := time.Now().Add(-time.Hour)
+	db.Model(&edited).UpdateColumn("updated_at", past)
+	db.Model(&untouched).UpdateColumn("updated_at", past.Add(time.Minute))
+	
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+edited.ID, author.ID,
+		gin.H{"steps": []gin.H{{"instruction": "Rest the dough"}}})
+	expectStatus(t, w, http.StatusOK)
+	
+	var reloaded models.Recipe
+	if err := db.First(&reloaded, "id = ?", edited.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.UpdatedAt.After(past.Add(time.Minute)) {
+		t.Errorf("expected updated_at to move past %s, got %s", past, reloaded.UpdatedAt)
+	}
+	
+	w = serve(h.GetRecipes, "GET", "/recipes", "/recipes?sort=recently_updated", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var page PaginatedResponse[recipeListItem]
+	decode(t, w, &page)
+	if len(page.Data) != 2 || page.Data[0].ID != edited.ID || page.Data[1].ID != untouched.ID {
+		t.Errorf("expected the edited recipe first, got %+v", page.Data)
+	}
+}
+
+func TestRecipeLikesArePagedAndPublic(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	for i := 0; i < 3; i++ {
+		if err := db.Create(&models.Like{UserID: createUser(t, db).ID, RecipeID: recipe.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	seen := make(map[string]bool)
+	for page, want := range []int{2, 1} {
+		target := fmt.Sprintf("/recipes/%s/likes?limit=2&page=%d", recipe.ID, page+1)
+		w := serve(h.GetRecipeLikes, "GET", "/recipes/:id/likes", target, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		
+		var likes PaginatedResponse[map[string]interface{}]
+		decode(t, w, &likes)
+		if likes.Total != 3 || likes.Pages != 2 || len(likes.Data) != want {
+			t.Fatalf("page %d: expected %d of 3 likes on 2 pages, got %d of %d on %d", page+1, want, len(likes.Data), likes.Total, likes.Pages)
+		}
+		for _, liker := range likes.Data {
+			for _, private := range []string{"email", "password_hash", "is_admin", "bio"} {
+				if _, ok := liker[private]; ok {
+					t.Errorf("liker exposes %s: %v", private, liker)
+				}
+			}
+			seen[liker["id"].(string)] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected the pages to cover 3 distinct likers, got %d", len(seen))
+	}
+}
+
+func TestAlsoBoughtRanksByCoPurchases(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	paid := func(r *models.Recipe) { r.Price = 9.99 }
+	bought := createRecipe(t, db, author, category, paid)
+	often := createRecipe(t, db, author, category, paid)
+	sometimes := createRecipe(t, db, author, category, paid)
+	pending := createRecipe(t, db, author, category, paid)
+	
+	buyers := []models.User{createUser(t, db), createUser(t, db), createUser(t, db)}
+	for i, buyer := range buyers {
+		createPurchase(t, db, buyer, bought, "completed")
+		createPurchase(t, db, buyer, often, "completed")
+		if i < 2 {
+			createPurchase(t, db, buyer, sometimes, "completed")
+		} else {
+			createPurchase(t, db, buyer, pending, "pending")
+		}
+	}
+	
+	type recommendations struct {
+		Recipes []struct {
+			Recipe      models.Recipe `json:"recipe"`
+			CoPurchases int           `json:"co_purchases"`
+		} `json:"recipes"`
+	}
+	target := "/recipes/" + bought.ID + "/also-bought"
+	
+	w := serve(h.GetAlsoBought, "GET", "/recipes/:id/also-bought", target, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var anonymous recommendations
+	decode(t, w, &anonymous)
+	if len(anonymous.Recipes) != 2 ||
+		anonymous.Recipes[0].Recipe.ID != often.ID || anonymous.Recipes[0].CoPurchases != 3 ||
+		anonymous.Recipes[1].Recipe.ID != sometimes.ID || anonymous.Recipes[1].CoPurchases != 2 {
+		t.Errorf("expected %q (3) then %q (2), got %+v", often.Title, sometimes.Title, anonymous.Recipes)
+	}
+	
+	// Recipes the viewer already bought aren't recommended to them
+	w = serve(h.GetAlsoBought, "GET", "/recipes/:id/also-bought", target, buyers[2].ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	var buyer recommendations
+	decode(t, w, &buyer)
+	if len(buyer.Recipes) != 1 || buyer.Recipes[0].Recipe.ID != sometimes.ID {
+		t.Errorf("expected only %q for a buyer of the rest, got %+v", sometimes.Title, buyer.Recipes)
+	}
+}
+
+func TestRapidCommentsAreThrottled(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.CommentsPerMinute = 3
+	h := NewRecipeHandler(db, cfg)
+	user := createUser(t, db)
+	category := createCategory(t, db)
+	
+	comment := func(recipe models.Recipe) *httptest.ResponseRecorder {
+		return serve(h.AddComment, "POST", "/recipes/:id/comment", "/recipes/"+recipe.ID+"/comment", user.ID,
+			gin.H{"content": "Looks great"})
+	}
+	
+	// A second comment on the same recipe comes too soon after the first
+	first := createRecipe(t, db, user, category, nil)
+	expectStatus(t, comment(first), http.StatusCreated)
+	expectStatus(t, comment(first), http.StatusTooManyRequests)
+	
+	// Spread over recipes, the per-minute cap still applies
+	expectStatus(t, comment(createRecipe(t, db, user, category, nil)), http.StatusCreated)
+	expectStatus(t, comment(createRecipe(t, db, user, category, nil)), http.StatusTooManyRequests)
+	
+	var count int64
+	db.Model(&models.Comment{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 comments to be stored, got %d", count)
+	}
+}
+
+func TestScreenCommentRejectsOrFlags(t *testing.T) {
+	cfg := testConfig()
+	cfg.CommentBannedWords = []string{"spam"}
+	
+	h := NewRecipeHandler(nil, cfg)
+	if flagged, err := h.screenComment("Tasty!"); err != nil || flagged {
+		t.Errorf("clean comment: got flagged=%v, err=%v", flagged, err)
+	}
+	if _, err := h.screenComment("spam spam spam"); err == nil {
+		t.Error("banned word: expected the comment to be rejected")
+	}
+	if _, err := h.screenComment("Try http://a.example http://b.example http://c.example"); err == nil {
+		t.Error("links: expected the comment to be rejected")
+	}
+	
+	cfg.CommentFilterMode = "flag"
+	h = NewRecipeHandler(nil, cfg)
+	if flagged, err := h.screenComment("spam spam spam"); err != nil || !flagged {
+		t.Errorf("flag mode: got flagged=%v, err=%v", flagged, err)
+	}
+}
+
+func TestCancelledRequestAbortsQueries(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/recipes", nil).WithContext(ctx)
+	
+	cancel()
+	start := time.Now()
+	err := h.db(c).Exec("SELECT pg_sleep(5)").Error
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the query to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the query to stop right away, it took %s", elapsed)
+	}
+	
+	h.GetRecipes(c)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected the listing to fail with a cancelled context, got %d", w.Code)
+	}
+}
+
+func TestMyInteractionsReportEachState(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	user := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) { r.Price = 4.5 })
+	
+	type interactions struct {
+		Liked      bool `json:"liked"`
+		Bookmarked bool `json:"bookmarked"`
+		Rating     int  `json:"rating"`
+		HasAccess  bool `json:"has_access"`
+	}
+	fetch := func(userID string) interactions {
+		t.Helper()
+		w := serve(h.GetMyInteractions, "GET", "/recipes/:id/me", "/recipes/"+recipe.ID+"/me", userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var got interactions
+		decode(t, w, &got)
+		return got
+	}
+	
+	if got := fetch(user.ID); got != (interactions{}) {
+		t.Errorf("before interacting: got %+v", got)
+	}
+	if got := fetch(author.ID); got != (interactions{HasAccess: true}) {
+		t.Errorf("author: got %+v", got)
+	}
+	
+	for _, row := range []interface{}{
+		&models.Like{UserID: user.ID, RecipeID: recipe.ID},
+		&models.Bookmark{UserID: user.ID, RecipeID: recipe.ID},
+		&models.Rating{UserID: user.ID, RecipeID: recipe.ID, Rating: 4},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fetch(user.ID); got != (interactions{Liked: true, Bookmarked: true, Rating: 4}) {
+		t.Errorf("after interacting: got %+v", got)
+	}
+	
+	createPurchase(t, db, user, recipe, "pending")
+	if got := fetch(user.ID); got.HasAccess {
+		t.Error("expected a pending purchase not to grant access")
+	}
+	createPurchase(t, db, user, recipe, "completed")
+	if got := fetch(user.ID); !got.HasAccess {
+		t.Error("expected a completed purchase to grant access")
+	}
+}
+
+func TestDeletingARatingRecomputesTheAverage(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	fan := createUser(t, db)
+	critic := createUser(t, db)
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	target := "/recipes/" + recipe.ID + "/rating"
+	
+	expectStatus(t, serve(h.AddRating, "POST", "/recipes/:id/rating", target, fan.ID, gin.H{"rating": 5}), http.StatusOK)
+	expectStatus(t, serve(h.AddRating, "POST", "/recipes/:id/rating", target, critic.ID, gin.H{"rating": 2}), http.StatusOK)
+	expectRatingStats(t, db, recipe.ID, 3.5, 2)
+	
+	expectStatus(t, serve(h.DeleteRating, "DELETE", "/recipes/:id/rating", target, fan.ID, nil), http.StatusOK)
+	expectRatingStats(t, db, recipe.ID, 2, 1)
+	expectStatus(t, serve(h.DeleteRating, "DELETE", "/recipes/:id/rating", target, fan.ID, nil), http.StatusNotFound)
+	
+	expectStatus(t, serve(h.DeleteRating, "DELETE", "/recipes/:id/rating", target, critic.ID, nil), http.StatusOK)
+	expectRatingStats(t, db, recipe.ID, 0, 0)
+}
+
+// expectRatingStats checks the rating aggregates stored on a recipe.
+func expectRatingStats(t *testing.T, db *gorm.DB, recipeID string, average float64, total int) {
+	t.Helper()
+	
+	var recipe models.Recipe
+	if err := db.First(&recipe, "id = ?", recipeID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if recipe.AverageRating != average || recipe.TotalRatings != total {
+		t.Errorf("expected an average of %v over %d ratings, got %v over %d", average, total, recipe.AverageRating, recipe.TotalRatings)
+	}
+}
+
+// createComments stores top-level comments on recipe, a minute apart in the
+// given order.
+func createComments(t *testing.T, db *gorm.DB, user models.User, recipe models.Recipe, count int) []models.Comment {
+	t.Helper()
+	
+	start := time.Now().Add(-time.Hour)
+	comments := make([]models.Comment, count)
+	for i := range comments {
+		comments[i] = models.Comment{
+			UserID:    user.ID,
+			RecipeID:  recipe.ID,
+			Content:   fmt.Sprintf("Comment %d", i+1),
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}
+		if err := db.Create(&comments[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return comments
+}
+
+// commentIDs lists the IDs of a page of comments in order.
+func commentIDs(items []commentListItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestCommentsSortNewestOrOldestFirst(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	comments := createComments(t, db, user, recipe, 3)
+	oldest := []string{comments[0].ID, comments[1].ID, comments[2].ID}
+	newest := []string{comments[2].ID, comments[1].ID, comments[0].ID}
+	
+	for query, want := range map[string][]string{"": newest, "?sort=newest": newest, "?sort=oldest": oldest} {
+		w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments"+query, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var page commentListResponse
+		decode(t, w, &page)
+		if got := commentIDs(page.Data); !slices.Equal(got, want) {
+			t.Errorf("%q: expected %v, got %v", query, want, got)
+		}
+	}
+	
+	w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments?sort=best", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestCommentCursorsRoundTrip(t *testing.T) {
+	cursor := commentCursor{CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC), ID: "c1"}
+	decoded, err := decodeCommentCursor(encodeCommentCursor(cursor))
+	if err != nil || !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("expected %+v back, got %+v: %v", cursor, decoded, err)
+	}
+	
+	for _, token := range []string{"%%%", "bm8tc2VwYXJhdG9y", "bm90LWEtdGltZXxjMQ", "MjAyNC0wMy0wMVQxMjozMDowMFp8"} {
+		if _, err := decodeCommentCursor(token); err == nil {
+			t.Errorf("expected %q to be rejected", token)
+		}
+	}
+}
+
+func TestCommentReplyCounts(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	replier := createUser(t, db)
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	comments := createComments(t, db, user, recipe, 2)
+	
+	reply := func(hidden, flagged bool) {
+		t.Helper()
+		comment := models.Comment{UserID: replier.ID, RecipeID: recipe.ID, ParentID: &comments[0].ID,
+			Content: "Agreed", Hidden: hidden, Flagged: flagged}
+		if err := db.Create(&comment).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	reply(false, false)
+	reply(false, false)
+	reply(true, false)
+	reply(false, true)
+	
+	counts := func(userID string) map[string]int64 {
+		t.Helper()
+		w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments", userID, nil)
+		expectStatus(t, w, http.StatusOK)
+		var page commentListResponse
+		decode(t, w, &page)
+		counts := make(map[string]int64, len(page.Data))
+		for _, item := range page.Data {
+			counts[item.ID] = item.ReplyCount
+		}
+		return counts
+	}
+	
+	tests := []struct {
+		userID        string
+		first, second int64
+	}{
+		{"", 2, 0},
+		{user.ID, 2, 0},
+		{replier.ID, 3, 0},
+	}
+	for _, tt := range tests {
+		got := counts(tt.userID)
+		if len(got) != 2 || got[comments[0].ID] != tt.first || got[comments[1].ID] != tt.second {
+			t.Errorf("user %q: expected reply counts %d and %d, got %v", tt.userID, tt.first, tt.second, got)
+		}
+	}
+	
+	target := "/recipes/" + recipe.ID + "/comments?parent_id=" + comments[0].ID
+	w := serve(h.GetComments, "GET", "/recipes/:id/comments", target, "", nil)
+	expectStatus(t, w, http.StatusOK)
+	var replies commentListResponse
+	decode(t, w, &replies)
+	if len(replies.Data) != 2 || replies.Total != 2 {
+		t.Errorf("expected the 2 visible replies, got %d of %d", len(replies.Data), replies.Total)
+	}
+}
+
+func TestCommentCursorsAreStableAcrossInserts(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	user := createUser(t, db)
+	recipe := createRecipe(t, db, user, createCategory(t, db), nil)
+	comments := createComments(t, db, user, recipe, 5)
+	
+	page := func(query string) commentListResponse {
+		t.Helper()
+		w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments?limit=2"+query, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var page commentListResponse
+		decode(t, w, &page)
+		return page
+	}
+	
+	first := page("")
+	if got := commentIDs(first.Data); !slices.Equal(got, []string{comments[4].ID, comments[3].ID}) {
+		t.Fatalf("unexpected first page %v", got)
+	}
+	if first.NextCursor == nil {
+		t.Fatal("expected a next cursor")
+	}
+	
+	// A comment posted meanwhile would push the rest of the pages back by one
+	posted := models.Comment{UserID: user.ID, RecipeID: recipe.ID, Content: "Just tried it"}
+	if err := db.Create(&posted).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	second := page("&cursor=" + *first.NextCursor)
+	if got := commentIDs(second.Data); !slices.Equal(got, []string{comments[2].ID, comments[1].ID}) {
+		t.Errorf("expected the second page to continue after the first, got %v", got)
+	}
+	if second.NextCursor == nil {
+		t.Fatal("expected a next cursor")
+	}
+	
+	last := page("&cursor=" + *second.NextCursor)
+	if got := commentIDs(last.Data); !slices.Equal(got, []string{comments[0].ID}) || last.NextCursor != nil {
+		t.Errorf("expected only the oldest comment and no further cursor, got %v and %v", got, last.NextCursor)
+	}
+	
+	w := serve(h.GetComments, "GET", "/recipes/:id/comments", "/recipes/"+recipe.ID+"/comments?cursor=bogus", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestRecipeIDsPageThroughEveryPublishedRecipe(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	
+	published := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		published[createRecipe(t, db, author, category, nil).ID] = true
+	}
+	createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	deleted := createRecipe(t, db, author, category, nil)
+	db.Delete(&deleted)
+	
+	seen := make(map[string]bool)
+	target := "/recipes/ids?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("expected the cursor to run out")
+		}
+		w := serve(h.GetRecipeIDs, "GET", "/recipes/ids", target, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var page struct {
+			Recipes []struct {
+				ID string `json:"id"`
+			} `json:"recipes"`
+			NextCursor *string `json:"next_cursor"`
+		}
+		decode(t, w, &page)
+		
+		for _, recipe := range page.Recipes {
+			if !published[recipe.ID] || seen[recipe.ID] {
+				t.Errorf("unexpected or repeated recipe %s", recipe.ID)
+			}
+			seen[recipe.ID] = true
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		target = "/recipes/ids?limit=2&after=" + *page.NextCursor
+	}
+	if len(seen) != len(published) {
+		t.Errorf("expected all %d published recipes, got %d", len(published), len(seen))
+	}
+}
+
+func TestValidateRecipeImagesChecksStepImages(t *testing.T) {
+	h := NewRecipeHandler(nil, testConfig())
+	text := func(value string) *string { return &value }
+	
+	steps := []models.Step{
+		{Instruction: "Chop", ImageURL: text(" /uploads/chop.jpg ")},
+		{Instruction: "Stir", ImageURL: text("  ")},
+		{Instruction: "Serve"},
+	}
+	if err := h.validateRecipeImages("", nil, steps); err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].ImageURL == nil || *steps[0].ImageURL != "/uploads/chop.jpg" {
+		t.Errorf("expected the upload URL to be trimmed, got %v", steps[0].ImageURL)
+	}
+	if steps[1].ImageURL != nil {
+		t.Errorf("expected a blank image URL to be dropped, got %q", *steps[1].ImageURL)
+	}
+	
+	for _, url := range []string{"https://example.com/chop.jpg", "/uploads/../secrets", "/uploads/a/b.jpg"} {
+		err := h.validateRecipeImages("", nil, []models.Step{{Instruction: "Chop", ImageURL: text(url)}})
+		if err == nil {
+			t.Errorf("%q: expected the step image to be rejected", url)
+		}
+	}
+}
+
+func TestStepImagesFollowTheirStepsWhenReordered(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	
+	body := recipeRequest(createCategory(t, db).ID)
+	body["steps"] = []gin.H{
+		{"instruction": "Chop", "image_url": "/uploads/chop.jpg"},
+		{"instruction": "Fry"},
+		{"instruction": "Plate", "image_url": "/uploads/plate.jpg"},
+	}
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", author.ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	var created models.Recipe
+	decode(t, w, &created)
+	
+	byInstruction := make(map[string]models.Step)
+	for _, step := range created.Steps {
+		byInstruction[step.Instruction] = step
+	}
+	if len(byInstruction) != 3 || byInstruction["Chop"].ImageURL == nil || byInstruction["Fry"].ImageURL != nil {
+		t.Fatalf("expected the step images to be stored, got %+v", created.Steps)
+	}
+	
+	order := []string{byInstruction["Plate"].ID, byInstruction["Chop"].ID, byInstruction["Fry"].ID}
+	w = serve(h.ReorderSteps, "PATCH", "/recipes/:id/steps/reorder", "/recipes/"+created.ID+"/steps/reorder", author.ID,
+		gin.H{"step_ids": order})
+	expectStatus(t, w, http.StatusOK)
+	var reordered []models.Step
+	decode(t, w, &reordered)
+	if len(reordered) != 3 {
+		t.Fatalf("expected 3 steps, got %+v", reordered)
+	}
+	
+	wantImages := []string{"/uploads/plate.jpg", "/uploads/chop.jpg", ""}
+	for i, step := range reordered {
+		image := ""
+		if step.ImageURL != nil {
+			image = *step.ImageURL
+		}
+		if step.ID != order[i] || step.StepNumber != i+1 || image != wantImages[i] {
+			t.Errorf("position %d: got step %s numbered %d with image %q", i+1, step.ID, step.StepNumber, image)
+		}
+	}
+}
+
+func TestEditingAStepKeepsItsImage(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	
+	body := recipeRequest(createCategory(t, db).ID)
+	body["steps"] = []gin.H{
+		{"instruction": "Chop", "image_url": "/uploads/chop.jpg"},
+		{"instruction": "Plate", "image_url": "/uploads/plate.jpg"},
+	}
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", author.ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	var created models.Recipe
+	decode(t, w, &created)
+	byInstruction := make(map[string]models.Step)
+	for _, step := range created.Steps {
+		byInstruction[step.Instruction] = step
+	}
+	chop, plate := byInstruction["Chop"], byInstruction["Plate"]
+	if chop.ImageURL == nil || plate.ImageURL == nil {
+		t.Fatalf("expected the step images to be stored, got %+v", created.Steps)
+	}
+	
+	// Only the text of the first step is sent, and the second step's image is removed
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+created.ID, author.ID, gin.H{"steps": []gin.H{
+		{"id": chop.ID, "instruction": "Chop finely"},
+		{"id": plate.ID, "instruction": "Plate", "image_url": ""},
+	}})
+	expectStatus(t, w, http.StatusOK)
+	
+	var stored models.Step
+	db.First(&stored, "id = ?", chop.ID)
+	if stored.Instruction != "Chop finely" || stored.ImageURL == nil || *stored.ImageURL != "/uploads/chop.jpg" {
+		t.Errorf("expected the new text with the old image, got %q and %v", stored.Instruction, stored.ImageURL)
+	}
+	db.First(&stored, "id = ?", plate.ID)
+	if stored.ImageURL != nil {
+		t.Errorf("expected a blank image_url to remove the image, got %q", *stored.ImageURL)
+	}
+}
+
+func TestReorderStepsRequiresEveryStepOfTheRecipe(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	recipe := createRecipe(t, db, author, category, nil)
+	other := createRecipe(t, db, author, category, nil)
+	
+	steps := []models.Step{
+		{RecipeID: recipe.ID, StepNumber: 1, Instruction: "First"},
+		{RecipeID: recipe.ID, StepNumber: 2, Instruction: "Second"},
+		{RecipeID: recipe.ID, StepNumber: 3, Instruction: "Third"},
+		{RecipeID: other.ID, StepNumber: 1, Instruction: "Elsewhere"},
+	}
+	if err := db.Create(&steps).Error; err != nil {
+		t.Fatal(err)
+	}
+	reorder := func(ids ...string) *httptest.ResponseRecorder {
+		return serve(h.ReorderSteps, "PATCH", "/recipes/:id/steps/reorder", "/recipes/"+recipe.ID+"/steps/reorder",
+			author.ID, gin.H{"step_ids": ids})
+	}
+	
+	invalid := map[string][]string{
+		"partial":   {steps[2].ID, steps[0].ID},
+		"duplicate": {steps[0].ID, steps[0].ID, steps[1].ID, steps[2].ID},
+		"foreign":   {steps[2].ID, steps[1].ID, steps[0].ID, steps[3].ID},
+		"empty":     {},
+	}
+	for name, ids := range invalid {
+		if w := reorder(ids...); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body)
+		}
+	}
+	
+	expectStatus(t, reorder(steps[2].ID, steps[0].ID, steps[1].ID), http.StatusOK)
+	var reordered []models.Step
+	db.Where("recipe_id = ?", recipe.ID).Order("step_number").Find(&reordered)
+	var got []string
+	for _, step := range reordered {
+		got = append(got, step.Instruction)
+	}
+	if want := []string{"Third", "First", "Second"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	
+	var untouched models.Step
+	db.First(&untouched, "id = ?", steps[3].ID)
+	if untouched.StepNumber != 1 {
+		t.Errorf("expected the other recipe's step to keep its number, got %d", untouched.StepNumber)
+	}
+}
+
+func TestDuplicateTitlesNeedForce(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	existing := createRecipe(t, db, author, category, func(r *models.Recipe) { r.Title = "Banana Bread" })
+	
+	body := recipeRequest(category.ID)
+	body["title"] = " banana bread "
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", author.ID, body)
+	expectStatus(t, w, http.StatusConflict)
+	var conflict struct {
+		ExistingRecipeID string `json:"existing_recipe_id"`
+	}
+	decode(t, w, &conflict)
+	if conflict.ExistingRecipeID != existing.ID {
+		t.Errorf("expected the conflict to point at %s, got %s", existing.ID, conflict.ExistingRecipeID)
+	}
+	
+	// Other authors may use the same title
+	w = serve(h.CreateRecipe, "POST", "/recipes", "/recipes", createUser(t, db).ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	
+	w = serve(h.CreateRecipe, "POST", "/recipes", "/recipes?force=true", author.ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	var count int64
+	db.Model(&models.Recipe{}).Where("user_id = ?", author.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("expected the forced duplicate to be created, author has %d recipes", count)
+	}
+}
+
+func TestUpdateRecipeRejectsUnknownDifficulty(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, gin.H{"difficulty_level": "extreme"})
+	expectStatus(t, w, http.StatusBadRequest)
+	
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.DifficultyLevel != "easy" {
+		t.Errorf("expected the difficulty to stay easy, got %q", stored.DifficultyLevel)
+	}
+	
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, gin.H{"difficulty_level": "hard"})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestRecipeSizeLimits(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.MaxIngredients = 3
+	cfg.MaxSteps = 2
+	h := NewRecipeHandler(db, cfg)
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	
+	ingredients := func(n int) []gin.H {
+		list := make([]gin.H, n)
+		for i := range list {
+			list[i] = gin.H{"name": fmt.Sprintf("ingredient %d", i)}
+		}
+		return list
+	}
+	steps := func(n int) []gin.H {
+		list := make([]gin.H, n)
+		for i := range list {
+			list[i] = gin.H{"instruction": fmt.Sprintf("step %d", i)}
+		}
+		return list
+	}
+	
+	tests := []struct {
+		name                       string
+		ingredientCount, stepCount int
+		status                     int
+	}{
+		{"at both limits", 3, 2, http.StatusCreated},
+		{"one ingredient too many", 4, 2, http.StatusBadRequest},
+		{"one step too many", 3, 3, http.StatusBadRequest},
+	}
+	var created models.Recipe
+	for _, tt := range tests {
+		body := recipeRequest(category.ID)
+		body["ingredients"] = ingredients(tt.ingredientCount)
+		body["steps"] = steps(tt.stepCount)
+		
+		w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", author.ID, body)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body)
+		}
+		if w.Code == http.StatusCreated {
+			decode(t, w, &created)
+		}
+	}
+	if created.ID == "" {
+		t.Fatal("expected the recipe at the limits to be created")
+	}
+	
+	// New entries in an update count on top of the stored ones
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+created.ID, author.ID, gin.H{"ingredients": ingredients(1)})
+	expectStatus(t, w, http.StatusBadRequest)
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+created.ID, author.ID, gin.H{"steps": steps(1)})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestUpdateRecipeChangesOnlySentFields(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) {
+		r.Description = "Crispy and golden"
+		r.Price = 50
+		r.Ingredients = []models.Ingredient{{Name: "potatoes", Quantity: "1 kg"}}
+		r.Steps = []models.Step{{StepNumber: 1, Instruction: "Fry"}}
+	})
+	
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, gin.H{"price": 75})
+	expectStatus(t, w, http.StatusOK)
+	
+	var stored models.Recipe
+	if err := db.Preload("Ingredients").Preload("Steps").First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Price != 75 {
+		t.Errorf("expected the price to be 75, got %v", stored.Price)
+	}
+	if stored.Title != recipe.Title || stored.Description != recipe.Description || stored.Servings != recipe.Servings ||
+		stored.DifficultyLevel != recipe.DifficultyLevel || !stored.IsPublished {
+		t.Errorf("expected the other fields to be unchanged, got %+v", stored)
+	}
+	if len(stored.Ingredients) != 1 || len(stored.Steps) != 1 {
+		t.Errorf("expected the nested collections to be untouched, got %d ingredients and %d steps", len(stored.Ingredients), len(stored.Steps))
+	}
+	
+	// A zero price is a change, not a missing field
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID, gin.H{"price": 0})
+	expectStatus(t, w, http.StatusOK)
+	if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Price != 0 {
+		t.Errorf("expected the price to be cleared, got %v", stored.Price)
+	}
+}
+
+func TestRecipePricesOutsideTheLimitAreRejected(t *testing.T) {
+	// Invalid prices are answered before the database is used
+	cfg := testConfig()
+	cfg.MaxRecipePrice = 100
+	h := NewRecipeHandler(nil, cfg)
+	
+	for _, price := range []float64{-1, 100.01, 5000} {
+		body := recipeRequest("category-1")
+		body["price"] = price
+		w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", "user-1", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("price %v: expected 400, got %d", price, w.Code)
+		}
+	}
+}
+
+func TestRecipePricesAreCheckedOnUpdate(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.MaxRecipePrice = 100
+	h := NewRecipeHandler(db, cfg)
+	author := createUser(t, db)
+	
+	// The limit itself is allowed
+	body := recipeRequest(createCategory(t, db).ID)
+	body["price"] = 100
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", author.ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	var created models.Recipe
+	decode(t, w, &created)
+	if created.Price != 100 {
+		t.Fatalf("expected the price to be 100, got %v", created.Price)
+	}
+	
+	for _, price := range []float64{-5, -0.01, 100.01} {
+		w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+created.ID, author.ID, gin.H{"price": price})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("price %v: expected 400, got %d", price, w.Code)
+		}
+	}
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", created.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Price != 100 {
+		t.Errorf("expected rejected updates to keep the price at 100, got %v", stored.Price)
+	}
+	
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+created.ID, author.ID, gin.H{"price": 99.99})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestConvertRecipeUnitsFlagsWhatChanged(t *testing.T) {
+	recipe := models.Recipe{
+		Ingredients: []models.Ingredient{
+			{ID: "flour", Name: "flour", Quantity: "200 g"},
+			{ID: "garlic", Name: "garlic", Quantity: "2 cloves"},
+		},
+		Steps: []models.Step{
+			{ID: "bake", Instruction: "Bake at 200°C"},
+			{ID: "rest", Instruction: "Rest for 5 minutes"},
+		},
+	}
+	
+	conversions := convertRecipeUnits(&recipe, utils.UnitsImperial)
+	
+	flour, garlic := recipe.Ingredients[0], recipe.Ingredients[1]
+	if flour.Quantity != "7.05" || flour.Unit != "oz" || flour.Amount == nil || *flour.Amount != 7.05 {
+		t.Errorf("expected 200 g as 7.05 oz, got %q %q", flour.Quantity, flour.Unit)
+	}
+	if garlic.Quantity != "2 cloves" || garlic.Unit != "" {
+		t.Errorf("expected the cloves untouched, got %q %q", garlic.Quantity, garlic.Unit)
+	}
+	if recipe.Steps[0].Instruction != "Bake at 392°F" || recipe.Steps[1].Instruction != "Rest for 5 minutes" {
+		t.Errorf("unexpected steps %+v", recipe.Steps)
+	}
+	
+	want := []unitConversion{
+		{Field: "ingredient", ID: "flour", Original: "200 g"},
+		{Field: "step", ID: "bake", Original: "Bake at 200°C"},
+	}
+	if !slices.Equal(conversions, want) {
+		t.Errorf("expected conversions %+v, got %+v", want, conversions)
+	}
+}
+
+func TestBulkDeleteSkipsRecipesOfOthers(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	other := createUser(t, db)
+	category := createCategory(t, db)
+	mine := createRecipe(t, db, author, category, nil)
+	myDraft := createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	theirs := createRecipe(t, db, other, category, nil)
+	missing := "00000000-0000-0000-0000-000000000000"
+	
+	w := serve(h.BulkDeleteRecipes, "DELETE", "/recipes", "/recipes", author.ID,
+		gin.H{"ids": []string{mine.ID, theirs.ID, myDraft.ID, missing, mine.ID}})
+	expectStatus(t, w, http.StatusOK)
+	
+	var body struct {
+		Results []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"results"`
+		Deleted int `json:"deleted"`
+		Skipped int `json:"skipped"`
+	}
+	decode(t, w, &body)
+	want := map[string]string{mine.ID: "deleted", theirs.ID: "skipped", myDraft.ID: "deleted", missing: "skipped"}
+	if len(body.Results) != len(want) || body.Deleted != 2 || body.Skipped != 2 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	for _, result := range body.Results {
+		if want[result.ID] != result.Status {
+			t.Errorf("%s: expected %s, got %s", result.ID, want[result.ID], result.Status)
+		}
+	}
+	
+	for _, recipe := range []models.Recipe{mine, myDraft} {
+		if err := db.First(&models.Recipe{}, "id = ?", recipe.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("expected %q to be deleted, got %v", recipe.Title, err)
+		}
+		if err := db.Unscoped().First(&models.Recipe{}, "id = ?", recipe.ID).Error; err != nil {
+			t.Errorf("expected %q to be soft deleted, got %v", recipe.Title, err)
+		}
+	}
+	if err := db.First(&models.Recipe{}, "id = ?", theirs.ID).Error; err != nil {
+		t.Errorf("expected the other author's recipe to remain, got %v", err)
+	}
+	
+	for _, ids := range []interface{}{[]string{}, []string{"not-a-uuid"}} {
+		w := serve(h.BulkDeleteRecipes, "DELETE", "/recipes", "/recipes", author.ID, gin.H{"ids": ids})
+		expectStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestOnlyTheAuthorSeesPurchasers(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) { r.Price = 25 })
+	buyer := createUser(t, db)
+	pending := createUser(t, db)
+	createPurchase(t, db, buyer, recipe, "completed")
+	createPurchase(t, db, pending, recipe, "pending")
+	target := "/recipes/" + recipe.ID + "/purchasers"
+	
+	w := serve(h.GetRecipePurchasers, "GET", "/recipes/:id/purchasers", target, buyer.ID, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	
+	w = serve(h.GetRecipePurchasers, "GET", "/recipes/:id/purchasers", target, author.ID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), "@example.com") {
+		t.Errorf("expected buyer emails to stay private, got %s", w.Body)
+	}
+	
+	var page PaginatedResponse[models.RecipePurchaser]
+	decode(t, w, &page)
+	if page.Total != 1 || len(page.Data) != 1 {
+		t.Fatalf("expected only the completed purchase, got %+v", page)
+	}
+	if got := page.Data[0]; got.ID != buyer.ID || got.Username != buyer.Username || got.Amount != 25 || got.PurchasedAt.IsZero() {
+		t.Errorf("unexpected purchaser %+v", got)
+	}
+}
+
+func TestStaleRecipeEditsConflict(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), nil)
+	target := "/recipes/" + recipe.ID
+	
+	// Two sessions load version 1; the first to save wins
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", target, author.ID, gin.H{"title": "First edit", "version": 1})
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Recipe
+	decode(t, w, &updated)
+	if updated.Version != 2 {
+		t.Errorf("expected the edit to bump the version to 2, got %d", updated.Version)
+	}
+	
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", target, author.ID, gin.H{"title": "Second edit", "version": 1})
+	expectStatus(t, w, http.StatusConflict)
+	var conflict struct {
+		Version int `json:"version"`
+	}
+	decode(t, w, &conflict)
+	if conflict.Version != 2 {
+		t.Errorf("expected the conflict to report version 2, got %d", conflict.Version)
+	}
+	
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "First edit" || stored.Version != 2 {
+		t.Errorf("expected the stale edit to change nothing, got %q at version %d", stored.Title, stored.Version)
+	}
+	
+	// Edits without a version aren't checked
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", target, author.ID, gin.H{"title": "Unchecked edit"})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestRecipeWithoutIngredientsListsEmptyArrays(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	recipe := createRecipe(t, db, createUser(t, db), createCategory(t, db), nil)
+	
+	for _, w := range []*httptest.ResponseRecorder{
+		serve(h.GetRecipe, "GET", "/recipes/:id", "/recipes/"+recipe.ID, "", nil),
+		serve(h.GetRecipes, "GET", "/recipes", "/recipes?author_id="+recipe.UserID, "", nil),
+	} {
+		expectStatus(t, w, http.StatusOK)
+		body := w.Body.String()
+		for _, field := range []string{"ingredients", "steps", "images"} {
+			if !strings.Contains(body, `"`+field+`":[]`) {
+				t.Errorf("expected %q to be an empty array in %s", field, body)
+			}
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultRecommendationLimit is how many recipes GetRecipesByIngredients
+// returns unless asked for a different number.
+const defaultRecommendationLimit = 20
+
+// ingredientMatch is a recipe recommended from the ingredients a user has,
+// with the recipe ingredients that none of them cover.
+type ingredientMatch struct {
+	recipeListItem
+	MatchedCount       int      `json:"matched_count"`
+	MissingIngredients []string `json:"missing_ingredients"`
+}
+
+// GetRecipesByIngredients recommends published recipes for the ingredients a
+// user has on hand. A recipe ingredient counts as covered when its name
+// contains one of the given names, case-insensitively. Recipes covering the
+// largest share of their ingredients come first; max_missing drops recipes
+// that need more than that many other ingredients.
+func (h *RecipeHandler) GetRecipesByIngredients(c *gin.Context) {
+	var input struct {
+		Ingredients []string `json:"ingredients" binding:"required,min=1,max=50,dive,required,max=100"`
+		MaxMissing  *int     `json:"max_missing" binding:"omitempty,min=0"`
+		Limit       int      `json:"limit" binding:"omitempty,min=1,max=50"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+	
+	var terms []string
+	for _, name := range input.Ingredients {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			terms = append(terms, name)
+		}
+	}
+	terms = uniqueStrings(terms)
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one ingredient is required"})
+		return
+	}
+	if input.Limit == 0 {
+		input.Limit = defaultRecommendationLimit
+	}
+	
+	// One ILIKE per ingredient the user has, OR-ed together
+	conditions := make([]string, len(terms))
+	patterns := make([]interface{}, len(terms))
+	for i, term := range terms {
+		conditions[i] = "ingredients.name ILIKE ?"
+		patterns[i] = "%" + escapeLike(term) + "%"
+	}
+	covered := gorm.Expr("COUNT(*) FILTER (WHERE "+strings.Join(conditions, " OR ")+")", patterns...)
+	
+	query := h.db(c).Model(&models.Ingredient{}).
+		Joins("JOIN recipes ON recipes.id = ingredients.recipe_id").
+		Where("recipes.is_published = ? AND recipes.deleted_at IS NULL", true).
+		Group("ingredients.recipe_id").
+		Having("? > 0", covered)
+	if input.MaxMissing != nil {
+		query = query.Having("COUNT(*) - ? <= ?", covered, *input.MaxMissing)
+	}
+	
+	var ranked []struct {
+		RecipeID string
+	}
+	if err := query.Select("ingredients.recipe_id").
+		Clauses(clause.OrderBy{Expression: gorm.Expr("? * 1.0 / COUNT(*) DESC, ? DESC, ingredients.recipe_id", covered, covered)}).
+		Limit(input.Limit).
+		Scan(&ranked).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find recipes"})
+		return
+	}
+	
+	ids := make([]string, len(ranked))
+	for i, row := range ranked {
+		ids[i] = row.RecipeID
+	}
+	
+	var recipes []models.Recipe
+	if len(ids) > 0 {
+		if err := h.db(c).Preload("User").Preload("Category").Preload("Images").Preload("Ingredients").
+			Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find recipes"})
+			return
+		}
+	}
+	
+	// Keep the ranking from the aggregate query
+	userID, _ := c.Get("user_id")
+	byID := make(map[string]recipeListItem, len(recipes))
+	for _, item := range withOwnership(recipes, userID) {
+		byID[item.ID] = item
+	}
+	
+	matches := make([]ingredientMatch, 0, len(ids))
+	for _, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			continue
+		}
+		
+		match := ingredientMatch{recipeListItem: item, MissingIngredients: []string{}}
+		for _, ingredient := range item.Ingredients {
+			if coversIngredient(terms, ingredient.Name) {
+				match.MatchedCount++
+			} else {
+				match.MissingIngredients = append(match.MissingIngredients, ingredient.Name)
+			}
+		}
+		matches = append(matches, match)
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"recipes":     matches,
+		"ingredients": terms,
+	})
+}
+
+// coversIngredient mirrors the ILIKE matching of GetRecipesByIngredients.
+func coversIngredient(terms []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, term := range terms {
+		if strings.Contains(name, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestCoversIngredient(t *testing.T) {
+	terms := []string{"lentil", "50%"}
+	tests := map[string]bool{
+		"Red Lentils":        true,
+		"LENTIL stock":       true,
+		"onion":              false,
+		"50% dark chocolate": true,
+	}
+	for name, want := range tests {
+		if got := coversIngredient(terms, name); got != want {
+			t.Errorf("coversIngredient(%q) = %v, want %v", name, got, want)
+		}
+	}
+	
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("unexpected escaped pattern %q", got)
+	}
+}
+
+func TestRecipesByIngredientsRejectsBadInput(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	
+	for _, body := range []gin.H{
+		{},
+		{"ingredients": []string{}},
+		{"ingredients": []string{"  "}},
+		{"ingredients": []string{"lentils"}, "max_missing": -1},
+		{"ingredients": []string{"lentils"}, "limit": 51},
+	} {
+		w := serve(h.GetRecipesByIngredients, "POST", "/recipes/by-ingredients", "/recipes/by-ingredients", "", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestRecipesByIngredientsRankFullyCoveredFirst(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	withIngredients := func(names ...string) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) {
+			for _, name := range names {
+				r.Ingredients = append(r.Ingredients, models.Ingredient{Name: name})
+			}
+		})
+	}
+	partial := withIngredients("Red lentils", "onion", "cumin", "carrot")
+	full := withIngredients("Lentils", "Onion", "garlic")
+	withIngredients("rice", "beans")
+	
+	find := func(body gin.H) []ingredientMatch {
+		t.Helper()
+		body["ingredients"] = []string{" Lentil", "onion", "GARLIC", "onion"}
+		w := serve(h.GetRecipesByIngredients, "POST", "/recipes/by-ingredients", "/recipes/by-ingredients", "", body)
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Recipes []ingredientMatch `json:"recipes"`
+		}
+		decode(t, w, &response)
+		return response.Recipes
+	}
+	
+	matches := find(gin.H{})
+	if len(matches) != 2 || matches[0].ID != full.ID || matches[1].ID != partial.ID {
+		t.Fatalf("expected the fully covered recipe before the partial one, got %+v", matches)
+	}
+	if matches[0].MatchedCount != 3 || len(matches[0].MissingIngredients) != 0 {
+		t.Errorf("expected nothing missing for the covered recipe, got %+v", matches[0])
+	}
+	missing := matches[1].MissingIngredients
+	slices.Sort(missing)
+	if matches[1].MatchedCount != 2 || !slices.Equal(missing, []string{"carrot", "cumin"}) {
+		t.Errorf("expected carrot and cumin to be missing, got %d matched and %v", matches[1].MatchedCount, missing)
+	}
+	
+	matches = find(gin.H{"max_missing": 1})
+	if len(matches) != 1 || matches[0].ID != full.ID {
+		t.Errorf("expected max_missing to drop the partial recipe, got %+v", matches)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"gorm.io/gorm"
+)
+
+// PublishDueRecipes publishes every recipe whose scheduled publish time has
+// passed and returns how many were published. Recipes taken down by a
+// moderator are skipped. Publish times are stored in UTC.
+func PublishDueRecipes(db *gorm.DB) (int64, error) {
+	result := db.Model(&models.Recipe{}).
+		Where("is_published = ? AND moderation_hidden = ? AND publish_at <= ?", false, false, time.Now().UTC()).
+		Updates(map[string]interface{}{"is_published": true, "publish_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+// PruneSearchLogs deletes search logs too old to count towards the trending
+// searches and returns how many were deleted.
+func PruneSearchLogs(db *gorm.DB) (int64, error) {
+	result := db.Where("created_at < ?", time.Now().AddDate(0, 0, -maxTrendingDays)).Delete(&models.SearchLog{})
+	return result.RowsAffected, result.Error
+}
\ No newline at end of file
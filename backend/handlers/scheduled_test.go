@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+)
+
+func TestPublishDueRecipes(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	scheduled := func(at time.Time) models.Recipe {
+		return createRecipe(t, db, author, category, func(r *models.Recipe) {
+			r.IsPublished = false
+			r.PublishAt = &at
+		})
+	}
+	due := scheduled(time.Now().UTC().Add(-time.Minute))
+	later := scheduled(time.Now().UTC().Add(time.Hour))
+	createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	
+	expectListed(t, "before publishing", listedRecipes(t, h, ""))
+	
+	published, err := PublishDueRecipes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 1 {
+		t.Errorf("expected 1 recipe to be published, got %d", published)
+	}
+	expectListed(t, "after publishing", listedRecipes(t, h, ""), due)
+	
+	var stored models.Recipe
+	if err := db.First(&stored, "id = ?", due.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.PublishAt != nil {
+		t.Errorf("expected the schedule to be cleared, got %v", stored.PublishAt)
+	}
+	if err := db.First(&stored, "id = ?", later.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.IsPublished || stored.PublishAt == nil {
+		t.Error("expected the later recipe to stay scheduled")
+	}
+	
+	if published, err := PublishDueRecipes(db); err != nil || published != 0 {
+		t.Errorf("expected a second run to publish nothing, got %d, %v", published, err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Result caps for each group of the global search.
+const (
+	defaultSearchGroupSize = 5
+	maxSearchGroupSize     = 20
+)
+
+// Limits for logged search terms and the trending list. Logs older than
+// maxTrendingDays are pruned, so it also bounds the window.
+const (
+	minSearchTermLength  = 3
+	maxSearchTermLength  = 100
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+	defaultTrendingDays  = 7
+	maxTrendingDays      = 30
+)
+
+// searchLogInterval is how long repeats of a term by the same client aren't
+// logged again. Trending counts distinct clients, so the repeats would only
+// take up space.
+const searchLogInterval = time.Hour
+
+type SearchHandler struct {
+	DB     *gorm.DB
+	Config *config.Config
+	
+	// Trending terms are public, so they are screened like comments and
+	// may not contain links
+	termFilter utils.ContentFilter
+}
+
+func NewSearchHandler(db *gorm.DB, cfg *config.Config) *SearchHandler {
+	return &SearchHandler{
+		DB:         db,
+		Config:     cfg,
+		termFilter: utils.NewWordListFilter(cfg.CommentBannedWords, 0),
+	}
+}
+
+// db returns the handler's database bound to the request context.
+func (h *SearchHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+// searchRecipe is the short form of a recipe used in search results.
+type searchRecipe struct {
+	ID               string  `json:"id"`
+	Title            string  `json:"title"`
+	Slug             *string `json:"slug"`
+	FeaturedImageURL *string `json:"featured_image_url"`
+}
+
+// searchCategory is the short form of a category used in search results.
+type searchCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Search looks for q in published recipe titles, usernames and category names
+// and returns up to limit matches per group with each group's total. Only
+// public fields are returned, so drafts and emails never show up.
+func (h *SearchHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit < 1 {
+		limit = defaultSearchGroupSize
+	}
+	if limit > maxSearchGroupSize {
+		limit = maxSearchGroupSize
+	}
+	
+	// Wildcards typed by the user match literally
+	pattern := "%" + escapeLike(q) + "%"
+	
+	recipes := []searchRecipe{}
+	var recipeTotal int64
+	recipeQuery := publishedRecipes(h.db(c).Model(&models.Recipe{})).Where("title ILIKE ?", pattern)
+	if err := recipeQuery.Count(&recipeTotal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
+		return
+	}
+	if err := recipeQuery.Order("like_count DESC").Limit(limit).Find(&recipes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
+		return
+	}
+	
+	users := []models.PublicUser{}
+	var userTotal int64
+	userQuery := h.db(c).Model(&models.User{}).Where("username ILIKE ? AND id <> ?", pattern, deletedUserID)
+	if err := userQuery.Count(&userTotal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	if err := userQuery.Order("username ASC").Limit(limit).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	
+	categories := []searchCategory{}
+	var categoryTotal int64
+	categoryQuery := h.db(c).Model(&models.Category{}).Where("name ILIKE ?", pattern)
+	if err := categoryQuery.Count(&categoryTotal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search categories"})
+		return
+	}
+	if err := categoryQuery.Order("name ASC").Limit(limit).Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search categories"})
+		return
+	}
+	
+	logSearchTerm(h.db(c), q, searchClient(c, h.Config.JWTSecret))
+	
+	c.JSON(http.StatusOK, gin.H{
+		"query":      q,
+		"recipes":    gin.H{"items": recipes, "total": recipeTotal},
+		"users":      gin.H{"items": users, "total": userTotal},
+		"categories": gin.H{"items": categories, "total": categoryTotal},
+	})
+}
+
+// trendingTerm is a search term with how often it was searched.
+type trendingTerm struct {
+	Term  string `json:"term"`
+	Count int64  `json:"count"`
+}
+
+// GetTrendingSearches returns the terms the most clients searched for in the
+// last days, most popular first. Terms the content filter rejects are left out.
+func (h *SearchHandler) GetTrendingSearches(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit < 1 {
+		limit = defaultTrendingLimit
+	}
+	if limit > maxTrendingLimit {
+		limit = maxTrendingLimit
+	}
+	
+	days := defaultTrendingDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxTrendingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number between 1 and " + strconv.Itoa(maxTrendingDays)})
+			return
+		}
+		days = parsed
+	}
+	
+	// Logs without a client hash predate it and each count as their own client.
+	// Ranked terms are read in batches until enough pass the filter.
+	terms := make([]trendingTerm, 0, limit)
+	batch := limit * 2
+	for offset := 0; len(terms) < limit; offset += batch {
+		var ranked []trendingTerm
+		if err := h.db(c).Model(&models.SearchLog{}).
+			Select("term, COUNT(DISTINCT CASE WHEN client_hash = '' THEN id::text ELSE client_hash END) AS count").
+			Where("created_at >= ?", time.Now().AddDate(0, 0, -days)).
+			Group("term").
+			Order("count DESC").Order("term ASC").
+			Offset(offset).Limit(batch).
+			Scan(&ranked).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending searches"})
+			return
+		}
+		
+		for _, term := range ranked {
+			if len(terms) == limit {
+				break
+			}
+			if h.termFilter.Check(term.Term) == nil {
+				terms = append(terms, term)
+			}
+		}
+		if len(ranked) < batch {
+			break
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"days":  days,
+		"terms": terms,
+	})
+}
+
+// normalizeSearchTerm lowercases a query and collapses its whitespace. It
+// returns "" for terms too short or too long to be worth counting.
+func normalizeSearchTerm(q string) string {
+	term := strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if length := utf8.RuneCountInString(term); length < minSearchTermLength || length > maxSearchTermLength {
+		return ""
+	}
+	return term
+}
+
+// searchClient identifies who searched, by user ID when signed in and by IP
+// address otherwise. The value is keyed with secret so it can't be traced back
+// to an address.
+func searchClient(c *gin.Context, secret string) string {
+	client := "ip:" + c.ClientIP()
+	if userID, ok := c.Get("user_id"); ok {
+		client = "user:" + userID.(string)
+	}
+	
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(client))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// logSearchTerm records a search for the trending list, unless the client
+// already searched for the term within searchLogInterval. Logging is best
+// effort and never fails the search itself.
+func logSearchTerm(db *gorm.DB, q, client string) {
+	term := normalizeSearchTerm(q)
+	if term == "" {
+		return
+	}
+	
+	now := time.Now()
+	db.Exec(`INSERT INTO search_logs (term, client_hash, created_at)
+		SELECT ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM search_logs WHERE term = ? AND client_hash = ? AND created_at >= ?)`,
+		term, client, now, term, client, now.Add(-searchLogInterval))
+}
\ No newline at end of file
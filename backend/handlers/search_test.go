@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func searchContext(ip, userID string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/search", nil)
+	c.Request.RemoteAddr = ip + ":1234"
+	if userID != "" {
+		c.Set("user_id", userID)
+	}
+	return c
+}
+
+func TestSearchClientIdentifiesUsersAndAddresses(t *testing.T) {
+	anonymous := searchClient(searchContext("203.0.113.7", ""), "secret")
+	if anonymous != searchClient(searchContext("203.0.113.7", ""), "secret") {
+		t.Error("expected the same address to give the same client")
+	}
+	if anonymous == searchClient(searchContext("203.0.113.8", ""), "secret") {
+		t.Error("expected different addresses to give different clients")
+	}
+	if strings.Contains(anonymous, "203.0.113.7") {
+		t.Errorf("client hash exposes the address: %s", anonymous)
+	}
+	
+	// Signed in users are the same client from any address
+	user := searchClient(searchContext("203.0.113.7", "u1"), "secret")
+	if user == anonymous || user != searchClient(searchContext("198.51.100.1", "u1"), "secret") {
+		t.Error("expected signed in users to be identified by their ID")
+	}
+}
+
+func TestLogSearchTermSkipsRepeatsFromTheSameClient(t *testing.T) {
+	db, log := dryRun(t)
+	logSearchTerm(db, "  Lentil   SOUP ", "client")
+	logSearchTerm(db, "ab", "client")
+	
+	if len(log.statements) != 1 {
+		t.Fatalf("expected one insert, got %q", log.statements)
+	}
+	if !strings.Contains(log.statements[0], "SELECT 'lentil soup', 'client'") ||
+		!strings.Contains(log.statements[0], "WHERE NOT EXISTS (SELECT 1 FROM search_logs WHERE term = 'lentil soup' AND client_hash = 'client'") {
+		t.Errorf("unexpected insert: %s", log.statements[0])
+	}
+}
+
+func TestSearchRequiresAQuery(t *testing.T) {
+	// A missing query is answered before the database is used
+	h := NewSearchHandler(nil, testConfig())
+	w := serve(h.Search, "GET", "/search", "/search?q=%20", "", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestSearchMatchesWildcardsLiterally(t *testing.T) {
+	db, log := dryRun(t)
+	h := NewSearchHandler(db, testConfig())
+	w := serve(h.Search, "GET", "/search", "/search?q=50%25_off", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	
+	var searches int
+	for _, statement := range log.statements {
+		if strings.Contains(statement, "ILIKE") {
+			searches++
+			if !strings.Contains(statement, `ILIKE '%50\%\_off%'`) {
+				t.Errorf("expected the wildcards to be escaped: %s", statement)
+			}
+		}
+		if strings.Contains(statement, "title ILIKE") && !strings.Contains(statement, "recipes.is_published = true") {
+			t.Errorf("expected only published recipes to be searched: %s", statement)
+		}
+	}
+	if searches != 6 {
+		t.Errorf("expected a count and a page per group, got %q", log.statements)
+	}
+}
+
+func TestSearchGroupsResults(t *testing.T) {
+	db := testDB(t)
+	h := NewSearchHandler(db, testConfig())
+	cook := createUser(t, db)
+	if err := db.Model(&cook).Update("username", "zucchini_lover").Error; err != nil {
+		t.Fatal(err)
+	}
+	category := createCategory(t, db)
+	if err := db.Model(&category).Update("name", "Zucchini Dishes").Error; err != nil {
+		t.Fatal(err)
+	}
+	titled := func(title string, published bool) models.Recipe {
+		return createRecipe(t, db, cook, category, func(r *models.Recipe) {
+			r.Title = title
+			r.IsPublished = published
+		})
+	}
+	fritters := titled("Zucchini Fritters", true)
+	titled("Stuffed Zucchini", true)
+	titled("Zucchini Secret Draft", false)
+	titled("Carrot Cake", true)
+	
+	w := serve(h.Search, "GET", "/search", "/search?q=zucchini&limit=1", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if body := w.Body.String(); strings.Contains(body, cook.Email) || strings.Contains(body, "password") || strings.Contains(body, "Secret Draft") {
+		t.Errorf("expected private data to be left out, got %s", body)
+	}
+	
+	var body struct {
+		Recipes struct {
+			Items []searchRecipe `json:"items"`
+			Total int64          `json:"total"`
+		} `json:"recipes"`
+		Users struct {
+			Items []models.PublicUser `json:"items"`
+			Total int64               `json:"total"`
+		} `json:"users"`
+		Categories struct {
+			Items []searchCategory `json:"items"`
+			Total int64            `json:"total"`
+		} `json:"categories"`
+	}
+	decode(t, w, &body)
+	if body.Recipes.Total != 2 || len(body.Recipes.Items) != 1 {
+		t.Errorf("expected 1 of 2 published recipes, got %d of %d", len(body.Recipes.Items), body.Recipes.Total)
+	}
+	if body.Users.Total != 1 || len(body.Users.Items) != 1 || body.Users.Items[0].ID != cook.ID {
+		t.Errorf("expected the cook under users, got %+v", body.Users)
+	}
+	if body.Categories.Total != 1 || len(body.Categories.Items) != 1 || body.Categories.Items[0].ID != category.ID {
+		t.Errorf("expected the category under categories, got %+v", body.Categories)
+	}
+	
+	w = serve(h.Search, "GET", "/search", "/search?q=fritters", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &body)
+	if len(body.Recipes.Items) != 1 || body.Recipes.Items[0].ID != fritters.ID || body.Users.Total != 0 || body.Categories.Total != 0 {
+		t.Errorf("expected only the fritters, got %+v", body)
+	}
+}
+
+func TestTrendingRejectsBadWindows(t *testing.T) {
+	// Invalid windows are answered before the database is used
+	h := NewSearchHandler(nil, testConfig())
+	for _, days := range []string{"0", "31", "week"} {
+		w := serve(h.GetTrendingSearches, "GET", "/search/trending", "/search/trending?days="+days, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: expected 400, got %d", days, w.Code)
+		}
+	}
+}
+
+func TestTrendingRanksTheMostSearchedTermFirst(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.CommentBannedWords = []string{"spam"}
+	h := NewSearchHandler(db, cfg)
+	
+	searches := map[string][]string{
+		"lentil soup":   {"a", "b", "c", "a"},
+		"  PASTA bake ": {"a", "b"},
+		"pasta bake":    {"b"},
+		"ab":            {"a", "b", "c", "d", "e"},
+		"spam offers":   {"a", "b", "c", "d", "e"},
+	}
+	for q, clients := range searches {
+		for _, client := range clients {
+			logSearchTerm(db, q, client)
+		}
+	}
+	// Searches older than the window only count when asked for
+	old := []models.SearchLog{
+		{Term: "pumpkin pie", ClientHash: "a", CreatedAt: time.Now().AddDate(0, 0, -10)},
+		{Term: "pumpkin pie", ClientHash: "b", CreatedAt: time.Now().AddDate(0, 0, -10)},
+		{Term: "pumpkin pie", ClientHash: "c", CreatedAt: time.Now().AddDate(0, 0, -10)},
+		{Term: "pumpkin pie", ClientHash: "d", CreatedAt: time.Now().AddDate(0, 0, -10)},
+	}
+	if err := db.Create(&old).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	trending := func(query string) []trendingTerm {
+		t.Helper()
+		w := serve(h.GetTrendingSearches, "GET", "/search/trending", "/search/trending"+query, "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Terms []trendingTerm `json:"terms"`
+		}
+		decode(t, w, &response)
+		return response.Terms
+	}
+	
+	want := []trendingTerm{{"lentil soup", 3}, {"pasta bake", 2}}
+	if got := trending(""); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := trending("?limit=1"); !slices.Equal(got, want[:1]) {
+		t.Errorf("expected only %v, got %v", want[:1], got)
+	}
+	want = append([]trendingTerm{{"pumpkin pie", 4}}, want...)
+	if got := trending("?days=30"); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// GetRecipeBySlug serves the same response as GetRecipe for a recipe's slug.
+// A slug the recipe had before its title changed redirects to the current one.
+// Like GetRecipe, drafts are only found by their author.
+func (h *RecipeHandler) GetRecipeBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	userID, _ := c.Get("user_id")
+	
+	var recipe models.Recipe
+	if err := h.db(c).Select("id").Where("slug = ? AND (is_published = ? OR user_id = ?)", slug, true, userID).
+		First(&recipe).Error; err != nil {
+		var redirect models.RecipeSlugRedirect
+		if err := h.db(c).Where("slug = ?", slug).First(&redirect).Error; err == nil {
+			var target models.Recipe
+			if err := h.db(c).Select("id", "slug").Where("id = ? AND (is_published = ? OR user_id = ?)", redirect.RecipeID, true, userID).
+				First(&target).Error; err == nil && target.Slug != nil {
+				location := "/api/recipes/by-slug/" + url.PathEscape(*target.Slug)
+				if c.Request.URL.RawQuery != "" {
+					location += "?" + c.Request.URL.RawQuery
+				}
+				c.Redirect(http.StatusMovedPermanently, location)
+				return
+			}
+		}
+		
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: recipe.ID})
+	h.GetRecipe(c)
+}
+
+// maxSlugAttempts bounds how often a slug is picked again after a concurrent
+// request took it first.
+const maxSlugAttempts = 3
+
+// withUniqueSlug picks a slug for title with uniqueRecipeSlug and passes it to
+// save, which writes it within a savepoint. Another request can claim the same
+// slug between the check and the write, in which case the unique index rejects
+// the write and a new slug is picked.
+func withUniqueSlug(tx *gorm.DB, title, recipeID string, save func(tx *gorm.DB, slug string) error) error {
+	for attempt := 1; ; attempt++ {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			slug, err := uniqueRecipeSlug(tx, title, recipeID)
+			if err != nil {
+				return err
+			}
+			return save(tx, slug)
+		})
+		if attempt < maxSlugAttempts && isSlugConflict(err) {
+			continue
+		}
+		return err
+	}
+}
+
+// isSlugConflict reports whether err is a unique violation on a recipe slug or
+// a slug redirect.
+func isSlugConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(pgErr.ConstraintName == "idx_recipes_slug" || pgErr.ConstraintName == "recipe_slug_redirects_pkey")
+}
+
+// uniqueRecipeSlug derives a slug from a title, adding -2, -3 and so on until it
+// is used by no other recipe, deleted ones included, and by no other recipe's
+// redirect. recipeID is empty for a recipe that hasn't been created yet.
+func uniqueRecipeSlug(tx *gorm.DB, title, recipeID string) (string, error) {
+	base := utils.Slugify(title)
+	
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+		
+		recipes := tx.Unscoped().Model(&models.Recipe{}).Where("slug = ?", candidate)
+		redirects := tx.Model(&models.RecipeSlugRedirect{}).Where("slug = ?", candidate)
+		if recipeID != "" {
+			recipes = recipes.Where("id <> ?", recipeID)
+			redirects = redirects.Where("recipe_id <> ?", recipeID)
+		}
+		
+		var taken int64
+		if err := recipes.Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			if err := redirects.Count(&taken).Error; err != nil {
+				return "", err
+			}
+		}
+		if taken == 0 {
+			return candidate, nil
+		}
+	}
+}
+
+// renameRecipeSlug gives a recipe a new slug, picked by withUniqueSlug, and
+// keeps the old slug as a redirect.
+func renameRecipeSlug(tx *gorm.DB, recipe *models.Recipe, slug string) error {
+	if recipe.Slug == nil || *recipe.Slug == slug {
+		return nil
+	}
+	
+	// Taking back one of its own earlier slugs drops that redirect
+	if err := tx.Where("slug = ? AND recipe_id = ?", slug, recipe.ID).Delete(&models.RecipeSlugRedirect{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.RecipeSlugRedirect{Slug: *recipe.Slug, RecipeID: recipe.ID}).Error
+}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsSlugConflict(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "idx_recipes_slug"}, true},
+		{fmt.Errorf("create: %w", &pgconn.PgError{Code: "23505", ConstraintName: "recipe_slug_redirects_pkey"}), true},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_lower"}, false},
+		{&pgconn.PgError{Code: "23503", ConstraintName: "idx_recipes_slug"}, false},
+		{errors.New("connection reset"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isSlugConflict(tt.err); got != tt.want {
+			t.Errorf("isSlugConflict(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRecipeSlugs(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	category := createCategory(t, db)
+	title := fixtureName("Spicy Lentil Soup ")
+	base := utils.Slugify(title)
+	
+	create := func() models.Recipe {
+		t.Helper()
+		body := recipeRequest(category.ID)
+		body["title"] = title
+		w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes?force=true", author.ID, body)
+		expectStatus(t, w, http.StatusCreated)
+		var recipe models.Recipe
+		decode(t, w, &recipe)
+		return recipe
+	}
+	bySlug := func(slug, userID string) *httptest.ResponseRecorder {
+		return serve(h.GetRecipeBySlug, "GET", "/recipes/by-slug/:slug", "/recipes/by-slug/"+slug, userID, nil)
+	}
+	
+	first, second := create(), create()
+	if first.Slug == nil || *first.Slug != base {
+		t.Fatalf("expected the slug %q, got %v", base, first.Slug)
+	}
+	if second.Slug == nil || *second.Slug != base+"-2" {
+		t.Fatalf("expected a colliding title to get %q, got %v", base+"-2", second.Slug)
+	}
+	
+	w := bySlug(base+"-2", "")
+	expectStatus(t, w, http.StatusOK)
+	var detail struct {
+		Recipe models.Recipe `json:"recipe"`
+	}
+	decode(t, w, &detail)
+	if detail.Recipe.ID != second.ID {
+		t.Errorf("expected the slug to find %s, got %s", second.ID, detail.Recipe.ID)
+	}
+	
+	// Renaming keeps the old slug as a redirect, so it stays taken
+	w = serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+first.ID, author.ID, gin.H{"title": title + " Deluxe"})
+	expectStatus(t, w, http.StatusOK)
+	w = bySlug(base+"?units=metric", "")
+	expectStatus(t, w, http.StatusMovedPermanently)
+	if location := w.Header().Get("Location"); location != "/api/recipes/by-slug/"+base+"-deluxe?units=metric" {
+		t.Errorf("unexpected redirect to %q", location)
+	}
+	if third := create(); *third.Slug != base+"-3" {
+		t.Errorf("expected a redirecting slug to stay taken, got %q", *third.Slug)
+	}
+	
+	// Drafts are only found by their author
+	if err := db.Model(&models.Recipe{}).Where("id = ?", second.ID).Update("is_published", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, bySlug(base+"-2", createUser(t, db).ID), http.StatusNotFound)
+	expectStatus(t, bySlug(base+"-2", author.ID), http.StatusOK)
+	expectStatus(t, bySlug("no-such-recipe", ""), http.StatusNotFound)
+}
\ No newline at end of file
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exactCountLimit is the estimated table size up to which rows are counted
+// exactly. Larger tables report the planner's estimate instead of a scan.
+const exactCountLimit = 100000
+
+// platformStats are the site-wide counts shown on the stats page. Approximate
+// lists the counts that are estimates.
+type platformStats struct {
+	PublishedRecipes int64     `json:"published_recipes"`
+	Users            int64     `json:"users"`
+	Ratings          int64     `json:"ratings"`
+	Categories       int64     `json:"categories"`
+	Approximate      []string  `json:"approximate"`
+	GeneratedAt      time.Time `json:"generated_at"`
+}
+
+type StatsHandler struct {
+	DB     *gorm.DB
+	Config *config.Config
+	
+	// The stats are cached for StatsCacheTTL so the page doesn't cost a query
+	// per visitor
+	cacheMu        sync.RWMutex
+	cached         *platformStats
+	cacheExpiresAt time.Time
+}
+
+func NewStatsHandler(db *gorm.DB, cfg *config.Config) *StatsHandler {
+	return &StatsHandler{DB: db, Config: cfg}
+}
+
+// db returns the handler's database bound to the request context.
+func (h *StatsHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+// GetStats returns the number of published recipes, users, ratings and
+// categories on the platform.
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	h.cacheMu.RLock()
+	stats, fresh := h.cached, time.Now().Before(h.cacheExpiresAt)
+	h.cacheMu.RUnlock()
+	
+	if stats == nil || !fresh {
+		var err error
+		if stats, err = h.loadStats(h.db(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stats"})
+			return
+		}
+		
+		h.cacheMu.Lock()
+		h.cached = stats
+		h.cacheExpiresAt = time.Now().Add(time.Duration(h.Config.StatsCacheTTL) * time.Second)
+		h.cacheMu.Unlock()
+	}
+	
+	c.JSON(http.StatusOK, stats)
+}
+
+// loadStats counts everything shown by GetStats.
+func (h *StatsHandler) loadStats(db *gorm.DB) (*platformStats, error) {
+	stats := &platformStats{Approximate: []string{}, GeneratedAt: time.Now()}
+	
+	counts := []struct {
+		name  string
+		table string
+		query *gorm.DB
+		count *int64
+	}{
+		{"published_recipes", "recipes", publishedRecipes(db.Model(&models.Recipe{})), &stats.PublishedRecipes},
+		{"users", "users", db.Model(&models.User{}).Where("id <> ?", deletedUserID), &stats.Users},
+		{"ratings", "ratings", db.Model(&models.Rating{}), &stats.Ratings},
+		{"categories", "categories", db.Model(&models.Category{}), &stats.Categories},
+	}
+	for _, item := range counts {
+		approximate, err := countRows(db, item.table, item.query, item.count)
+		if err != nil {
+			return nil, err
+		}
+		if approximate {
+			stats.Approximate = append(stats.Approximate, item.name)
+		}
+	}
+	
+	return stats, nil
+}
+
+// countRows counts the rows matched by query into count. When the planner
+// estimates the table holds more than exactCountLimit rows, the planner's
+// estimate for query is used instead and countRows reports it as approximate.
+func countRows(db *gorm.DB, table string, query *gorm.DB, count *int64) (bool, error) {
+	// reltuples is -1 for tables that were never analyzed
+	var estimate int64
+	if err := db.Raw("SELECT COALESCE((SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)), -1)", table).
+		Scan(&estimate).Error; err != nil {
+		return false, err
+	}
+	if estimate > exactCountLimit {
+		rows, err := estimateRows(db, query)
+		if err != nil {
+			return false, err
+		}
+		*count = rows
+		return true, nil
+	}
+	
+	return false, query.Count(count).Error
+}
+
+// estimateRows returns the planner's estimate of the rows matched by query,
+// so its conditions and soft deletes are taken into account without a scan.
+func estimateRows(db *gorm.DB, query *gorm.DB) (int64, error) {
+	var plan string
+	if err := db.Raw("EXPLAIN (FORMAT JSON) ?", query).Scan(&plan).Error; err != nil {
+		return 0, err
+	}
+	
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &explained); err != nil {
+		return 0, err
+	}
+	if len(explained) == 0 {
+		return 0, errors.New("EXPLAIN returned no plan")
+	}
+	return int64(explained[0].Plan.Rows), nil
+}
\ No newline at end of file
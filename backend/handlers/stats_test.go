@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	
+	"food-recipes-backend/models"
+)
+
+func TestStatsCountSeededData(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.StatsCacheTTL = 0
+	h := NewStatsHandler(db, cfg)
+	
+	stats := func() platformStats {
+		t.Helper()
+		w := serve(h.GetStats, "GET", "/stats", "/stats", "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var stats platformStats
+		decode(t, w, &stats)
+		if len(stats.Approximate) != 0 {
+			t.Errorf("expected small tables to be counted exactly, got %v approximated", stats.Approximate)
+		}
+		return stats
+	}
+	before := stats()
+	
+	author := createUser(t, db)
+	fan := createUser(t, db)
+	category := createCategory(t, db)
+	recipe := createRecipe(t, db, author, category, nil)
+	createRecipe(t, db, author, category, nil)
+	createRecipe(t, db, author, category, func(r *models.Recipe) { r.IsPublished = false })
+	deleted := createRecipe(t, db, author, category, nil)
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	ratings := []models.Rating{
+		{UserID: author.ID, RecipeID: recipe.ID, Rating: 4},
+		{UserID: fan.ID, RecipeID: recipe.ID, Rating: 5},
+	}
+	if err := db.Create(&ratings).Error; err != nil {
+		t.Fatal(err)
+	}
+	// The placeholder for deleted accounts isn't a user
+	placeholder := models.User{ID: deletedUserID, Email: "deleted-user@deleted.invalid", Username: deletedUserUsername, PasswordHash: "!"}
+	if err := db.Where(models.User{ID: deletedUserID}).FirstOrCreate(&placeholder).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	after := stats()
+	if got := after.PublishedRecipes - before.PublishedRecipes; got != 2 {
+		t.Errorf("expected 2 more published recipes, got %d", got)
+	}
+	if got := after.Users - before.Users; got != 2 {
+		t.Errorf("expected 2 more users, got %d", got)
+	}
+	if got := after.Ratings - before.Ratings; got != 2 {
+		t.Errorf("expected 2 more ratings, got %d", got)
+	}
+	if got := after.Categories - before.Categories; got != 1 {
+		t.Errorf("expected 1 more category, got %d", got)
+	}
+}
+
+func TestStatsAreCached(t *testing.T) {
+	db := testDB(t)
+	h := NewStatsHandler(db, testConfig())
+	
+	count := func() int64 {
+		t.Helper()
+		w := serve(h.GetStats, "GET", "/stats", "/stats", "", nil)
+		expectStatus(t, w, http.StatusOK)
+		var stats platformStats
+		decode(t, w, &stats)
+		return stats.Categories
+	}
+	cached := count()
+	createCategory(t, db)
+	if got := count(); got != cached {
+		t.Errorf("expected the cached count %d until the cache expires, got %d", cached, got)
+	}
+}
+
+func TestEstimateRowsExplainsTheFilteredQuery(t *testing.T) {
+	db, log := dryRun(t)
+	
+	// The dry run returns no plan, only the statement is checked
+	estimateRows(db, publishedRecipes(db.Model(&models.Recipe{})))
+	if len(log.statements) != 1 {
+		t.Fatalf("expected one statement, got %q", log.statements)
+	}
+	want := `EXPLAIN (FORMAT JSON) SELECT * FROM "recipes" WHERE recipes.is_published = true AND "recipes"."deleted_at" IS NULL`
+	if log.statements[0] != want {
+		t.Errorf("expected %s, got %s", want, log.statements[0])
+	}
+}
\ No newline at end of file
@@ -1,110 +1,466 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// imageExtensions maps the image types uploads may be configured to accept to
+// the extension their files are saved with.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/avif": ".avif",
+}
+
+// multipartOverhead allows for the multipart boundaries and headers around the
+// file when comparing a request's size with the maximum file size.
+const multipartOverhead = 64 << 10
+
 type UploadHandler struct {
-	UploadDir string
+	DB           *gorm.DB
+	Config       *config.Config
+	UploadDir    string
+	AllowedTypes map[string]bool
+	MaxFileSize  int64
+	
+	// Uploads are capped per user
+	minuteLimiter *utils.RateLimiter
+	dailyLimiter  *utils.RateLimiter
+	
+	// JPEG and PNG uploads also get a WebP variant when webpEncoder is set
+	webpEncoder string
+	webpQuality int
 }
 
-func NewUploadHandler(uploadDir string) *UploadHandler {
+func NewUploadHandler(db *gorm.DB, cfg *config.Config) *UploadHandler {
 	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+	if err := os.MkdirAll(cfg.UploadDir, 0755); err != nil {
 		panic(fmt.Sprintf("Failed to create upload directory: %v", err))
 	}
 	
-	return &UploadHandler{UploadDir: uploadDir}
+	allowed := make(map[string]bool)
+	for _, mimeType := range cfg.UploadTypes {
+		mimeType = strings.ToLower(mimeType)
+		if _, ok := imageExtensions[mimeType]; !ok {
+			log.Printf("Ignoring unsupported upload type %q", mimeType)
+			continue
+		}
+		allowed[mimeType] = true
+	}
+	
+	// WebP variants need the cwebp encoder; without it uploads still work
+	webpEncoder := ""
+	if cfg.UploadWebP {
+		path, err := exec.LookPath(cfg.WebPEncoder)
+		if err != nil {
+			log.Printf("WebP variants disabled, encoder %q not found: %v", cfg.WebPEncoder, err)
+		}
+		webpEncoder = path
+	}
+	
+	return &UploadHandler{
+		DB:            db,
+		Config:        cfg,
+		UploadDir:     cfg.UploadDir,
+		AllowedTypes:  allowed,
+		MaxFileSize:   int64(cfg.MaxUploadMB) << 20,
+		minuteLimiter: utils.NewRateLimiter(cfg.UploadsPerMinute, time.Minute),
+		dailyLimiter:  utils.NewRateLimiter(cfg.UploadsPerDay, 24*time.Hour),
+		webpEncoder:   webpEncoder,
+		webpQuality:   cfg.WebPQuality,
+	}
 }
 
+// db returns the handler's database bound to the request context.
+func (h *UploadHandler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+// UploadImage streams the "image" form field to a temporary file in the upload
+// directory instead of buffering the multipart form. The file only gets its
+// final name once it has been validated, so a failed or half-finished upload
+// is never served.
 func (h *UploadHandler) UploadImage(c *gin.Context) {
-	file, header, err := c.Request.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
-	defer file.Close()
 	
-	// Validate file type
-	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+	tmpName, size, fileType, ok := h.receiveImage(c, userID.(string))
+	if !ok {
 		return
 	}
 	
-	fileType := http.DetectContentType(buffer)
-	if fileType != "image/jpeg" && fileType != "image/png" && fileType != "image/gif" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, and GIF images are allowed"})
+	// Generate unique filename. The extension follows the detected content so
+	// the file is always served with an image content type.
+	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), imageExtensions[fileType])
+	if err := os.Rename(tmpName, filepath.Join(h.UploadDir, filename)); err != nil {
+		os.Remove(tmpName)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
 	
-	// Reset file pointer
-	_, err = file.Seek(0, 0)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process file"})
+	// A failed conversion only costs the client the smaller variant
+	var webpFilename *string
+	if h.webpEncoder != "" && webpSourceTypes[fileType] {
+		variant, err := h.webpVariant(c.Request.Context(), filename, size)
+		if err != nil {
+			log.Printf("Failed to create WebP variant of %s: %v", filename, err)
+		} else if variant != "" {
+			webpFilename = &variant
+		}
+	}
+	
+	// Attribute the file to its uploader, so it can be cleaned up with the account
+	upload := models.Upload{
+		UserID:       userID.(string),
+		Filename:     filename,
+		WebPFilename: webpFilename,
+		MimeType:     fileType,
+		Size:         size,
+	}
+	if err := h.db(c).Create(&upload).Error; err != nil {
+		removeUploadFiles(h.UploadDir, []models.Upload{upload})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
 	
-	// Generate unique filename
-	ext := filepath.Ext(header.Filename)
-	if ext == "" {
-		// Determine extension from content type
-		switch fileType {
-		case "image/jpeg":
-			ext = ".jpg"
-		case "image/png":
-			ext = ".png"
-		case "image/gif":
-			ext = ".gif"
-		}
+	// Return the file URL (you might want to use a CDN URL in production)
+	fileURL := fmt.Sprintf("/uploads/%s", filename)
+	
+	response := gin.H{
+		"url":       fileURL,
+		"filename":  filename,
+		"file_size": size,
+		"mime_type": fileType,
+	}
+	if webpFilename != nil {
+		response["webp_url"] = fmt.Sprintf("/uploads/%s", *webpFilename)
+	}
+	
+	c.JSON(http.StatusOK, response)
+}
+
+// receiveImage checks the caller's upload limits, then streams the "image"
+// form field to a temporary file in the upload directory and checks
+// its size and type. When it returns false the error response has been sent;
+// otherwise the caller must rename or remove the temporary file.
+func (h *UploadHandler) receiveImage(c *gin.Context, userID string) (tmpName string, size int64, fileType string, ok bool) {
+	// Turn away oversized requests before reading any of the body. Requests
+	// without a Content-Length are cut off while streaming instead.
+	if c.Request.ContentLength > h.MaxFileSize+multipartOverhead {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": h.tooLargeMessage()})
+		return "", 0, "", false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxFileSize+multipartOverhead)
+	
+	if !h.minuteLimiter.Allow(userID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "You are uploading too quickly, please wait a moment"})
+		return "", 0, "", false
+	}
+	if !h.dailyLimiter.Allow(userID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Daily upload limit reached, please try again tomorrow"})
+		return "", 0, "", false
 	}
 	
-	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-	filepath := filepath.Join(h.UploadDir, filename)
+	part, err := imagePart(c.Request)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": h.tooLargeMessage()})
+		return "", 0, "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+		return "", 0, "", false
+	}
+	defer part.Close()
 	
-	// Create the file
-	out, err := os.Create(filepath)
+	tmp, err := os.CreateTemp(h.UploadDir, ".upload-*.tmp")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-		return
+		return "", 0, "", false
 	}
-	defer out.Close()
+	defer func() {
+		tmp.Close()
+		if !ok {
+			os.Remove(tmp.Name())
+		}
+	}()
 	
-	// Copy the file content
-	_, err = io.Copy(out, file)
+	// Read one byte past the limit to tell a file of exactly MaxFileSize from a
+	// larger one
+	size, err = io.Copy(tmp, io.LimitReader(part, h.MaxFileSize+1))
+	if errors.As(err, &maxBytesErr) || size > h.MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": h.tooLargeMessage()})
+		return "", 0, "", false
+	}
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return "", 0, "", false
+	}
+	
+	// Validate file type
+	buffer := make([]byte, 512)
+	n, err := tmp.ReadAt(buffer, 0)
+	if err != nil && err != io.EOF {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process file"})
+		return "", 0, "", false
+	}
+	
+	fileType = detectImageType(buffer[:n])
+	if !h.AllowedTypes[fileType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported image type, allowed types: " + h.allowedTypeList()})
+		return "", 0, "", false
+	}
+	
+	// CreateTemp makes the file private; uploads are meant to be readable
+	if err := tmp.Chmod(0644); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-		return
+		return "", 0, "", false
+	}
+	if err := tmp.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return "", 0, "", false
 	}
 	
-	// Return the file URL (you might want to use a CDN URL in production)
-	fileURL := fmt.Sprintf("/uploads/%s", filename)
+	return tmp.Name(), size, fileType, true
+}
+
+// imagePart advances a multipart request to its "image" file field.
+func imagePart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"url":       fileURL,
-		"filename":  filename,
-		"file_size": header.Size,
-		"mime_type": fileType,
-	})
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "image" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
 }
 
+// ServeUploads serves an uploaded file. http.ServeContent answers Range
+// requests with 206 Partial Content and conditional requests with 304, so
+// large images can be previewed and resumed without fetching them whole.
 func (h *UploadHandler) ServeUploads(c *gin.Context) {
 	filename := c.Param("filename")
-	filepath := filepath.Join(h.UploadDir, filename)
 	
 	// Security check to prevent directory traversal
-	if filepath != filepath.Clean(filepath) || filepath != filepath.Join(h.UploadDir, filename) {
+	if filepath.Base(filename) != filename || filename == "." || filename == ".." {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
 		return
 	}
 	
-	c.File(filepath)
+	// Dot files are uploads still being written
+	if strings.HasPrefix(filename, ".") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	
+	file, err := os.Open(filepath.Join(h.UploadDir, filename))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer file.Close()
+	
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, filename, info.ModTime(), file)
+}
+
+// tooLargeMessage is the error returned for uploads over the size limit.
+func (h *UploadHandler) tooLargeMessage() string {
+	return fmt.Sprintf("File is too large, the maximum size is %d MB", h.MaxFileSize>>20)
+}
+
+// allowedTypeList returns the accepted image types for error messages.
+func (h *UploadHandler) allowedTypeList() string {
+	types := make([]string, 0, len(h.AllowedTypes))
+	for mimeType := range h.AllowedTypes {
+		types = append(types, mimeType)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
+
+// detectImageType sniffs the content type of an upload. AVIF isn't known to
+// http.DetectContentType, so its ftyp box is checked by hand.
+func detectImageType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		if brand := string(data[8:12]); brand == "avif" || brand == "avis" {
+			return "image/avif"
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// isUploadURL reports whether an image URL points at a file we serve from the
+// upload directory, either as a relative /uploads/ path or on UPLOAD_BASE_URL.
+func isUploadURL(cfg *config.Config, url string) bool {
+	path := url
+	if cfg.UploadBaseURL != "" {
+		path = strings.TrimPrefix(url, strings.TrimRight(cfg.UploadBaseURL, "/"))
+	}
+	
+	filename := strings.TrimPrefix(path, "/uploads/")
+	if filename == path || filename == "" {
+		return false
+	}
+	
+	// Reject nested paths and traversal attempts
+	return filepath.Base(filename) == filename && filename != ".."
+}
+
+// uploadedFilename returns the name of the file an upload URL points at, or ""
+// for other URLs.
+func uploadedFilename(cfg *config.Config, url string) string {
+	if !isUploadURL(cfg, url) {
+		return ""
+	}
+	return url[strings.LastIndexByte(url, '/')+1:]
+}
+
+// uploaders maps image URLs pointing at uploaded files to the ID of the user who
+// uploaded them. URLs of files without an upload record are left out.
+func uploaders(db *gorm.DB, cfg *config.Config, urls []string) (map[string]string, error) {
+	byFilename := make(map[string][]string)
+	for _, url := range urls {
+		if filename := uploadedFilename(cfg, url); filename != "" {
+			byFilename[filename] = append(byFilename[filename], url)
+		}
+	}
+	
+	owners := make(map[string]string)
+	if len(byFilename) == 0 {
+		return owners, nil
+	}
+	
+	filenames := make([]string, 0, len(byFilename))
+	for filename := range byFilename {
+		filenames = append(filenames, filename)
+	}
+	// URLs may point at the original file or at its WebP variant
+	var uploads []models.Upload
+	if err := db.Where("filename IN ? OR webp_filename IN ?", filenames, filenames).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	for _, upload := range uploads {
+		urls := byFilename[upload.Filename]
+		if upload.WebPFilename != nil {
+			urls = append(urls, byFilename[*upload.WebPFilename]...)
+		}
+		for _, url := range urls {
+			owners[url] = upload.UserID
+		}
+	}
+	return owners, nil
+}
+
+// attributeRecipeImages sets UploadedBy on images from the upload records of
+// their files, overriding whatever the client sent.
+func attributeRecipeImages(db *gorm.DB, cfg *config.Config, images []models.RecipeImage) error {
+	urls := make([]string, len(images))
+	for i, image := range images {
+		urls[i] = image.ImageURL
+	}
+	owners, err := uploaders(db, cfg, urls)
+	if err != nil {
+		return err
+	}
+	
+	for i := range images {
+		images[i].UploadedBy = nil
+		if owner, ok := owners[images[i].ImageURL]; ok {
+			images[i].UploadedBy = &owner
+		}
+	}
+	return nil
+}
+
+// uploadReference is a column that can point at an uploaded file. Rows only
+// keep the file alive while condition holds.
+type uploadReference struct {
+	from      string
+	condition string
+	column    string
+}
+
+// uploadReferences lists every column that can hold an upload URL, so cleanups
+// don't delete files that are still shown somewhere.
+var uploadReferences = []uploadReference{
+	{"recipes", "recipes.deleted_at IS NULL", "recipes.featured_image_url"},
+	{"recipe_images JOIN recipes ON recipes.id = recipe_images.recipe_id", "recipes.deleted_at IS NULL", "recipe_images.image_url"},
+	{"steps JOIN recipes ON recipes.id = steps.recipe_id", "recipes.deleted_at IS NULL", "steps.image_url"},
+	{"mades", "mades.deleted_at IS NULL", "mades.photo_url"},
+	{"users", "users.deleted_at IS NULL AND users.id <> uploads.user_id", "users.avatar_url"},
+	{"categories", "", "categories.image_url"},
+}
+
+// orphanedUploads lists a user's uploads that no longer appear in any of the
+// uploadReferences, neither as the original file nor as its WebP variant.
+func orphanedUploads(tx *gorm.DB, userID string) ([]models.Upload, error) {
+	query := tx.Where("uploads.user_id = ?", userID)
+	for _, ref := range uploadReferences {
+		where := "(" + ref.column + " LIKE '%/uploads/' || uploads.filename OR " +
+			ref.column + " LIKE '%/uploads/' || uploads.webp_filename)"
+		if ref.condition != "" {
+			where = ref.condition + " AND " + where
+		}
+		query = query.Where("NOT EXISTS (SELECT 1 FROM " + ref.from + " WHERE " + where + ")")
+	}
+	
+	var uploads []models.Upload
+	err := query.Find(&uploads).Error
+	return uploads, err
+}
+
+// removeUploadFiles deletes the files of uploads, including their WebP
+// variants, whose records were removed. Failures are only logged since the
+// records are already gone.
+func removeUploadFiles(dir string, uploads []models.Upload) {
+	for _, upload := range uploads {
+		filenames := []string{upload.Filename}
+		if upload.WebPFilename != nil {
+			filenames = append(filenames, *upload.WebPFilename)
+		}
+		for _, filename := range filenames {
+			if err := os.Remove(filepath.Join(dir, filename)); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove upload %s: %v", filename, err)
+			}
+		}
+	}
 }
\ No newline at end of file
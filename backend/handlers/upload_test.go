@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/middleware"
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statementLog records the SQL of every statement a dry run session builds.
+type statementLog struct {
+	logger.Interface
+	statements []string
+}
+
+func (l *statementLog) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	l.statements = append(l.statements, sql)
+}
+
+// dryRun returns a session that builds statements without a database and logs
+// them.
+func dryRun(t *testing.T) (*gorm.DB, *statementLog) {
+	t.Helper()
+	
+	log := &statementLog{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, log
+}
+
+func TestOrphanedUploadsChecksEveryReference(t *testing.T) {
+	db, log := dryRun(t)
+	if _, err := orphanedUploads(db, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.statements) != 1 {
+		t.Fatalf("expected one query, got %q", log.statements)
+	}
+	
+	for _, column := range []string{"recipes.featured_image_url", "recipe_images.image_url", "steps.image_url",
+		"mades.photo_url", "users.avatar_url", "categories.image_url"} {
+		for _, filename := range []string{"uploads.filename", "uploads.webp_filename"} {
+			if !strings.Contains(log.statements[0], column+" LIKE '%/uploads/' || "+filename) {
+				t.Errorf("orphaned uploads don't match %s against %s: %s", column, filename, log.statements[0])
+			}
+		}
+	}
+}
+
+func TestUploadersLooksUpWebPVariants(t *testing.T) {
+	db, log := dryRun(t)
+	if _, err := uploaders(db, testConfig(), []string{"/uploads/photo.webp"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.statements) != 1 || !strings.Contains(log.statements[0], "webp_filename IN ('photo.webp')") {
+		t.Errorf("expected the lookup to match WebP variants, got %q", log.statements)
+	}
+}
+
+// Smallest headers content sniffing recognizes for each image type.
+var (
+	pngHeader  = []byte("\x89PNG\r\n\x1a\n")
+	webpHeader = []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+)
+
+// newTestUploadHandler returns an upload handler saving into a temporary
+// directory. edit may adjust the configuration first.
+func newTestUploadHandler(t *testing.T, db *gorm.DB, edit func(*config.Config)) *UploadHandler {
+	t.Helper()
+	
+	cfg := testConfig()
+	cfg.UploadDir = t.TempDir()
+	if edit != nil {
+		edit(cfg)
+	}
+	return NewUploadHandler(db, cfg)
+}
+
+// uploadRequest builds a multipart request sending data as the "image" field.
+func uploadRequest(t *testing.T, data []byte) *http.Request {
+	t.Helper()
+	
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// uploadedFiles lists the files left in the upload directory.
+func uploadedFiles(t *testing.T, h *UploadHandler) []string {
+	t.Helper()
+	
+	entries, err := os.ReadDir(h.UploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+func TestUploadTypesAreConfigurable(t *testing.T) {
+	defaults := newTestUploadHandler(t, nil, nil)
+	withWebP := newTestUploadHandler(t, nil, func(cfg *config.Config) {
+		cfg.UploadTypes = []string{"image/png", "IMAGE/WEBP", "image/bmp"}
+	})
+	if !reflect.DeepEqual(withWebP.AllowedTypes, map[string]bool{"image/png": true, "image/webp": true}) {
+		t.Errorf("expected unsupported types to be ignored, got %v", withWebP.AllowedTypes)
+	}
+	
+	// A rejected type is answered before the database is used
+	w := serveRequest(defaults.UploadImage, "/upload", uploadRequest(t, webpHeader), "user-1")
+	expectStatus(t, w, http.StatusBadRequest)
+	if !strings.Contains(w.Body.String(), "image/gif, image/jpeg, image/png") {
+		t.Errorf("expected the allowed types in the error, got %s", w.Body)
+	}
+	
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = uploadRequest(t, webpHeader)
+	tmpName, _, fileType, ok := withWebP.receiveImage(c, "user-1")
+	if !ok || fileType != "image/webp" {
+		t.Fatalf("expected the WebP to be accepted, got %q", fileType)
+	}
+	os.Remove(tmpName)
+}
+
+func TestFailedUploadsLeaveNoFiles(t *testing.T) {
+	h := newTestUploadHandler(t, nil, func(cfg *config.Config) { cfg.MaxUploadMB = 1 })
+	
+	tests := []struct {
+		name   string
+		data   []byte
+		status int
+	}{
+		{"not an image", []byte("just some text"), http.StatusBadRequest},
+		{"too large", append(pngHeader, make([]byte, 1<<20)...), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := serveRequest(h.UploadImage, "/upload", uploadRequest(t, tt.data), "user-1")
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body)
+		}
+		if files := uploadedFiles(t, h); len(files) != 0 {
+			t.Errorf("%s: expected no files to be left behind, got %v", tt.name, files)
+		}
+	}
+}
+
+func TestUploadsAreNotServedUntilComplete(t *testing.T) {
+	h := newTestUploadHandler(t, nil, nil)
+	if err := os.WriteFile(filepath.Join(h.UploadDir, ".upload-1.tmp"), pngHeader, 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	w := serve(h.ServeUploads, "GET", "/uploads/:filename", "/uploads/.upload-1.tmp", "", nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestUploadsAreRateLimited(t *testing.T) {
+	// Every upload here is rejected as text, so the database isn't used
+	tests := []struct {
+		name              string
+		perMinute, perDay int
+	}{
+		{"per minute", 2, 100},
+		{"per day", 100, 2},
+	}
+	for _, tt := range tests {
+		h := newTestUploadHandler(t, nil, func(cfg *config.Config) {
+			cfg.UploadsPerMinute = tt.perMinute
+			cfg.UploadsPerDay = tt.perDay
+		})
+		upload := func(userID string) int {
+			return serveRequest(h.UploadImage, "/upload", uploadRequest(t, []byte("just some text")), userID).Code
+		}
+		
+		for i := 0; i < 2; i++ {
+			if code := upload("user-1"); code != http.StatusBadRequest {
+				t.Fatalf("%s: expected upload %d to get through the limit, got %d", tt.name, i+1, code)
+			}
+		}
+		if code := upload("user-1"); code != http.StatusTooManyRequests {
+			t.Errorf("%s: expected 429 past the cap, got %d", tt.name, code)
+		}
+		if code := upload("user-2"); code != http.StatusBadRequest {
+			t.Errorf("%s: expected other users to be unaffected, got %d", tt.name, code)
+		}
+	}
+}
+
+func TestUnauthenticatedUploadsAreRejected(t *testing.T) {
+	// Requests without a valid token are turned away before the database is used
+	h := newTestUploadHandler(t, nil, nil)
+	protected := chain(middleware.AuthMiddleware(nil), h.UploadImage)
+	
+	for _, authorization := range []string{"", "Bearer not-a-token"} {
+		req := uploadRequest(t, pngHeader)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := serveRequest(protected, "/upload", req, "")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%q: expected 401, got %d", authorization, w.Code)
+		}
+	}
+	
+	w := serveRequest(h.UploadImage, "/upload", uploadRequest(t, pngHeader), "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	if files := uploadedFiles(t, h); len(files) != 0 {
+		t.Errorf("expected nothing to be saved, got %v", files)
+	}
+}
+
+func TestUploadsAreAttributedAndCleanedUpWithTheAccount(t *testing.T) {
+	db := testDB(t)
+	cfg := testConfig()
+	cfg.UploadDir = t.TempDir()
+	uploads := NewUploadHandler(db, cfg)
+	recipes := NewRecipeHandler(db, cfg)
+	auth := NewAuthHandler(db, cfg)
+	uploader := createUser(t, db)
+	setPassword(t, db, &uploader, "correct horse")
+	fan := createUser(t, db)
+	
+	upload := func() string {
+		t.Helper()
+		w := serveRequest(uploads.UploadImage, "/upload", uploadRequest(t, pngHeader), uploader.ID)
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			URL string `json:"url"`
+		}
+		decode(t, w, &response)
+		return response.URL
+	}
+	shared := upload()
+	unused := upload()
+	
+	var record models.Upload
+	if err := db.First(&record, "filename = ?", uploadedFilename(cfg, unused)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if record.UserID != uploader.ID {
+		t.Errorf("expected the upload to be attributed to its uploader, got %q", record.UserID)
+	}
+	
+	// The uploader is recorded on recipe images, whatever the client claims
+	body := recipeRequest(createCategory(t, db).ID)
+	body["images"] = []gin.H{{"image_url": shared, "uploaded_by": fan.ID}, {"image_url": "https://example.com/a.jpg"}}
+	w := serve(recipes.CreateRecipe, "POST", "/recipes", "/recipes", uploader.ID, body)
+	expectStatus(t, w, http.StatusCreated)
+	var images []models.RecipeImage
+	db.Order("image_url").Find(&images)
+	// Relative upload URLs sort before the external one
+	if len(images) != 2 || images[0].UploadedBy == nil || *images[0].UploadedBy != uploader.ID || images[1].UploadedBy != nil {
+		t.Errorf("expected only the uploaded image to be attributed to the uploader, got %+v", images)
+	}
+	
+	// Another user's avatar keeps the shared file alive
+	if err := db.Model(&fan).Update("avatar_url", shared).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	w = serve(auth.DeleteAccount, "DELETE", "/auth/account", "/auth/account", uploader.ID, gin.H{"password": "correct horse"})
+	expectStatus(t, w, http.StatusOK)
+	
+	var left []string
+	db.Model(&models.Upload{}).Order("filename").Pluck("filename", &left)
+	want := []string{uploadedFilename(cfg, shared)}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("expected only the shared upload to be kept, got %v", left)
+	}
+	if files := uploadedFiles(t, uploads); !reflect.DeepEqual(files, want) {
+		t.Errorf("expected only the shared file to be kept, got %v", files)
+	}
+}
+
+// endlessBody fails the test if the handler reads any of it.
+type endlessBody struct {
+	t *testing.T
+}
+
+func (b endlessBody) Read(p []byte) (int, error) {
+	b.t.Error("expected the body not to be read")
+	return 0, io.EOF
+}
+
+func TestOversizedUploadsAreRejectedBeforeReading(t *testing.T) {
+	h := newTestUploadHandler(t, nil, func(cfg *config.Config) {
+		cfg.MaxUploadMB = 1
+		cfg.UploadsPerMinute = 1
+	})
+	
+	req := httptest.NewRequest("POST", "/upload", endlessBody{t})
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+	req.ContentLength = h.MaxFileSize + multipartOverhead + 1
+	
+	w := serveRequest(h.UploadImage, "/upload", req, "user-1")
+	expectStatus(t, w, http.StatusRequestEntityTooLarge)
+	if files := uploadedFiles(t, h); len(files) != 0 {
+		t.Errorf("expected nothing to be saved, got %v", files)
+	}
+	
+	// The early rejection doesn't count against the upload limits
+	if !h.minuteLimiter.Allow("user-1") {
+		t.Error("expected the rejected upload not to use up the rate limit")
+	}
+}
+
+func TestUploadsAnswerRangeRequests(t *testing.T) {
+	h := newTestUploadHandler(t, nil, nil)
+	data := append(pngHeader, []byte("0123456789abcdefghij")...)
+	if err := os.WriteFile(filepath.Join(h.UploadDir, "photo.png"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	get := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/uploads/photo.png", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		return serveRequest(h.ServeUploads, "/uploads/:filename", req, "")
+	}
+	
+	w := get("")
+	expectStatus(t, w, http.StatusOK)
+	if w.Header().Get("Accept-Ranges") != "bytes" || !bytes.Equal(w.Body.Bytes(), data) {
+		t.Errorf("expected the whole file with Accept-Ranges, got %q and %d bytes", w.Header().Get("Accept-Ranges"), w.Body.Len())
+	}
+	
+	tests := []struct {
+		rangeHeader  string
+		body         []byte
+		contentRange string
+	}{
+		{"bytes=8-11", data[8:12], fmt.Sprintf("bytes 8-11/%d", len(data))},
+		{"bytes=-5", data[len(data)-5:], fmt.Sprintf("bytes %d-%d/%d", len(data)-5, len(data)-1, len(data))},
+		{"bytes=20-", data[20:], fmt.Sprintf("bytes 20-%d/%d", len(data)-1, len(data))},
+	}
+	for _, tt := range tests {
+		w := get(tt.rangeHeader)
+		expectStatus(t, w, http.StatusPartialContent)
+		if !bytes.Equal(w.Body.Bytes(), tt.body) {
+			t.Errorf("%s: expected %q, got %q", tt.rangeHeader, tt.body, w.Body.Bytes())
+		}
+		if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+			t.Errorf("%s: expected Content-Range %q, got %q", tt.rangeHeader, tt.contentRange, got)
+		}
+	}
+	
+	w = get(fmt.Sprintf("bytes=%d-", len(data)+10))
+	expectStatus(t, w, http.StatusRequestedRangeNotSatisfiable)
+}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report validation errors using the JSON/form field names clients send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+		v.RegisterValidation("allergen", func(fl validator.FieldLevel) bool {
+			return models.IsAllergen(fl.Field().String())
+		})
+		v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+			return strings.TrimSpace(fl.Field().String()) != ""
+		})
+	}
+}
+
+// bindingErrorResponse turns a binding error into a response body. Validation
+// failures are mapped to readable per-field messages, e.g.
+// {"password": "must be at least 6 characters"}. Fields of nested items are
+// keyed by their path, e.g. {"ingredients[2].name": "is required"}.
+func bindingErrorResponse(err error) gin.H {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return gin.H{"error": "Invalid request body"}
+	}
+	
+	fields := make(map[string]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields[fieldPath(fe)] = validationMessage(fe)
+	}
+	
+	return gin.H{"error": "Validation failed", "fields": fields}
+}
+
+// fieldPath returns the path of a failed field below the bound struct, so
+// "createRecipeRequest.ingredients[2].name" becomes "ingredients[2].name".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func validationMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "allergen":
+		return "must be one of: " + strings.Join(models.Allergens, ", ")
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	}
+	
+	return "is invalid"
+}
\ No newline at end of file
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	
+	"food-recipes-backend/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestSignupReportsReadableFieldErrors(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewAuthHandler(nil, testConfig())
+	
+	w := serve(h.Signup, "POST", "/auth/signup", "/auth/signup", "", gin.H{"username": "anna", "password": "123"})
+	expectStatus(t, w, http.StatusBadRequest)
+	
+	var body struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}
+	decode(t, w, &body)
+	want := map[string]string{
+		"email":    "is required",
+		"password": "must be at least 6 characters",
+	}
+	if body.Error != "Validation failed" || len(body.Fields) != len(want) {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	for field, message := range want {
+		if body.Fields[field] != message {
+			t.Errorf("%s: expected %q, got %q", field, message, body.Fields[field])
+		}
+	}
+}
+
+func TestBlankIngredientsAndStepsAreReportedByIndex(t *testing.T) {
+	// Invalid requests are answered before the database is used
+	h := NewRecipeHandler(nil, testConfig())
+	
+	body := recipeRequest("category-1")
+	body["ingredients"] = []gin.H{{"name": "salt"}, {"name": "pepper"}, {"name": "  ", "quantity": "1 tsp"}}
+	body["steps"] = []gin.H{{}, {"instruction": "Season"}}
+	w := serve(h.CreateRecipe, "POST", "/recipes", "/recipes", "user-1", body)
+	expectStatus(t, w, http.StatusBadRequest)
+	
+	var response struct {
+		Fields map[string]string `json:"fields"`
+	}
+	decode(t, w, &response)
+	want := map[string]string{
+		"ingredients[2].name":  "is required",
+		"steps[0].instruction": "is required",
+	}
+	if len(response.Fields) != len(want) {
+		t.Fatalf("expected %v, got %v", want, response.Fields)
+	}
+	for field, message := range want {
+		if response.Fields[field] != message {
+			t.Errorf("%s: expected %q, got %q", field, message, response.Fields[field])
+		}
+	}
+}
+
+func TestUpdatesWithABlankIngredientAreRejected(t *testing.T) {
+	db := testDB(t)
+	h := NewRecipeHandler(db, testConfig())
+	author := createUser(t, db)
+	recipe := createRecipe(t, db, author, createCategory(t, db), func(r *models.Recipe) {
+		r.Ingredients = []models.Ingredient{{Name: "potatoes", Quantity: "1 kg"}}
+	})
+	
+	w := serve(h.UpdateRecipe, "PUT", "/recipes/:id", "/recipes/"+recipe.ID, author.ID,
+		gin.H{"ingredients": []gin.H{{"name": "potatoes"}, {"name": ""}}})
+	expectStatus(t, w, http.StatusBadRequest)
+	var response struct {
+		Fields map[string]string `json:"fields"`
+	}
+	decode(t, w, &response)
+	if response.Fields["ingredients[1].name"] != "is required" {
+		t.Errorf("expected the blank ingredient to be reported, got %v", response.Fields)
+	}
+	
+	var ingredients []models.Ingredient
+	db.Where("recipe_id = ?", recipe.ID).Find(&ingredients)
+	if len(ingredients) != 1 || ingredients[0].Name != "potatoes" {
+		t.Errorf("expected the ingredients to be untouched, got %+v", ingredients)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// webpSourceTypes are the upload types that get a WebP variant. GIFs are left
+// alone since cwebp would drop their animation.
+var webpSourceTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// webpVariant converts an uploaded file with the cwebp encoder and returns the
+// name of the WebP file next to it. The variant is discarded, and "" returned,
+// if it isn't smaller than the original.
+func (h *UploadHandler) webpVariant(ctx context.Context, filename string, size int64) (string, error) {
+	src := filepath.Join(h.UploadDir, filename)
+	variant := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".webp"
+	dst := filepath.Join(h.UploadDir, variant)
+	
+	cmd := exec.CommandContext(ctx, h.webpEncoder, "-quiet", "-q", strconv.Itoa(h.webpQuality), src, "-o", dst)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(dst)
+		return "", fmt.Errorf("cwebp: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	
+	info, err := os.Stat(dst)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() >= size {
+		os.Remove(dst)
+		return "", nil
+	}
+	
+	if err := os.Chmod(dst, 0644); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	return variant, nil
+}
\ No newline at end of file
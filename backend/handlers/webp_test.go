@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	
+	"food-recipes-backend/config"
+)
+
+// fakeEncoder writes a shell script standing in for cwebp, called as
+// "-quiet -q QUALITY SRC -o DST", that runs script with those arguments.
+func fakeEncoder(t *testing.T, script string) string {
+	t.Helper()
+	
+	path := filepath.Join(t.TempDir(), "cwebp")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// webpTestHandler returns an upload handler converting with encoder, and the
+// name of the file holding data in its upload directory.
+func webpTestHandler(t *testing.T, encoder string, data []byte) (*UploadHandler, string) {
+	t.Helper()
+	
+	h := newTestUploadHandler(t, nil, func(cfg *config.Config) {
+		cfg.UploadWebP = true
+		cfg.WebPEncoder = encoder
+	})
+	if err := os.WriteFile(filepath.Join(h.UploadDir, "photo.png"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return h, "photo.png"
+}
+
+func TestWebPVariants(t *testing.T) {
+	original := append(pngHeader, make([]byte, 100)...)
+	
+	tests := []struct {
+		name    string
+		script  string
+		variant string
+		fails   bool
+	}{
+		{"smaller", `head -c 10 "$4" > "$6"`, "photo.webp", false},
+		{"not smaller", `cat "$4" "$4" > "$6"`, "", false},
+		{"encoder error", `echo "bad input" >&2; touch "$6"; exit 1`, "", true},
+	}
+	for _, tt := range tests {
+		h, filename := webpTestHandler(t, fakeEncoder(t, tt.script), original)
+		
+		variant, err := h.webpVariant(context.Background(), filename, int64(len(original)))
+		if (err != nil) != tt.fails || variant != tt.variant {
+			t.Errorf("%s: expected variant %q and failure %v, got %q and %v", tt.name, tt.variant, tt.fails, variant, err)
+		}
+		want := []string{filename}
+		if tt.variant != "" {
+			want = append(want, tt.variant)
+		}
+		if files := uploadedFiles(t, h); len(files) != len(want) {
+			t.Errorf("%s: expected files %v, got %v", tt.name, want, files)
+		}
+	}
+	
+	if webpSourceTypes["image/gif"] || !webpSourceTypes["image/jpeg"] || !webpSourceTypes["image/png"] {
+		t.Errorf("expected JPEG and PNG but not GIF uploads to get a variant, got %v", webpSourceTypes)
+	}
+}
+
+func TestWebPVariantIsSmallerThanThePNG(t *testing.T) {
+	encoder, err := exec.LookPath("cwebp")
+	if err != nil {
+		t.Skip("cwebp is not installed")
+	}
+	
+	// A noisy gradient, which PNG compresses far worse than lossy WebP
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	noise := rand.New(rand.NewSource(1))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(noise.Intn(64)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	
+	h, filename := webpTestHandler(t, encoder, buf.Bytes())
+	variant, err := h.webpVariant(context.Background(), filename, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variant == "" {
+		t.Fatal("expected a WebP variant")
+	}
+	info, err := os.Stat(filepath.Join(h.UploadDir, variant))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() >= int64(buf.Len()) {
+		t.Errorf("expected the WebP variant to be smaller than %d bytes, got %d", buf.Len(), info.Size())
+	}
+}
\ No newline at end of file
@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	
 	"food-recipes-backend/config"
 	"food-recipes-backend/handlers"
+	"food-recipes-backend/metrics"
 	"food-recipes-backend/middleware"
+	"food-recipes-backend/migrations"
 	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func main() {
@@ -23,6 +32,13 @@ func main() {
 	
 	cfg := config.Load()
 	
+	if err := utils.SetBcryptCost(cfg.BcryptCost); err != nil {
+		log.Fatal("Invalid BCRYPT_COST:", err)
+	}
+	if err := utils.SetJWTExpiry(time.Duration(cfg.JWTExpiryHours) * time.Hour); err != nil {
+		log.Fatal("Invalid JWT_EXPIRY_HOURS:", err)
+	}
+	
 	// Initialize database
 	dsn := cfg.DatabaseURL
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -30,32 +46,48 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	
-	// Auto migrate tables
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Category{},
-		&models.Recipe{},
-		&models.Ingredient{},
-		&models.Step{},
-		&models.RecipeImage{},
-		&models.Like{},
-		&models.Bookmark{},
-		&models.Comment{},
-		&models.Rating{},
-		&models.Purchase{},
-	); err != nil {
+	if err := migrations.EnsureExtensions(db); err != nil {
+		log.Fatal("Failed to prepare database:", err)
+	}
+	
+	// AutoMigrate is only meant for local development. The versioned migrations
+	// still run after it so those databases get the same triggers, constraints
+	// and backfills as production.
+	if cfg.AutoMigrate {
+		autoMigrate(db)
+	}
+	if err := migrations.Run(db); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 	
-	// Create default categories
-	createDefaultCategories(db)
+	// Background jobs
+	scheduler := utils.NewScheduler()
+	scheduler.Register("publish-scheduled-recipes", time.Duration(cfg.PublishInterval)*time.Second, func(ctx context.Context) error {
+		published, err := handlers.PublishDueRecipes(db.WithContext(ctx))
+		if published > 0 {
+			log.Printf("Published %d scheduled recipes", published)
+		}
+		return err
+	})
+	scheduler.Register("prune-search-logs", time.Hour, func(ctx context.Context) error {
+		pruned, err := handlers.PruneSearchLogs(db.WithContext(ctx))
+		if pruned > 0 {
+			log.Printf("Pruned %d old search logs", pruned)
+		}
+		return err
+	})
+	scheduler.Start()
 	
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db)
-	recipeHandler := handlers.NewRecipeHandler(db)
-	categoryHandler := handlers.NewCategoryHandler(db)
-	uploadHandler := handlers.NewUploadHandler(cfg.UploadDir)
+	authHandler := handlers.NewAuthHandler(db, cfg)
+	recipeHandler := handlers.NewRecipeHandler(db, cfg)
+	categoryHandler := handlers.NewCategoryHandler(db, cfg)
+	uploadHandler := handlers.NewUploadHandler(db, cfg)
 	paymentHandler := handlers.NewChapaPaymentHandler(db, cfg.ChapaSecretKey)
+	maintenance := middleware.NewMaintenance(cfg)
+	adminHandler := handlers.NewAdminHandler(db, cfg, maintenance)
+	searchHandler := handlers.NewSearchHandler(db, cfg)
+	statsHandler := handlers.NewStatsHandler(db, cfg)
 	
 	// Setup Gin router
 	router := gin.Default()
@@ -63,8 +95,9 @@ func main() {
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -74,52 +107,210 @@ func main() {
 		c.Next()
 	})
 	
+	// Record request counts and latencies for /metrics
+	router.Use(middleware.MetricsMiddleware())
+	
+	// Gzip larger API responses
+	router.Use(middleware.CompressionMiddleware(cfg.CompressMinBytes))
+	
+	// Cancel the database and HTTP calls of requests that run longer than the
+	// configured timeout
+	router.Use(middleware.TimeoutMiddleware(time.Duration(cfg.RequestTimeout) * time.Second))
+	
+	// Turn requests away while the API is down for maintenance
+	router.Use(maintenance.Middleware(db))
+	
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	
+	// Health check for load balancers and uptime monitoring
+	router.GET("/health", handlers.Health(db))
+	
 	// Serve uploaded files
-	router.Static("/uploads", cfg.UploadDir)
+	router.GET("/uploads/:filename", uploadHandler.ServeUploads)
+	router.HEAD("/uploads/:filename", uploadHandler.ServeUploads)
+	
+	// Feature flags
+	commentsEnabled := middleware.RequireFeature(cfg, config.FeatureComments)
+	ratingsEnabled := middleware.RequireFeature(cfg, config.FeatureRatings)
+	likesEnabled := middleware.RequireFeature(cfg, config.FeatureLikes)
+	bookmarksEnabled := middleware.RequireFeature(cfg, config.FeatureBookmarks)
+	paymentsEnabled := middleware.RequireFeature(cfg, config.FeaturePayments)
 	
 	// Public routes
 	public := router.Group("/api")
 	{
 		public.POST("/auth/signup", authHandler.Signup)
 		public.POST("/auth/login", authHandler.Login)
+		public.GET("/auth/username-available", authHandler.CheckUsernameAvailable)
+		public.GET("/search", searchHandler.Search)
+		public.GET("/search/trending", searchHandler.GetTrendingSearches)
+		public.GET("/stats", statsHandler.GetStats)
 		public.GET("/categories", categoryHandler.GetCategories)
-		public.GET("/categories/:id/recipes", categoryHandler.GetCategoryRecipes)
-		public.GET("/recipes", recipeHandler.GetRecipes)
-		public.GET("/recipes/:id", middleware.OptionalAuthMiddleware(), recipeHandler.GetRecipe)
-		public.POST("/upload", uploadHandler.UploadImage)
+		public.GET("/categories/recipes", middleware.OptionalAuthMiddleware(db), categoryHandler.GetRecipesByCategories)
+		public.GET("/categories/:id/recipes", middleware.OptionalAuthMiddleware(db), categoryHandler.GetCategoryRecipes)
+		public.GET("/recipes", middleware.OptionalAuthMiddleware(db), recipeHandler.GetRecipes)
+		public.GET("/recipes/ids", recipeHandler.GetRecipeIDs)
+		public.POST("/recipes/by-ingredients", middleware.OptionalAuthMiddleware(db), recipeHandler.GetRecipesByIngredients)
+		public.GET("/recipes/featured", middleware.OptionalAuthMiddleware(db), recipeHandler.GetFeaturedRecipes)
+		public.GET("/recipes/:id", middleware.OptionalAuthMiddleware(db), recipeHandler.GetRecipe)
+		public.GET("/recipes/by-slug/:slug", middleware.OptionalAuthMiddleware(db), recipeHandler.GetRecipeBySlug)
+		public.GET("/recipes/:id/comments", commentsEnabled, middleware.OptionalAuthMiddleware(db), recipeHandler.GetComments)
+		public.GET("/recipes/:id/likes", likesEnabled, middleware.OptionalAuthMiddleware(db), recipeHandler.GetRecipeLikes)
+		public.GET("/recipes/:id/also-bought", paymentsEnabled, middleware.OptionalAuthMiddleware(db), recipeHandler.GetAlsoBought)
+		public.GET("/recipes/:id/cook", middleware.OptionalAuthMiddleware(db), recipeHandler.GetCookMode)
+		public.GET("/recipes/:id/made", middleware.OptionalAuthMiddleware(db), recipeHandler.GetMadeGallery)
+		public.GET("/recipes/:id/nutrition-per-serving", middleware.OptionalAuthMiddleware(db), recipeHandler.GetNutritionPerServing)
 	}
 	
 	// Protected routes
 	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(db))
 	{
 		// User routes
 		protected.GET("/auth/profile", authHandler.GetProfile)
+		protected.DELETE("/auth/account", authHandler.DeleteAccount)
+		protected.POST("/auth/avatar", uploadHandler.UploadAvatar)
+		protected.GET("/me/activity", recipeHandler.GetMyActivity)
+		protected.GET("/me/recently-viewed", recipeHandler.GetRecentlyViewed)
+		
+		// Upload routes
+		protected.POST("/upload", uploadHandler.UploadImage)
 		
 		// Recipe routes
+		protected.GET("/recipes/mine", recipeHandler.GetMyRecipes)
 		protected.POST("/recipes", recipeHandler.CreateRecipe)
 		protected.PUT("/recipes/:id", recipeHandler.UpdateRecipe)
+		protected.PATCH("/recipes/:id", recipeHandler.UpdateRecipe)
+		protected.DELETE("/recipes", recipeHandler.BulkDeleteRecipes)
 		protected.DELETE("/recipes/:id", recipeHandler.DeleteRecipe)
-		protected.POST("/recipes/:id/like", recipeHandler.ToggleLike)
-		protected.POST("/recipes/:id/bookmark", recipeHandler.ToggleBookmark)
-		protected.POST("/recipes/:id/rating", recipeHandler.AddRating)
-		protected.POST("/recipes/:id/comment", recipeHandler.AddComment)
+		protected.PATCH("/recipes/:id/steps/reorder", recipeHandler.ReorderSteps)
+		protected.POST("/recipes/:id/restore", recipeHandler.RestoreRecipe)
+		protected.GET("/recipes/:id/me", recipeHandler.GetMyInteractions)
+		protected.GET("/recipes/:id/purchasers", paymentsEnabled, recipeHandler.GetRecipePurchasers)
+		protected.GET("/recipes/:id/analytics", recipeHandler.GetRecipeAnalytics)
+		protected.GET("/recipes/:id/checklist", recipeHandler.GetChecklist)
+		protected.POST("/recipes/:id/checklist", recipeHandler.SaveChecklist)
+		protected.POST("/recipes/:id/like", likesEnabled, recipeHandler.ToggleLike)
+		protected.POST("/recipes/:id/bookmark", bookmarksEnabled, recipeHandler.ToggleBookmark)
+		protected.GET("/bookmarks", bookmarksEnabled, recipeHandler.GetBookmarks)
+		protected.PUT("/bookmarks/:id", bookmarksEnabled, recipeHandler.SaveBookmark)
+		protected.POST("/recipes/:id/queue", recipeHandler.EnqueueRecipe)
+		protected.DELETE("/recipes/:id/queue", recipeHandler.DequeueRecipe)
+		protected.POST("/recipes/:id/queue/cooked", recipeHandler.MarkQueuedCooked)
+		protected.GET("/queue", recipeHandler.GetQueue)
+		protected.PUT("/queue/order", recipeHandler.ReorderQueue)
+		protected.POST("/recipes/:id/rating", ratingsEnabled, recipeHandler.AddRating)
+		protected.DELETE("/recipes/:id/rating", ratingsEnabled, recipeHandler.DeleteRating)
+		protected.POST("/recipes/:id/made", recipeHandler.RecordMade)
+		protected.POST("/recipes/:id/comment", commentsEnabled, recipeHandler.AddComment)
+		protected.PUT("/comments/:id", commentsEnabled, recipeHandler.UpdateComment)
+		protected.PUT("/comments/:id/visibility", commentsEnabled, recipeHandler.SetCommentVisibility)
 		
 		// Payment routes
-		protected.POST("/payment/initialize", paymentHandler.InitializePayment)
-		protected.GET("/payment/purchases", paymentHandler.GetUserPurchases)
+		protected.POST("/payment/initialize", paymentsEnabled, paymentHandler.InitializePayment)
+		protected.GET("/payment/purchases", paymentsEnabled, paymentHandler.GetUserPurchases)
+	}
+	
+	// Admin routes
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(db), middleware.AdminMiddleware(db))
+	{
+		admin.GET("/recipes/duplicates", adminHandler.FindDuplicateRecipes)
+		admin.POST("/recipes/:id/unpublish", adminHandler.UnpublishRecipe)
+		admin.POST("/recipes/:id/republish", adminHandler.RepublishRecipe)
+		admin.POST("/recipes/:id/feature", adminHandler.FeatureRecipe)
+		admin.POST("/recipes/:id/unfeature", adminHandler.UnfeatureRecipe)
+		admin.GET("/maintenance", adminHandler.GetMaintenance)
+		admin.PUT("/maintenance", adminHandler.SetMaintenance)
 	}
 	
 	// Payment verification (public callback)
-	router.GET("/api/payment/verify", paymentHandler.VerifyPayment)
+	router.GET("/api/payment/verify", paymentsEnabled, paymentHandler.VerifyPayment)
 	
-	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	log.Fatal(router.Run(":" + cfg.Port))
+	// API description, built last so it covers every route above
+	openAPIHandler, err := handlers.NewOpenAPIHandler(router.Routes())
+	if err != nil {
+		log.Fatal("Failed to build the OpenAPI spec:", err)
+	}
+	router.GET("/api/openapi.json", openAPIHandler.GetSpec)
+	
+	server := newServer(cfg, router)
+	
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	
+	// Wait for an interrupt, then let in-flight requests and jobs finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down")
+	
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	if err := scheduler.Stop(ctx); err != nil {
+		log.Printf("Background jobs didn't stop in time: %v", err)
+	}
+}
+
+// newServer configures the HTTP server with explicit timeouts so slow clients
+// can't hold connections open indefinitely.
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.IdleTimeout) * time.Second,
+	}
+}
+
+// autoMigrate is the development fallback for the versioned migrations. It
+// creates missing tables and columns from the models and seeds the categories.
+func autoMigrate(db *gorm.DB) {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.CategoryTranslation{},
+		&models.Recipe{},
+		&models.Ingredient{},
+		&models.Step{},
+		&models.RecipeImage{},
+		&models.Upload{},
+		&models.Like{},
+		&models.Bookmark{},
+		&models.Queue{},
+		&models.Comment{},
+		&models.Rating{},
+		&models.Made{},
+		&models.RecentlyViewed{},
+		&models.Purchase{},
+		&models.ModerationLog{},
+		&models.RecipeView{},
+		&models.SearchLog{},
+		&models.RecipeSlugRedirect{},
+		&models.UserRecipeProgress{},
+	); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+	
+	// Create default categories
+	createDefaultCategories(db)
 }
 
 func createDefaultCategories(db *gorm.DB) {
-	categories := []models.Category{
+	categories := []struct {
+		Name        string
+		Description string
+	}{
 		{Name: "Breakfast", Description: "Start your day right"},
 		{Name: "Lunch", Description: "Midday meals"},
 		{Name: "Dinner", Description: "Evening delights"},
@@ -132,10 +323,22 @@ func createDefaultCategories(db *gorm.DB) {
 		{Name: "Healthy", Description: "Nutritious options"},
 	}
 	
-	for _, category := range categories {
-		var existing models.Category
-		if err := db.Where("name = ?", category.Name).First(&existing).Error; err != nil {
-			db.Create(&category)
-		}
+	rows := make([]models.Category, len(categories))
+	for i, c := range categories {
+		description := c.Description
+		rows[i] = models.Category{Name: c.Name, Description: &description}
+	}
+	
+	// Insert all of them at once, skipping names that already exist, so
+	// instances starting at the same time can't race each other into
+	// duplicate key errors
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoNothing: true,
+		}).Create(&rows).Error
+	})
+	if err != nil {
+		log.Printf("Failed to create default categories: %v", err)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/testdb"
+)
+
+func TestServerDropsSlowHeaders(t *testing.T) {
+	cfg := config.Load()
+	cfg.ReadHeaderTimeout = 1
+	server := newServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+	
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	
+	// Start a request but never finish its headers
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(5 * time.Second))
+	if _, err := bufio.NewReader(conn).ReadByte(); err == nil {
+		t.Fatal("expected the connection to be closed without a response")
+	} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		t.Fatal("expected the server to drop the connection after the header timeout")
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("connection closed after %s, before the header timeout", elapsed)
+	}
+}
+
+func TestSeedingCategoriesConcurrently(t *testing.T) {
+	db := testdb.Open(t, "main_test")
+	
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			createDefaultCategories(db)
+		}()
+	}
+	wg.Wait()
+	
+	var count int64
+	if err := db.Model(&models.Category{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 {
+		t.Errorf("expected exactly 10 categories, got %d", count)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+	
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// HTTPRequests counts handled requests by method, route template and status.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	
+	// HTTPDuration tracks request latency by method and route template.
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	
+	// Payments counts Chapa payment attempts by stage (initialize or verify)
+	// and outcome.
+	Payments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_total",
+		Help: "Payment initializations and verifications by outcome.",
+	}, []string{"stage", "outcome"})
+)
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
\ No newline at end of file
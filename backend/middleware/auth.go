@@ -3,13 +3,18 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	
+	"food-recipes-backend/models"
 	"food-recipes-backend/utils"
 	
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,13 +37,26 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		
+		// Tokens of deleted accounts are no longer accepted
+		exists, err := userExists(c, db, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to verify token, please try again"})
+			c.Abort()
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Next()
 	}
 }
 
-func OptionalAuthMiddleware() gin.HandlerFunc {
+func OptionalAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -52,10 +70,78 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		
+		// A token that can't be verified is treated like no token at all
 		claims, err := utils.ValidateJWT(tokenString)
 		if err == nil {
-			c.Set("user_id", claims.UserID)
-			c.Set("user_email", claims.Email)
+			if exists, err := userExists(c, db, claims.UserID); err == nil && exists {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+			}
+		}
+		
+		c.Next()
+	}
+}
+
+// activeUserTTL is how long a user found by userExists is trusted before the
+// database is asked again. ForgetUser drops the entry when the account is
+// deleted, other instances notice the deletion once the entry expires.
+const activeUserTTL = time.Minute
+
+// activeUserSweep is how many cache stores pass between removing expired entries.
+const activeUserSweep = 1000
+
+// activeUsers maps the IDs of users userExists found to when the entry expires,
+// so authenticated requests don't each cost a query.
+var (
+	activeUsers      sync.Map
+	activeUserStores atomic.Int64
+)
+
+// userExists reports whether the user's account still exists. A failed lookup
+// is returned as an error rather than as a missing user, so an unavailable
+// database doesn't log everyone out.
+func userExists(c *gin.Context, db *gorm.DB, userID string) (bool, error) {
+	now := time.Now()
+	if expiry, ok := activeUsers.Load(userID); ok && now.Before(expiry.(time.Time)) {
+		return true, nil
+	}
+	
+	var count int64
+	if err := db.WithContext(c.Request.Context()).Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		activeUsers.Delete(userID)
+		return false, nil
+	}
+	
+	activeUsers.Store(userID, now.Add(activeUserTTL))
+	if activeUserStores.Add(1)%activeUserSweep == 0 {
+		activeUsers.Range(func(key, expiry interface{}) bool {
+			if now.After(expiry.(time.Time)) {
+				activeUsers.Delete(key)
+			}
+			return true
+		})
+	}
+	return true, nil
+}
+
+// ForgetUser stops trusting the cached lookup of a user, so their tokens are
+// rejected on this instance right after the account is deleted.
+func ForgetUser(userID string) {
+	activeUsers.Delete(userID)
+}
+
+// AdminMiddleware must run after AuthMiddleware and only lets admins through.
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := db.WithContext(c.Request.Context()).First(&user, "id = ?", c.GetString("user_id")).Error; err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
 		}
 		
 		c.Next()
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// emptyDB builds queries without running them, so every lookup finds nothing.
+func emptyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func testContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	return c
+}
+
+func TestUserExistsTrustsCachedUsersUntilForgotten(t *testing.T) {
+	db := emptyDB(t)
+	userID := "cached-user"
+	activeUsers.Store(userID, time.Now().Add(time.Minute))
+	
+	if exists, err := userExists(testContext(), db, userID); err != nil || !exists {
+		t.Fatal("expected the cached user to exist without a query")
+	}
+	
+	ForgetUser(userID)
+	if exists, _ := userExists(testContext(), db, userID); exists {
+		t.Error("expected a forgotten user to be looked up again")
+	}
+}
+
+func TestUserExistsIgnoresExpiredEntries(t *testing.T) {
+	userID := "expired-user"
+	activeUsers.Store(userID, time.Now().Add(-time.Second))
+	
+	if exists, _ := userExists(testContext(), emptyDB(t), userID); exists {
+		t.Error("expected an expired entry to be looked up again")
+	}
+	if _, ok := activeUsers.Load(userID); ok {
+		t.Error("expected the missing user to be dropped from the cache")
+	}
+}
+
+func TestAuthMiddlewareReportsFailedUserLookups(t *testing.T) {
+	db := emptyDB(t)
+	if err := db.Callback().Query().Before("gorm:query").Register("test:fail", func(db *gorm.DB) {
+		db.AddError(errors.New("connection refused"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := utils.GenerateJWT("unreachable-user", "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+	AuthMiddleware(db)(c)
+	
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the user can't be looked up, got %d: %s", w.Code, w.Body)
+	}
+	if _, ok := activeUsers.Load("unreachable-user"); ok {
+		t.Error("expected a failed lookup not to be cached")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionMiddleware gzips API responses for clients that send
+// Accept-Encoding: gzip. Bodies smaller than minSize are sent as-is since
+// compressing them costs more than it saves. Uploaded images are already
+// compressed and are left alone.
+func CompressionMiddleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+		
+		original := c.Writer
+		buffer := &bufferedWriter{ResponseWriter: original, header: make(http.Header)}
+		c.Writer = buffer
+		
+		c.Next()
+		
+		c.Writer = original
+		buffer.header.Add("Vary", "Accept-Encoding")
+		
+		if buffer.body.Len() < minSize || buffer.header.Get("Content-Encoding") != "" {
+			buffer.flush()
+			return
+		}
+		
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(buffer.body.Bytes()); err != nil {
+			buffer.flush()
+			return
+		}
+		if err := gz.Close(); err != nil {
+			buffer.flush()
+			return
+		}
+		
+		buffer.body.Reset()
+		buffer.body.Write(compressed.Bytes())
+		buffer.header.Set("Content-Encoding", "gzip")
+		buffer.header.Del("Content-Length")
+		
+		// The compressed bytes differ from the original, so a strong ETag no
+		// longer holds
+		if etag := buffer.header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			buffer.header.Set("ETag", "W/"+etag)
+		}
+		
+		buffer.flush()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		
+		rejected := false
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); strings.TrimSpace(name) == "q" && err == nil && q == 0 {
+				rejected = true
+			}
+		}
+		if !rejected {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
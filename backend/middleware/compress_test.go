@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat(`{"title":"Pancakes"},`, 200)
+	
+	router := gin.New()
+	router.Use(CompressionMiddleware(1024))
+	router.GET("/api/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	router.GET("/api/tiny", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/uploads/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	
+	get := func(path, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	
+	w := get("/api/large", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a large response to be gzipped, got headers %v", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != large {
+		t.Error("expected the gzipped body to decompress to the original")
+	}
+	
+	for _, tc := range []struct {
+		path, encoding string
+	}{
+		{"/api/tiny", "gzip"},
+		{"/api/large", ""},
+		{"/api/large", "gzip;q=0"},
+		{"/uploads/large", "gzip"},
+	} {
+		w := get(tc.path, tc.encoding)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s with Accept-Encoding %q: expected no compression", tc.path, tc.encoding)
+		}
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("%s with Accept-Encoding %q: expected the body as-is, got %d %q", tc.path, tc.encoding, w.Code, w.Body)
+		}
+	}
+}
\ No newline at end of file
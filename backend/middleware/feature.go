@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	
+	"food-recipes-backend/config"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// RequireFeature hides routes of a disabled feature behind a 404.
+func RequireFeature(cfg *config.Config, feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.FeatureEnabled(feature) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "This feature is not available"})
+			c.Abort()
+			return
+		}
+		
+		c.Next()
+	}
+}
\ No newline at end of file
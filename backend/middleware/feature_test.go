@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"food-recipes-backend/config"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireFeatureHidesDisabledFeatures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Features: map[string]bool{config.FeatureLikes: true, config.FeatureComments: false}}
+	
+	router := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET("/likes", RequireFeature(cfg, config.FeatureLikes), ok)
+	router.GET("/comments", RequireFeature(cfg, config.FeatureComments), ok)
+	router.GET("/unknown", RequireFeature(cfg, "polls"), ok)
+	
+	for path, want := range map[string]int{"/likes": http.StatusOK, "/comments": http.StatusNotFound, "/unknown": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
\ No newline at end of file
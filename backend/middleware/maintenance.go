@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maintenanceExempt lists routes that keep working during maintenance: health
+// and metrics for monitoring, and login so admins can still get a token.
+var maintenanceExempt = map[string]bool{
+	"/health":         true,
+	"/metrics":        true,
+	"/api/auth/login": true,
+}
+
+// Maintenance tracks whether the API is down for maintenance. It starts from
+// MAINTENANCE_MODE and admins can switch it at runtime. The switch only
+// affects this process, so with several instances each has to be toggled, or
+// the environment changed and the instances restarted.
+type Maintenance struct {
+	enabled atomic.Bool
+	message string
+}
+
+func NewMaintenance(cfg *config.Config) *Maintenance {
+	m := &Maintenance{message: cfg.MaintenanceMessage}
+	m.enabled.Store(cfg.MaintenanceMode)
+	return m
+}
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+func (m *Maintenance) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Middleware answers every request with 503 while maintenance mode is on,
+// except exempt routes and requests carrying an admin's token.
+func (m *Maintenance) Middleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Enabled() || maintenanceExempt[c.Request.URL.Path] || isAdminRequest(c, db) {
+			c.Next()
+			return
+		}
+		
+		c.Header("Retry-After", "300")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": m.message, "maintenance": true})
+		c.Abort()
+	}
+}
+
+// isAdminRequest reports whether the request has a valid token of an admin.
+func isAdminRequest(c *gin.Context, db *gorm.DB) bool {
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+		return false
+	}
+	
+	claims, err := utils.ValidateJWT(tokenString)
+	if err != nil {
+		return false
+	}
+	
+	var user models.User
+	if err := db.WithContext(c.Request.Context()).First(&user, "id = ?", claims.UserID).Error; err != nil {
+		return false
+	}
+	return user.IsAdmin
+}
\ No newline at end of file
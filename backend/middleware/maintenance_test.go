@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"food-recipes-backend/config"
+	"food-recipes-backend/models"
+	"food-recipes-backend/testdb"
+	"food-recipes-backend/utils"
+	
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maintenanceRouter serves a few routes behind the maintenance middleware.
+func maintenanceRouter(m *Maintenance, db *gorm.DB) *gin.Engine {
+	router := gin.New()
+	router.Use(m.Middleware(db))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	for _, path := range []string{"/health", "/metrics", "/api/recipes"} {
+		router.GET(path, ok)
+	}
+	router.POST("/api/auth/login", ok)
+	return router
+}
+
+// request sends method path to router, with a bearer token unless it's empty.
+func request(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMaintenanceModeBlocksUsersButNotHealthChecks(t *testing.T) {
+	m := NewMaintenance(&config.Config{MaintenanceMode: true, MaintenanceMessage: "Back soon"})
+	router := maintenanceRouter(m, emptyDB(t))
+	
+	// A valid token of a user who isn't an admin
+	token, _, err := utils.GenerateJWT("some-user", "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tok := range []string{"", token, "not-a-token"} {
+		w := request(router, "GET", "/api/recipes", tok)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("token %q: expected 503, got %d", tok, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" || w.Body.String() != `{"error":"Back soon","maintenance":true}` {
+			t.Errorf("token %q: unexpected response %s", tok, w.Body)
+		}
+	}
+	
+	exempt := []struct{ method, path string }{{"GET", "/health"}, {"GET", "/metrics"}, {"POST", "/api/auth/login"}}
+	for _, route := range exempt {
+		if w := request(router, route.method, route.path, ""); w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected 200 during maintenance, got %d", route.method, route.path, w.Code)
+		}
+	}
+	
+	m.SetEnabled(false)
+	if w := request(router, "GET", "/api/recipes", ""); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once maintenance ends, got %d", w.Code)
+	}
+}
+
+func TestMaintenanceModeLetsAdminsThrough(t *testing.T) {
+	db := testdb.Open(t, "middleware_test")
+	admin := models.User{Email: "admin@example.com", Username: "admin", PasswordHash: "unused", IsAdmin: true}
+	cook := models.User{Email: "cook@example.com", Username: "cook", PasswordHash: "unused"}
+	for _, user := range []*models.User{&admin, &cook} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := maintenanceRouter(NewMaintenance(&config.Config{MaintenanceMode: true}), db)
+	
+	tests := []struct {
+		user   models.User
+		status int
+	}{
+		{admin, http.StatusOK},
+		{cook, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		token, _, err := utils.GenerateJWT(tt.user.ID, tt.user.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := request(router, "GET", "/api/recipes", token); w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.user.Username, tt.status, w.Code)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+	
+	"food-recipes-backend/metrics"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware records the count and latency of every request. Requests
+// are labelled with the route template rather than the raw path so recipe IDs
+// don't create a new series each.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		
+		c.Next()
+		
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
\ No newline at end of file
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	
+	"food-recipes-backend/metrics"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpointCountsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/api/recipes/:id", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/recipes/abc", nil))
+	
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from /metrics, got %d", w.Code)
+	}
+	
+	want := `http_requests_total{method="GET",route="/api/recipes/:id",status="418"} 1`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected %s in the metrics output", want)
+	}
+	if !strings.Contains(w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/api/recipes/:id"} 1`) {
+		t.Error("expected the request latency to be observed")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func timeoutRouter(timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(timeout))
+	router.GET("/api/test", handler)
+	return router
+}
+
+func TestTimeoutMiddlewareAnswersExpiredRequestsWith504(t *testing.T) {
+	router := timeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query cancelled"})
+	})
+	
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+	
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestTimeoutMiddlewarePassesFastResponsesThrough(t *testing.T) {
+	router := timeoutRouter(time.Second, func(c *gin.Context) {
+		c.Header("X-Test", "yes")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+	
+	if w.Code != http.StatusCreated || w.Header().Get("X-Test") != "yes" || w.Body.String() != `{"ok":true}` {
+		t.Errorf("unexpected response %d %v: %s", w.Code, w.Header(), w.Body)
+	}
+}
\ No newline at end of file
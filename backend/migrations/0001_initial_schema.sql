@@ -1,20 +1,24 @@
--- backend/schema.sql
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
+-- Every statement tolerates existing objects, so databases created by the old
+-- schema.sql or by DB_AUTO_MIGRATE can run the migrations from the start
+
 -- Users table
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     email VARCHAR(255) UNIQUE NOT NULL,
     username VARCHAR(100) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     avatar_url VARCHAR(500),
     bio TEXT,
+    is_admin BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
+    updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP
 );
 
 -- Categories table
-CREATE TABLE categories (
+CREATE TABLE IF NOT EXISTS categories (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     name VARCHAR(100) UNIQUE NOT NULL,
     description TEXT,
@@ -23,7 +27,7 @@ CREATE TABLE categories (
 );
 
 -- Recipes table
-CREATE TABLE recipes (
+CREATE TABLE IF NOT EXISTS recipes (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     title VARCHAR(255) NOT NULL,
     description TEXT,
@@ -40,11 +44,12 @@ CREATE TABLE recipes (
     like_count INTEGER DEFAULT 0,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP,
     is_published BOOLEAN DEFAULT FALSE
 );
 
 -- Ingredients table
-CREATE TABLE ingredients (
+CREATE TABLE IF NOT EXISTS ingredients (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     name VARCHAR(255) NOT NULL,
@@ -54,7 +59,7 @@ CREATE TABLE ingredients (
 );
 
 -- Steps table
-CREATE TABLE steps (
+CREATE TABLE IF NOT EXISTS steps (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     step_number INTEGER NOT NULL,
@@ -64,7 +69,7 @@ CREATE TABLE steps (
 );
 
 -- Recipe images table
-CREATE TABLE recipe_images (
+CREATE TABLE IF NOT EXISTS recipe_images (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     image_url VARCHAR(500) NOT NULL,
@@ -73,16 +78,17 @@ CREATE TABLE recipe_images (
 );
 
 -- Likes table
-CREATE TABLE likes (
+CREATE TABLE IF NOT EXISTS likes (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     created_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP,
     UNIQUE(user_id, recipe_id)
 );
 
 -- Bookmarks table
-CREATE TABLE bookmarks (
+CREATE TABLE IF NOT EXISTS bookmarks (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
@@ -91,28 +97,31 @@ CREATE TABLE bookmarks (
 );
 
 -- Comments table
-CREATE TABLE comments (
+CREATE TABLE IF NOT EXISTS comments (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     content TEXT NOT NULL,
+    flagged BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
+    updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP
 );
 
 -- Ratings table
-CREATE TABLE ratings (
+CREATE TABLE IF NOT EXISTS ratings (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
     rating INTEGER CHECK (rating >= 1 AND rating <= 5),
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP,
     UNIQUE(user_id, recipe_id)
 );
 
 -- Purchases table
-CREATE TABLE purchases (
+CREATE TABLE IF NOT EXISTS purchases (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
@@ -122,6 +131,16 @@ CREATE TABLE purchases (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Moderation audit log
+CREATE TABLE IF NOT EXISTS moderation_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID REFERENCES recipes(id) ON DELETE CASCADE,
+    admin_id UUID REFERENCES users(id),
+    action VARCHAR(50) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
 -- Functions and Triggers
 
 -- Function to update recipe average rating
@@ -146,6 +165,7 @@ END;
 $$ LANGUAGE plpgsql;
 
 -- Trigger for ratings
+DROP TRIGGER IF EXISTS trigger_update_recipe_rating ON ratings;
 CREATE TRIGGER trigger_update_recipe_rating
     AFTER INSERT OR UPDATE OR DELETE ON ratings
     FOR EACH ROW
@@ -165,6 +185,7 @@ END;
 $$ LANGUAGE plpgsql;
 
 -- Trigger for likes
+DROP TRIGGER IF EXISTS trigger_update_like_count ON likes;
 CREATE TRIGGER trigger_update_like_count
     AFTER INSERT OR DELETE ON likes
     FOR EACH ROW
@@ -184,6 +205,7 @@ END;
 $$ LANGUAGE plpgsql;
 
 -- Trigger for featured images
+DROP TRIGGER IF EXISTS trigger_ensure_single_featured_image ON recipe_images;
 CREATE TRIGGER trigger_ensure_single_featured_image
     BEFORE INSERT OR UPDATE ON recipe_images
     FOR EACH ROW
@@ -0,0 +1,13 @@
+-- Default categories
+INSERT INTO categories (name, description) VALUES
+    ('Breakfast', 'Start your day right'),
+    ('Lunch', 'Midday meals'),
+    ('Dinner', 'Evening delights'),
+    ('Desserts', 'Sweet treats'),
+    ('Appetizers', 'Starters and snacks'),
+    ('Vegetarian', 'Plant-based recipes'),
+    ('Vegan', '100% plant-based'),
+    ('Gluten-Free', 'No gluten ingredients'),
+    ('Quick & Easy', '30 minutes or less'),
+    ('Healthy', 'Nutritious options')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,28 @@
+-- Store emails lowercased and make email and username unique regardless of case
+
+-- Accounts whose email or username only differ by case from an older account
+-- would break the unique indexes below. The older account keeps its value, the
+-- later ones get their ID appended so they stay distinguishable and can log in
+-- after an admin sorts them out.
+WITH duplicates AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY LOWER(TRIM(email)) ORDER BY created_at, id) AS position
+    FROM users
+)
+UPDATE users u
+SET email = SPLIT_PART(LOWER(TRIM(u.email)), '@', 1) || '+' || LEFT(u.id::text, 8) || '@' || SPLIT_PART(LOWER(TRIM(u.email)), '@', 2)
+FROM duplicates d
+WHERE d.id = u.id AND d.position > 1;
+
+WITH duplicates AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY LOWER(username) ORDER BY created_at, id) AS position
+    FROM users
+)
+UPDATE users u
+SET username = LEFT(u.username, 91) || '-' || LEFT(u.id::text, 8)
+FROM duplicates d
+WHERE d.id = u.id AND d.position > 1;
+
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username));
\ No newline at end of file
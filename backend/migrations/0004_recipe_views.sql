@@ -0,0 +1,10 @@
+-- Recipe views for author analytics
+CREATE TABLE IF NOT EXISTS recipe_views (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_views_recipe_id ON recipe_views (recipe_id);
+CREATE INDEX IF NOT EXISTS idx_recipe_views_created_at ON recipe_views (created_at);
\ No newline at end of file
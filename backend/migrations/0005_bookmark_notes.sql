@@ -0,0 +1,2 @@
+-- Private notes on bookmarks
+ALTER TABLE bookmarks ADD COLUMN IF NOT EXISTS note TEXT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- Localized category names; the categories table keeps the default (English) text
+CREATE TABLE IF NOT EXISTS category_translations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(category_id, locale)
+);
\ No newline at end of file
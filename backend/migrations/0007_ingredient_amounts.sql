@@ -0,0 +1,3 @@
+-- Structured ingredient amounts parsed from the display quantity
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS amount DECIMAL(10,3);
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS amount_max DECIMAL(10,3);
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- SEO-friendly recipe slugs, backfilled from titles. Titles that collide get
+-- part of the recipe ID appended.
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS slug VARCHAR(100);
+
+UPDATE recipes r
+SET slug = CASE WHEN s.n = 1 THEN s.base ELSE s.base || '-' || LEFT(r.id::text, 8) END
+FROM (
+    SELECT id, base, ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, id) AS n
+    FROM (
+        SELECT id, created_at,
+            COALESCE(NULLIF(LEFT(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(title), '[^a-z0-9]+', '-', 'g')), 80), ''), 'recipe') AS base
+        FROM recipes
+    ) titles
+) s
+WHERE r.id = s.id AND r.slug IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipes_slug ON recipes (slug);
+
+-- Old slugs keep working after a title change
+CREATE TABLE IF NOT EXISTS recipe_slug_redirects (
+    slug VARCHAR(100) PRIMARY KEY,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Recipes curated by admins for the featured list
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS is_featured BOOLEAN DEFAULT FALSE;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS featured_rank INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_recipes_featured ON recipes (featured_rank) WHERE is_featured;
\ No newline at end of file
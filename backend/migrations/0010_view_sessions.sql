@@ -0,0 +1,5 @@
+-- Anonymous visitors are identified by a session cookie so repeat views can be
+-- debounced
+ALTER TABLE recipe_views ADD COLUMN IF NOT EXISTS session_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_views_session ON recipe_views (recipe_id, session_id, created_at);
\ No newline at end of file
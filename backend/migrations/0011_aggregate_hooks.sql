@@ -0,0 +1,12 @@
+-- Rating and like aggregates are maintained by model hooks, which also account
+-- for soft deleted rows. The old triggers counted those and broke on DELETE.
+DROP TRIGGER IF EXISTS trigger_update_recipe_rating ON ratings;
+DROP TRIGGER IF EXISTS trigger_update_like_count ON likes;
+DROP FUNCTION IF EXISTS update_recipe_rating();
+DROP FUNCTION IF EXISTS update_recipe_like_count();
+
+-- Bring existing aggregates in line with the rows the hooks count
+UPDATE recipes r SET
+    average_rating = COALESCE((SELECT AVG(rating) FROM ratings WHERE recipe_id = r.id AND deleted_at IS NULL), 0),
+    total_ratings = (SELECT COUNT(*) FROM ratings WHERE recipe_id = r.id AND deleted_at IS NULL),
+    like_count = (SELECT COUNT(*) FROM likes WHERE recipe_id = r.id AND deleted_at IS NULL);
\ No newline at end of file
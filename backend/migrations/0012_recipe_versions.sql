@@ -0,0 +1,2 @@
+-- Recipes carry a version that every edit bumps, so stale edits can be rejected
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
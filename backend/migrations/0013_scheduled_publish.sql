@@ -0,0 +1,4 @@
+-- Recipes can be scheduled to be published at a later time
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_recipes_publish_at ON recipes (publish_at) WHERE NOT is_published;
\ No newline at end of file
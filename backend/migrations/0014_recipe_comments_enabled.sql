@@ -0,0 +1,2 @@
+-- Authors can turn off comments on individual recipes
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS comments_enabled BOOLEAN NOT NULL DEFAULT TRUE;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- The initial schema restricts difficulty levels, but databases created with
+-- DB_AUTO_MIGRATE before the model declared the constraint lack it. Invalid
+-- values are reset so the constraint can be added.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'recipes'::regclass AND conname = 'recipes_difficulty_level_check'
+    ) THEN
+        UPDATE recipes SET difficulty_level = 'medium'
+        WHERE difficulty_level NOT IN ('easy', 'medium', 'hard');
+
+        ALTER TABLE recipes ADD CONSTRAINT recipes_difficulty_level_check
+            CHECK (difficulty_level IN ('easy', 'medium', 'hard'));
+    END IF;
+END $$;
\ No newline at end of file
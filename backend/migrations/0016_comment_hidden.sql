@@ -0,0 +1,2 @@
+-- Authors can hide their own comments from other readers without deleting them
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
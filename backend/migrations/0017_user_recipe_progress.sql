@@ -0,0 +1,10 @@
+-- Checked off ingredients and steps, per user and recipe
+CREATE TABLE IF NOT EXISTS user_recipe_progresses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    checked_ingredients JSONB NOT NULL DEFAULT '[]',
+    checked_steps JSONB NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(user_id, recipe_id)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Smaller WebP copies of JPEG and PNG uploads
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS webp_filename VARCHAR(255);
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Comments can reply to a top-level comment on the same recipe
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES comments(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+
+-- Keyset pagination walks comments by creation time and ID within a recipe
+CREATE INDEX IF NOT EXISTS idx_comments_recipe_created ON comments(recipe_id, created_at, id);
\ No newline at end of file
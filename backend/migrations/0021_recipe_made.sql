@@ -0,0 +1,16 @@
+-- Users can record that they cooked a recipe, with an optional photo and note
+CREATE TABLE IF NOT EXISTS mades (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    photo_url TEXT,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mades_user_id ON mades(user_id);
+CREATE INDEX IF NOT EXISTS idx_mades_recipe_id ON mades(recipe_id);
+CREATE INDEX IF NOT EXISTS idx_mades_deleted_at ON mades(deleted_at);
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS made_count INTEGER DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Recipes are tagged with allergens from a fixed vocabulary, see models.Allergens
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS allergens JSONB NOT NULL DEFAULT '[]';
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- The last recipes each user opened, capped per user by the application
+CREATE TABLE IF NOT EXISTS recently_vieweds (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, recipe_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recently_vieweds_viewed_at ON recently_vieweds(viewed_at);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Optional nutrition per ingredient line, used for per-serving totals
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS calories DECIMAL(10,2);
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS protein DECIMAL(10,2);
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS fat DECIMAL(10,2);
+ALTER TABLE ingredients ADD COLUMN IF NOT EXISTS carbs DECIMAL(10,2);
\ No newline at end of file
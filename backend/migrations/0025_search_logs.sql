@@ -0,0 +1,8 @@
+-- Normalized search terms, counted for the trending searches
+CREATE TABLE IF NOT EXISTS search_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    term VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_logs_created_at ON search_logs(created_at);
\ No newline at end of file
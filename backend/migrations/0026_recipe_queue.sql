@@ -0,0 +1,10 @@
+-- Recipes users plan to cook, in the order they plan to cook them
+CREATE TABLE IF NOT EXISTS queues (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_queues_user_recipe ON queues(user_id, recipe_id);
\ No newline at end of file
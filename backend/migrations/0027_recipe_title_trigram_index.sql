@@ -0,0 +1,9 @@
+-- Lets the admin duplicate search match titles with the pg_trgm % operator
+-- through an index. pg_trgm is optional, see EnsureExtensions, so the index is
+-- only created where the extension is installed.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS idx_recipes_title_trgm ON recipes USING gin (title gin_trgm_ops);
+    END IF;
+END $$;
\ No newline at end of file
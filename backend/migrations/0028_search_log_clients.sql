@@ -0,0 +1,5 @@
+-- Trending searches count distinct clients per term, so one client repeating a
+-- search can't push it up the list
+ALTER TABLE search_logs ADD COLUMN IF NOT EXISTS client_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_search_logs_term_client ON search_logs(term, client_hash);
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- Seed the placeholder that takes over the content of deleted accounts when
+-- DELETED_USER_RECIPES=reassign. It has a fixed ID, since it used to be found
+-- by its username, which anyone could register first.
+
+-- Accounts that registered the now reserved name give it up
+UPDATE users SET username = 'user-' || id::text
+WHERE LOWER(username) = 'deleted-user' AND password_hash <> '!';
+
+UPDATE users SET email = 'user-' || id::text || '@deleted.invalid'
+WHERE LOWER(email) = 'deleted-user@deleted.invalid' AND password_hash <> '!';
+
+-- A placeholder created on demand by earlier versions hands its content over
+-- and is retired like a deleted account. Real accounts never have the
+-- password hash "!".
+UPDATE users SET username = 'deleted-' || id::text, email = 'deleted-' || id::text || '@deleted.invalid'
+WHERE password_hash = '!' AND id <> '00000000-0000-0000-0000-000000000001';
+
+INSERT INTO users (id, email, username, password_hash)
+VALUES ('00000000-0000-0000-0000-000000000001', 'deleted-user@deleted.invalid', 'deleted-user', '!')
+ON CONFLICT (id) DO NOTHING;
+
+UPDATE recipes SET user_id = '00000000-0000-0000-0000-000000000001'
+WHERE user_id IN (SELECT id FROM users WHERE password_hash = '!' AND id <> '00000000-0000-0000-0000-000000000001');
+
+UPDATE comments SET user_id = '00000000-0000-0000-0000-000000000001'
+WHERE user_id IN (SELECT id FROM users WHERE password_hash = '!' AND id <> '00000000-0000-0000-0000-000000000001');
+
+UPDATE purchases SET user_id = '00000000-0000-0000-0000-000000000001'
+WHERE user_id IN (SELECT id FROM users WHERE password_hash = '!' AND id <> '00000000-0000-0000-0000-000000000001');
+
+UPDATE users SET deleted_at = NOW()
+WHERE password_hash = '!' AND id <> '00000000-0000-0000-0000-000000000001' AND deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Recipes taken down by a moderator stay hidden until a moderator republishes
+-- them, so their authors can't simply publish them again
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS moderation_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Recipes whose latest moderation action was a takedown are still taken down
+UPDATE recipes r SET moderation_hidden = TRUE
+WHERE NOT r.is_published
+    AND (SELECT m.action FROM moderation_logs m WHERE m.recipe_id = r.id ORDER BY m.created_at DESC LIMIT 1) = 'unpublish';
\ No newline at end of file
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// EnsureExtensions installs the Postgres extensions the models depend on, such
+// as uuid-ossp for the uuid_generate_v4() column defaults. It must run before
+// any tables are created.
+func EnsureExtensions(db *gorm.DB) error {
+	err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error
+	
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42501" {
+		return fmt.Errorf(`the database user lacks permission to create the "uuid-ossp" extension; ` +
+			`ask a superuser to run CREATE EXTENSION "uuid-ossp" on this database: %w`, err)
+	}
+	if err != nil {
+		return err
+	}
+	
+	// pg_trgm only powers the admin duplicate search, which falls back to exact
+	// title matches without it, so failing to install it isn't fatal
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		log.Printf("Warning: pg_trgm extension unavailable, duplicate detection will use exact title matches: %v", err)
+	}
+	return nil
+}
+
+// Run applies all embedded SQL migrations that haven't been applied yet, in
+// filename order. Each migration runs in its own transaction and is recorded
+// in the schema_migrations table, so running it again is a no-op.
+func Run(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT NOW()
+	)`).Error; err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	
+	var applied []string
+	if err := db.Table("schema_migrations").Pluck("version", &applied).Error; err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+	
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+		if done[version] {
+			continue
+		}
+		
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(string(content)).Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", version, err)
+		}
+		
+		log.Printf("Applied migration %s", version)
+	}
+	
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,74 @@
+package migrations_test
+
+import (
+	"path/filepath"
+	"testing"
+	
+	"food-recipes-backend/migrations"
+	"food-recipes-backend/testdb"
+)
+
+func TestRunMigratesAnEmptyDatabaseOnce(t *testing.T) {
+	db := testdb.Fresh(t, "food_recipes_migrations_test")
+	
+	if err := migrations.EnsureExtensions(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Run(db); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	
+	files, err := filepath.Glob("*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied int64
+	db.Table("schema_migrations").Count(&applied)
+	if int(applied) != len(files) {
+		t.Errorf("expected %d applied migrations, got %d", len(files), applied)
+	}
+	
+	for _, table := range []string{"users", "recipes", "comments", "queues", "search_logs"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("expected table %s to exist", table)
+		}
+	}
+	var categories int64
+	db.Table("categories").Count(&categories)
+	if categories == 0 {
+		t.Error("expected the default categories to be seeded")
+	}
+	
+	if err := migrations.Run(db); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	var reapplied int64
+	db.Table("schema_migrations").Count(&reapplied)
+	if reapplied != applied {
+		t.Errorf("expected the second run to apply nothing, applied count went from %d to %d", applied, reapplied)
+	}
+}
+
+func TestEnsureExtensionsInstallsUUIDOssp(t *testing.T) {
+	db := testdb.Fresh(t, "food_recipes_extensions_test")
+	
+	// The template database may already carry the extension
+	if err := db.Exec(`DROP EXTENSION IF EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	if err := migrations.EnsureExtensions(db); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	if err := db.Raw("SELECT uuid_generate_v4()::text").Scan(&id).Error; err != nil || id == "" {
+		t.Fatalf("expected uuid_generate_v4 to be available, got %q: %v", id, err)
+	}
+	
+	if err := migrations.Run(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO categories (name) VALUES ('Extension test')").Error; err != nil {
+		t.Errorf("expected IDs to default to uuid_generate_v4: %v", err)
+	}
+}
\ No newline at end of file
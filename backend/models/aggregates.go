@@ -0,0 +1,56 @@
+package models
+
+import "gorm.io/gorm"
+
+// lockRecipe takes the recipe's row lock for the rest of the transaction, so
+// concurrent refreshes run one after another and each recount sees the rows
+// committed before it. NO KEY UPDATE doesn't conflict with the key share locks
+// the foreign keys of the inserted likes, ratings and mades hold on the recipe.
+func lockRecipe(tx *gorm.DB, recipeID string) error {
+	return tx.Exec("SELECT id FROM recipes WHERE id = ? FOR NO KEY UPDATE", recipeID).Error
+}
+
+// RefreshRatingStats recomputes a recipe's average rating and rating count from
+// its ratings that aren't deleted. Batch operations that don't load the rows
+// run the hooks without a recipe ID; those callers refresh explicitly.
+func RefreshRatingStats(tx *gorm.DB, recipeID string) error {
+	if recipeID == "" {
+		return nil
+	}
+	if err := lockRecipe(tx, recipeID); err != nil {
+		return err
+	}
+	
+	ratings := tx.Model(&Rating{}).Where("recipe_id = ?", recipeID)
+	return tx.Model(&Recipe{}).Where("id = ?", recipeID).UpdateColumns(map[string]interface{}{
+		"average_rating": ratings.Session(&gorm.Session{}).Select("COALESCE(AVG(rating), 0)"),
+		"total_ratings":  ratings.Session(&gorm.Session{}).Select("COUNT(*)"),
+	}).Error
+}
+
+// RefreshLikeCount recounts a recipe's likes that aren't deleted.
+func RefreshLikeCount(tx *gorm.DB, recipeID string) error {
+	if recipeID == "" {
+		return nil
+	}
+	if err := lockRecipe(tx, recipeID); err != nil {
+		return err
+	}
+	
+	likes := tx.Model(&Like{}).Select("COUNT(*)").Where("recipe_id = ?", recipeID)
+	return tx.Model(&Recipe{}).Where("id = ?", recipeID).UpdateColumn("like_count", likes).Error
+}
+
+// RefreshMadeCount recounts the distinct users who recorded making a recipe,
+// so cooking it again doesn't inflate the count.
+func RefreshMadeCount(tx *gorm.DB, recipeID string) error {
+	if recipeID == "" {
+		return nil
+	}
+	if err := lockRecipe(tx, recipeID); err != nil {
+		return err
+	}
+	
+	cooks := tx.Model(&Made{}).Select("COUNT(DISTINCT user_id)").Where("recipe_id = ?", recipeID)
+	return tx.Model(&Recipe{}).Where("id = ?", recipeID).UpdateColumn("made_count", cooks).Error
+}
\ No newline at end of file
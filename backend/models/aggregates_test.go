@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/testdb"
+	
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statementLog records the SQL of every statement a dry run session builds.
+type statementLog struct {
+	logger.Interface
+	statements []string
+}
+
+func (l *statementLog) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	l.statements = append(l.statements, sql)
+}
+
+func dryRun(t *testing.T) (*gorm.DB, *statementLog) {
+	t.Helper()
+	
+	log := &statementLog{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, log
+}
+
+func TestRefreshAggregatesLockAndRecountInOneStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		refresh func(*gorm.DB, string) error
+		update  string
+	}{
+		{"ratings", RefreshRatingStats, `SET "average_rating"=(SELECT COALESCE(AVG(rating), 0) FROM "ratings" WHERE recipe_id = 'r1'`},
+		{"likes", RefreshLikeCount, `SET "like_count"=(SELECT COUNT(*) FROM "likes" WHERE recipe_id = 'r1'`},
+		{"mades", RefreshMadeCount, `SET "made_count"=(SELECT COUNT(DISTINCT user_id) FROM "mades" WHERE recipe_id = 'r1'`},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, log := dryRun(t)
+			if err := tt.refresh(db, "r1"); err != nil {
+				t.Fatal(err)
+			}
+			
+			if len(log.statements) != 2 {
+				t.Fatalf("expected a lock and an update, got %q", log.statements)
+			}
+			if !strings.HasSuffix(log.statements[0], "FOR NO KEY UPDATE") {
+				t.Errorf("first statement doesn't lock the recipe: %s", log.statements[0])
+			}
+			if !strings.Contains(log.statements[1], tt.update) {
+				t.Errorf("update doesn't recount in a subquery: %s", log.statements[1])
+			}
+		})
+	}
+}
+
+func TestRefreshAggregatesSkipMissingRecipe(t *testing.T) {
+	db, log := dryRun(t)
+	for _, refresh := range []func(*gorm.DB, string) error{RefreshRatingStats, RefreshLikeCount, RefreshMadeCount} {
+		if err := refresh(db, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(log.statements) != 0 {
+		t.Errorf("expected no statements, got %q", log.statements)
+	}
+}
+
+func TestHooksKeepAggregatesInSync(t *testing.T) {
+	db := testdb.Open(t, "models_test")
+	
+	var users []User
+	for _, name := range []string{"first", "second"} {
+		user := User{Email: name + "@example.com", Username: name, PasswordHash: "unused"}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	category := Category{Name: "Soups"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	recipe := Recipe{Title: "Lentil Soup", PreparationTime: 10, Servings: 2, DifficultyLevel: DifficultyEasy,
+		CategoryID: category.ID, UserID: users[0].ID, IsPublished: true, Allergens: []string{}}
+	if err := db.Create(&recipe).Error; err != nil {
+		t.Fatal(err)
+	}
+	
+	expect := func(step string, average float64, ratings, likes int) {
+		t.Helper()
+		var stored Recipe
+		if err := db.First(&stored, "id = ?", recipe.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.AverageRating != average || stored.TotalRatings != ratings || stored.LikeCount != likes {
+			t.Errorf("%s: expected average %v of %d ratings and %d likes, got %v of %d and %d",
+				step, average, ratings, likes, stored.AverageRating, stored.TotalRatings, stored.LikeCount)
+		}
+	}
+	
+	first := Rating{UserID: users[0].ID, RecipeID: recipe.ID, Rating: 4}
+	second := Rating{UserID: users[1].ID, RecipeID: recipe.ID, Rating: 2}
+	for _, rating := range []*Rating{&first, &second} {
+		if err := db.Create(rating).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect("after creating ratings", 3, 2, 0)
+	
+	second.Rating = 5
+	if err := db.Save(&second).Error; err != nil {
+		t.Fatal(err)
+	}
+	expect("after updating a rating", 4.5, 2, 0)
+	
+	if err := db.Delete(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	expect("after deleting a rating", 5, 1, 0)
+	
+	like := Like{UserID: users[1].ID, RecipeID: recipe.ID}
+	if err := db.Create(&like).Error; err != nil {
+		t.Fatal(err)
+	}
+	expect("after a like", 5, 1, 1)
+	if err := db.Delete(&like).Error; err != nil {
+		t.Fatal(err)
+	}
+	expect("after an unlike", 5, 1, 0)
+}
\ No newline at end of file
@@ -1,21 +1,30 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 	
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID           string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	Email        string    `json:"email" gorm:"uniqueIndex;not null"`
-	Username     string    `json:"username" gorm:"uniqueIndex;not null"`
-	PasswordHash string    `json:"-" gorm:"not null"`
-	AvatarURL    *string   `json:"avatar_url"`
-	Bio          *string   `json:"bio"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Recipes      []Recipe  `json:"recipes" gorm:"foreignKey:UserID"`
+	ID           string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Email        string         `json:"email" gorm:"uniqueIndex;not null"`
+	Username     string         `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash string         `json:"-" gorm:"not null"`
+	AvatarURL    *string        `json:"avatar_url"`
+	Bio          *string        `json:"bio"`
+	IsAdmin      bool           `json:"is_admin" gorm:"default:false"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	Recipes      []Recipe       `json:"recipes" gorm:"foreignKey:UserID"`
+}
+
+// AfterFind makes an unloaded Recipes list serialize as [] rather than null.
+func (u *User) AfterFind(tx *gorm.DB) error {
+	u.Recipes = emptyIfNil(u.Recipes)
+	return nil
 }
 
 type Category struct {
@@ -27,22 +36,73 @@ type Category struct {
 	Recipes     []Recipe  `json:"recipes" gorm:"foreignKey:CategoryID"`
 }
 
+// AfterFind makes an unloaded Recipes list serialize as [] rather than null.
+func (c *Category) AfterFind(tx *gorm.DB) error {
+	c.Recipes = emptyIfNil(c.Recipes)
+	return nil
+}
+
+// CategoryTranslation holds a category's name and description in one locale,
+// such as "am" or "fr-ca". Locales are stored lower-cased.
+type CategoryTranslation struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CategoryID  string    `json:"category_id" gorm:"type:uuid;not null;uniqueIndex:idx_category_locale"`
+	Locale      string    `json:"locale" gorm:"type:varchar(35);not null;uniqueIndex:idx_category_locale"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description *string   `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Difficulty levels a recipe can have. The database enforces the same set with
+// the recipes_difficulty_level_check constraint.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Allergens is the vocabulary recipes can be tagged with in Recipe.Allergens.
+var Allergens = []string{
+	"celery", "dairy", "eggs", "fish", "gluten", "mustard",
+	"nuts", "peanuts", "sesame", "shellfish", "soy", "sulphites",
+}
+
+// IsAllergen reports whether name is in the Allergens vocabulary.
+func IsAllergen(name string) bool {
+	for _, allergen := range Allergens {
+		if allergen == name {
+			return true
+		}
+	}
+	return false
+}
+
 type Recipe struct {
 	ID               string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	Title            string         `json:"title" gorm:"not null"`
+	Slug             *string        `json:"slug" gorm:"type:varchar(100);uniqueIndex"`
 	Description      string         `json:"description"`
 	FeaturedImageURL *string        `json:"featured_image_url"`
 	PreparationTime  int            `json:"preparation_time" gorm:"not null"`
 	CookingTime      int            `json:"cooking_time" gorm:"not null"`
 	Servings         int            `json:"servings" gorm:"not null"`
-	DifficultyLevel  string         `json:"difficulty_level" gorm:"type:varchar(20)"`
+	DifficultyLevel  string         `json:"difficulty_level" gorm:"type:varchar(20);check:recipes_difficulty_level_check,difficulty_level IN ('easy', 'medium', 'hard')"`
 	CategoryID       string         `json:"category_id" gorm:"type:uuid;not null"`
 	UserID           string         `json:"user_id" gorm:"type:uuid;not null"`
 	Price            float64        `json:"price" gorm:"type:decimal(10,2);default:0"`
 	AverageRating    float64        `json:"average_rating" gorm:"type:decimal(3,2);default:0"`
 	TotalRatings     int            `json:"total_ratings" gorm:"default:0"`
 	LikeCount        int            `json:"like_count" gorm:"default:0"`
+	MadeCount        int            `json:"made_count" gorm:"default:0"`
+	Allergens        []string       `json:"allergens" gorm:"type:jsonb;serializer:json;not null;default:'[]'"`
+	TotalTime        int            `json:"total_time" gorm:"-"`
 	IsPublished      bool           `json:"is_published" gorm:"default:false"`
+	PublishAt        *time.Time     `json:"publish_at" gorm:"index"`
+	ModerationHidden bool           `json:"moderation_hidden" gorm:"not null;default:false"`
+	CommentsEnabled  bool           `json:"comments_enabled" gorm:"not null;default:true"`
+	IsFeatured       bool           `json:"is_featured" gorm:"default:false"`
+	FeaturedRank     *int           `json:"featured_rank"`
+	Version          int            `json:"version" gorm:"not null;default:1"`
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -59,12 +119,60 @@ type Recipe struct {
 	Ratings      []Rating        `json:"ratings" gorm:"foreignKey:RecipeID"`
 }
 
+// AfterFind computes TotalTime, which matches the max_total_time search filter.
+// Relations that weren't loaded are emptied so they serialize as [] not null.
+func (r *Recipe) AfterFind(tx *gorm.DB) error {
+	r.TotalTime = r.PreparationTime + r.CookingTime
+	r.fillEmptySlices()
+	return nil
+}
+
+// AfterSave keeps TotalTime in sync when a recipe is created or updated.
+func (r *Recipe) AfterSave(tx *gorm.DB) error {
+	r.TotalTime = r.PreparationTime + r.CookingTime
+	r.fillEmptySlices()
+	return nil
+}
+
+func (r *Recipe) fillEmptySlices() {
+	r.Ingredients = emptyIfNil(r.Ingredients)
+	r.Steps = emptyIfNil(r.Steps)
+	r.Images = emptyIfNil(r.Images)
+	r.Likes = emptyIfNil(r.Likes)
+	r.Bookmarks = emptyIfNil(r.Bookmarks)
+	r.Comments = emptyIfNil(r.Comments)
+	r.Ratings = emptyIfNil(r.Ratings)
+	r.Allergens = emptyIfNil(r.Allergens)
+	r.User.Recipes = emptyIfNil(r.User.Recipes)
+	r.Category.Recipes = emptyIfNil(r.Category.Recipes)
+}
+
+// emptyIfNil returns an empty slice in place of nil, so JSON responses always
+// contain arrays for list fields.
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// Ingredient keeps Quantity as the text shown to readers. Amount and AmountMax
+// are parsed from it (or sent directly) so recipes can be scaled; AmountMax is
+// only set for ranges like "1-2".
 type Ingredient struct {
 	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
-	Name      string    `json:"name" gorm:"not null"`
+	Name      string    `json:"name" gorm:"not null" binding:"required,notblank"`
 	Quantity  string    `json:"quantity"`
+	Amount    *float64  `json:"amount" gorm:"type:decimal(10,3)"`
+	AmountMax *float64  `json:"amount_max" gorm:"type:decimal(10,3)"`
 	Unit      string    `json:"unit"`
+	// Nutrition for the ingredient as listed, not per serving. Unknown values
+	// stay null.
+	Calories  *float64  `json:"calories" gorm:"type:decimal(10,2)"`
+	Protein   *float64  `json:"protein" gorm:"type:decimal(10,2)"`
+	Fat       *float64  `json:"fat" gorm:"type:decimal(10,2)"`
+	Carbs     *float64  `json:"carbs" gorm:"type:decimal(10,2)"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -72,7 +180,7 @@ type Step struct {
 	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	RecipeID    string    `json:"recipe_id" gorm:"type:uuid;not null"`
 	StepNumber  int       `json:"step_number" gorm:"not null"`
-	Instruction string    `json:"instruction" gorm:"not null"`
+	Instruction string    `json:"instruction" gorm:"not null" binding:"required,notblank"`
 	ImageURL    *string   `json:"image_url"`
 	CreatedAt   time.Time `json:"created_at"`
 }
@@ -82,53 +190,151 @@ type RecipeImage struct {
 	RecipeID     string    `json:"recipe_id" gorm:"type:uuid;not null"`
 	ImageURL     string    `json:"image_url" gorm:"not null"`
 	IsFeatured   bool      `json:"is_featured" gorm:"default:false"`
+	UploadedBy   *string   `json:"uploaded_by" gorm:"type:uuid;index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Upload records an image file stored in the upload directory and the user who
+// uploaded it, so files can be attributed and cleaned up.
+type Upload struct {
+	ID           string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       string    `json:"user_id" gorm:"type:uuid;not null;index"`
+	Filename     string    `json:"filename" gorm:"uniqueIndex;not null"`
+	WebPFilename *string   `json:"webp_filename" gorm:"column:webp_filename"`
+	MimeType     string    `json:"mime_type" gorm:"not null"`
+	Size         int64     `json:"size" gorm:"not null"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Like struct {
-	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`
-	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null"`
+	RecipeID  string         `json:"recipe_id" gorm:"type:uuid;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
 	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
 }
 
+// AfterCreate, AfterUpdate and AfterDelete keep the recipe's LikeCount in
+// sync however a like is added or removed.
+func (l *Like) AfterCreate(tx *gorm.DB) error {
+	return RefreshLikeCount(tx, l.RecipeID)
+}
+
+func (l *Like) AfterUpdate(tx *gorm.DB) error {
+	return RefreshLikeCount(tx, l.RecipeID)
+}
+
+func (l *Like) AfterDelete(tx *gorm.DB) error {
+	return RefreshLikeCount(tx, l.RecipeID)
+}
+
 type Bookmark struct {
 	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`
 	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
+	Note      *string   `json:"note" gorm:"type:text"`
 	CreatedAt time.Time `json:"created_at"`
 	
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
 	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
 }
 
-type Comment struct {
+// Queue is a recipe a user plans to cook. Unlike bookmarks the queue is
+// ordered by Position and worked through: a recipe leaves it once cooked.
+type Queue struct {
 	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`
-	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
-	Content   string    `json:"content" gorm:"not null"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_queues_user_recipe"`
+	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null;uniqueIndex:idx_queues_user_recipe"`
+	Position  int       `json:"position" gorm:"not null"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	
+	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
+}
+
+type Comment struct {
+	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null"`
+	RecipeID  string         `json:"recipe_id" gorm:"type:uuid;not null"`
+	ParentID  *string        `json:"parent_id" gorm:"type:uuid;index"`
+	Content   string         `json:"content" gorm:"not null"`
+	Flagged   bool           `json:"flagged" gorm:"default:false"`
+	Hidden    bool           `json:"hidden" gorm:"not null;default:false"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
 	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
 }
 
+// AfterFind fills the list fields of the nested user and recipe, which are
+// often not loaded, with empty slices.
+func (c *Comment) AfterFind(tx *gorm.DB) error {
+	c.User.Recipes = emptyIfNil(c.User.Recipes)
+	c.Recipe.fillEmptySlices()
+	return nil
+}
+
 type Rating struct {
-	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`
-	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
-	Rating    int       `json:"rating" gorm:"not null;check:rating>=1 AND rating<=5"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null"`
+	RecipeID  string         `json:"recipe_id" gorm:"type:uuid;not null"`
+	Rating    int            `json:"rating" gorm:"not null;check:rating>=1 AND rating<=5"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
 	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
 }
 
+// AfterCreate, AfterUpdate and AfterDelete keep the recipe's AverageRating
+// and TotalRatings in sync however a rating is changed.
+func (r *Rating) AfterCreate(tx *gorm.DB) error {
+	return RefreshRatingStats(tx, r.RecipeID)
+}
+
+func (r *Rating) AfterUpdate(tx *gorm.DB) error {
+	return RefreshRatingStats(tx, r.RecipeID)
+}
+
+func (r *Rating) AfterDelete(tx *gorm.DB) error {
+	return RefreshRatingStats(tx, r.RecipeID)
+}
+
+// Made records that a user cooked a recipe, optionally with a photo of the
+// result and a note. A user can record the same recipe more than once.
+type Made struct {
+	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null;index"`
+	RecipeID  string         `json:"recipe_id" gorm:"type:uuid;not null;index"`
+	PhotoURL  *string        `json:"photo_url"`
+	Note      *string        `json:"note" gorm:"type:text"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	
+	// The gallery is public, so only the cook's public profile is loaded
+	User   PublicUser `json:"user" gorm:"foreignKey:UserID"`
+	Recipe Recipe     `json:"recipe" gorm:"foreignKey:RecipeID"`
+}
+
+// AfterCreate, AfterUpdate and AfterDelete keep the recipe's MadeCount in
+// sync however an entry is added or removed.
+func (m *Made) AfterCreate(tx *gorm.DB) error {
+	return RefreshMadeCount(tx, m.RecipeID)
+}
+
+func (m *Made) AfterUpdate(tx *gorm.DB) error {
+	return RefreshMadeCount(tx, m.RecipeID)
+}
+
+func (m *Made) AfterDelete(tx *gorm.DB) error {
+	return RefreshMadeCount(tx, m.RecipeID)
+}
+
 type Purchase struct {
 	ID                  string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	UserID              string    `json:"user_id" gorm:"type:uuid;not null"`
@@ -142,6 +348,62 @@ type Purchase struct {
 	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
 }
 
+// RecipeSlugRedirect maps a recipe's previous slug to the recipe, so links
+// made before a title change still resolve.
+type RecipeSlugRedirect struct {
+	Slug      string    `json:"slug" gorm:"type:varchar(100);primary_key"`
+	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RecipeView struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null;index"`
+	UserID    *string   `json:"user_id" gorm:"type:uuid"`
+	SessionID *string   `json:"-" gorm:"type:varchar(64)"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// SearchLog records one search for the trending terms. Only the normalized
+// term is kept, along with a keyed hash of who searched.
+type SearchLog struct {
+	ID         string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Term       string    `json:"term" gorm:"type:varchar(100);not null;index:idx_search_logs_term_client"`
+	// Logs written before client hashes were recorded have an empty one
+	ClientHash string    `json:"-" gorm:"type:varchar(64);not null;default:'';index:idx_search_logs_term_client"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// UserRecipeProgress stores which ingredients and steps a user has checked off
+// while cooking a recipe, so the checklist follows them across devices.
+type UserRecipeProgress struct {
+	ID                 string    `json:"-" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID             string    `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_user_recipe_progress"`
+	RecipeID           string    `json:"recipe_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_recipe_progress"`
+	CheckedIngredients []string  `json:"checked_ingredients" gorm:"type:jsonb;serializer:json;not null"`
+	CheckedSteps       []string  `json:"checked_steps" gorm:"type:jsonb;serializer:json;not null"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// RecentlyViewed is a recipe a user opened recently. Each user keeps one row
+// per recipe, bumped on every view, and only the most recent few are kept.
+type RecentlyViewed struct {
+	UserID   string    `json:"-" gorm:"type:uuid;primary_key"`
+	RecipeID string    `json:"recipe_id" gorm:"type:uuid;primary_key"`
+	ViewedAt time.Time `json:"viewed_at" gorm:"not null;index"`
+}
+
+type ModerationLog struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RecipeID  string    `json:"recipe_id" gorm:"type:uuid;not null;index"`
+	AdminID   string    `json:"admin_id" gorm:"type:uuid;not null"`
+	Action    string    `json:"action" gorm:"type:varchar(50);not null"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	Admin User `json:"admin" gorm:"foreignKey:AdminID"`
+}
+
 // Auth types
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -149,23 +411,164 @@ type LoginRequest struct {
 }
 
 type SignupRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Username string `json:"username" binding:"required,min=3"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email     string  `json:"email" binding:"required,email"`
+	Username  string  `json:"username" binding:"required,min=3"`
+	Password  string  `json:"password" binding:"required,min=6"`
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,max=500"`
+	Bio       *string `json:"bio" binding:"omitempty,max=500"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateRecipeRequest is a partial update: only fields present in the request
+// are changed, and nested collections are only saved when they are provided.
+type UpdateRecipeRequest struct {
+	Title            *string       `json:"title" binding:"omitempty,min=1"`
+	Description      *string       `json:"description"`
+	FeaturedImageURL *string       `json:"featured_image_url"`
+	PreparationTime  *int          `json:"preparation_time" binding:"omitempty,min=1"`
+	CookingTime      *int          `json:"cooking_time" binding:"omitempty,min=0"`
+	Servings         *int          `json:"servings" binding:"omitempty,min=1"`
+	DifficultyLevel  *string       `json:"difficulty_level" binding:"omitempty,oneof=easy medium hard"`
+	CategoryID       *string       `json:"category_id" binding:"omitempty,min=1"`
+	Price            *float64      `json:"price" binding:"omitempty,min=0"`
+	IsPublished      *bool         `json:"is_published"`
+	PublishAt        *time.Time    `json:"publish_at"`
+	CommentsEnabled  *bool         `json:"comments_enabled"`
+	Allergens        []string      `json:"allergens" binding:"omitempty,max=20,dive,allergen"`
+	Ingredients      []Ingredient  `json:"ingredients" binding:"omitempty,dive"`
+	Steps            []Step        `json:"steps" binding:"omitempty,dive"`
+	Images           []RecipeImage `json:"images"`
+	
+	// Version is the recipe version the edit is based on. When set the update
+	// is rejected if the recipe changed in the meantime.
+	Version *int `json:"version" binding:"omitempty,min=1"`
+}
+
+// Publishes reports whether the edit publishes the recipe, right away or on a
+// schedule.
+func (r UpdateRecipeRequest) Publishes() bool {
+	return (r.IsPublished != nil && *r.IsPublished) || r.PublishAt != nil
+}
+
+// Changes returns the column updates for the scalar fields that were sent.
+func (r UpdateRecipeRequest) Changes() map[string]interface{} {
+	changes := make(map[string]interface{})
+	
+	if r.Title != nil {
+		changes["title"] = *r.Title
+	}
+	if r.Description != nil {
+		changes["description"] = *r.Description
+	}
+	if r.FeaturedImageURL != nil {
+		// An empty string removes the featured image
+		if *r.FeaturedImageURL == "" {
+			changes["featured_image_url"] = nil
+		} else {
+			changes["featured_image_url"] = *r.FeaturedImageURL
+		}
+	}
+	if r.PreparationTime != nil {
+		changes["preparation_time"] = *r.PreparationTime
+	}
+	if r.CookingTime != nil {
+		changes["cooking_time"] = *r.CookingTime
+	}
+	if r.Servings != nil {
+		changes["servings"] = *r.Servings
+	}
+	if r.DifficultyLevel != nil {
+		changes["difficulty_level"] = *r.DifficultyLevel
+	}
+	if r.CategoryID != nil {
+		changes["category_id"] = *r.CategoryID
+	}
+	if r.Price != nil {
+		changes["price"] = *r.Price
+	}
+	if r.IsPublished != nil {
+		// Publishing or unpublishing by hand cancels any pending schedule
+		changes["is_published"] = *r.IsPublished
+		changes["publish_at"] = nil
+	}
+	if r.CommentsEnabled != nil {
+		changes["comments_enabled"] = *r.CommentsEnabled
+	}
+	if r.Allergens != nil {
+		// Map updates bypass the field's JSON serializer, so encode it here
+		encoded, _ := json.Marshal(r.Allergens)
+		changes["allergens"] = string(encoded)
+	}
+	if r.PublishAt != nil {
+		// A future time keeps the recipe hidden until the scheduler publishes
+		// it, a past one publishes it right away. The column has no time zone,
+		// so the time is stored in UTC.
+		if r.PublishAt.After(time.Now()) {
+			changes["is_published"] = false
+			changes["publish_at"] = r.PublishAt.UTC()
+		} else {
+			changes["is_published"] = true
+			changes["publish_at"] = nil
+		}
+	}
+	
+	return changes
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token     string    `json:"token"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
 }
 
 // Search types
 type SearchFilters struct {
-	Query         string  `form:"q"`
-	CategoryID    string  `form:"category_id"`
-	MaxTotalTime  int     `form:"max_total_time"`
-	Ingredient    string  `form:"ingredient"`
-	MinRating     float64 `form:"min_rating"`
-	Page          int     `form:"page" binding:"min=1"`
-	Limit         int     `form:"limit" binding:"min=1,max=50"`
+	Query            string     `form:"q"`
+	CategoryID       string     `form:"category_id"`
+	AuthorID         string     `form:"author_id"`
+	Username         string     `form:"username"`
+	MaxTotalTime     int        `form:"max_total_time"`
+	Ingredient       string     `form:"ingredient"`
+	MinRating        float64    `form:"min_rating"`
+	MinPrice         *float64   `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice         *float64   `form:"max_price" binding:"omitempty,min=0"`
+	MinServings      int        `form:"min_servings" binding:"omitempty,min=1"`
+	MaxServings      int        `form:"max_servings" binding:"omitempty,min=1"`
+	FreeOnly         bool       `form:"free_only"`
+	ExcludeAllergens []string   `form:"exclude_allergens"`
+	CreatedAfter     *time.Time `form:"created_after"`
+	CreatedBefore    *time.Time `form:"created_before"`
+	Highlight        bool       `form:"highlight"`
+	Sort             string     `form:"sort"`
+	Page             int        `form:"page"`
+	Limit            int        `form:"limit"`
+}
+
+// Public profile types
+type PublicUser struct {
+	ID        string  `json:"id"`
+	Username  string  `json:"username"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
+// TableName lets PublicUser be loaded as a relation straight from users.
+func (PublicUser) TableName() string {
+	return "users"
+}
+
+type RecipeLiker struct {
+	PublicUser `gorm:"embedded"`
+	LikedAt    time.Time `json:"liked_at"`
+}
+
+// RecipePurchaser is a buyer shown to a recipe's author. Only the public
+// profile is included, never the buyer's email.
+type RecipePurchaser struct {
+	PublicUser  `gorm:"embedded"`
+	Amount      float64   `json:"amount"`
+	PurchasedAt time.Time `json:"purchased_at"`
 }
\ No newline at end of file
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	
+	"food-recipes-backend/testdb"
+)
+
+func TestUpdateRecipeRequestStoresPublishAtInUTC(t *testing.T) {
+	publishAt := time.Now().Add(48 * time.Hour).In(time.FixedZone("EAT", 3*60*60))
+	changes := UpdateRecipeRequest{PublishAt: &publishAt}.Changes()
+	
+	stored, ok := changes["publish_at"].(time.Time)
+	if !ok {
+		t.Fatalf("expected a publish time, got %v", changes["publish_at"])
+	}
+	if stored.Location() != time.UTC {
+		t.Errorf("expected UTC, got %s", stored.Location())
+	}
+	if !stored.Equal(publishAt) {
+		t.Errorf("expected %s, got %s", publishAt, stored)
+	}
+	if changes["is_published"] != false {
+		t.Errorf("expected a scheduled recipe to stay unpublished, got %v", changes["is_published"])
+	}
+}
+
+func TestUpdateRecipeRequestPublishesPastTimes(t *testing.T) {
+	publishAt := time.Now().Add(-time.Hour)
+	changes := UpdateRecipeRequest{PublishAt: &publishAt}.Changes()
+	
+	if changes["is_published"] != true || changes["publish_at"] != nil {
+		t.Errorf("expected the recipe to be published right away, got %v", changes)
+	}
+}
+
+func TestRecipeTotalTimeFollowsSave(t *testing.T) {
+	recipe := &Recipe{PreparationTime: 10, CookingTime: 25}
+	if err := recipe.AfterSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if recipe.TotalTime != 35 {
+		t.Errorf("expected a total time of 35, got %d", recipe.TotalTime)
+	}
+}
+
+func TestUpdateRecipeRequestOnlyChangesSentFields(t *testing.T) {
+	price := 0.0
+	enabled := false
+	changes := UpdateRecipeRequest{Price: &price, CommentsEnabled: &enabled}.Changes()
+	
+	if len(changes) != 2 {
+		t.Fatalf("expected only the sent fields, got %v", changes)
+	}
+	if changes["price"] != 0.0 || changes["comments_enabled"] != false {
+		t.Errorf("expected zero values to be kept, got %v", changes)
+	}
+	
+	if changes := (UpdateRecipeRequest{}).Changes(); len(changes) != 0 {
+		t.Errorf("expected an empty request to change nothing, got %v", changes)
+	}
+}
+
+func TestUnloadedRelationsSerializeAsEmptyArrays(t *testing.T) {
+	var recipe Recipe
+	if err := recipe.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+	var comment Comment
+	if err := comment.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		value  interface{}
+		fields []string
+	}{
+		{recipe, []string{"ingredients", "steps", "images", "comments", "allergens", "recipes"}},
+		{comment, []string{"ingredients", "steps", "recipes"}},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range tt.fields {
+			if !strings.Contains(string(data), `"`+field+`":[]`) {
+				t.Errorf("expected %q to be an empty array in %s", field, data)
+			}
+		}
+	}
+}
+
+func TestDatabaseRejectsUnknownDifficulty(t *testing.T) {
+	db := testdb.Open(t, "models_test")
+	
+	user := User{Email: "cook@example.com", Username: "cook", PasswordHash: "unused"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	category := Category{Name: "Soups"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	recipe := func(difficulty string) *Recipe {
+		return &Recipe{Title: "Lentil Soup", PreparationTime: 10, Servings: 2, DifficultyLevel: difficulty,
+			CategoryID: category.ID, UserID: user.ID, Allergens: []string{}}
+	}
+	
+	if err := db.Create(recipe("impossible")).Error; err == nil {
+		t.Error("expected inserting an unknown difficulty to fail")
+	}
+	
+	valid := recipe(DifficultyHard)
+	if err := db.Create(valid).Error; err != nil {
+		t.Fatal(err)
+	}
+	err := db.Exec("UPDATE recipes SET difficulty_level = 'extreme' WHERE id = ?", valid.ID).Error
+	if err == nil {
+		t.Error("expected updating to an unknown difficulty to fail")
+	}
+}
\ No newline at end of file
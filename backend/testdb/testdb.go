@@ -0,0 +1,160 @@
+// Package testdb connects tests to the Postgres database named by
+// TEST_DATABASE_URL. Tests that need a database are skipped when it isn't set.
+package testdb
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	
+	"food-recipes-backend/migrations"
+	
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	mu       sync.Mutex
+	prepared = make(map[string]*gorm.DB)
+)
+
+// Open returns a connection whose tables live in their own Postgres schema, so
+// test packages running at the same time don't see each other's rows. The
+// migrations are applied the first time a schema is opened and every table is
+// emptied on each call, including the categories seeded by the migrations.
+func Open(t testing.TB, schema string) *gorm.DB {
+	t.Helper()
+	
+	dsn := databaseURL(t)
+	
+	mu.Lock()
+	defer mu.Unlock()
+	
+	db, ok := prepared[schema]
+	if !ok {
+		var err error
+		if db, err = connect(dsn, schema); err != nil {
+			t.Fatal(err)
+		}
+		if err := migrations.Run(db); err != nil {
+			t.Fatal(err)
+		}
+		prepared[schema] = db
+	}
+	
+	if err := truncate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// Fresh creates an empty database named name, without extensions or tables,
+// for tests of the migrations themselves. It is dropped when the test ends.
+// The test is skipped if the database user may not create databases.
+func Fresh(t testing.TB, name string) *gorm.DB {
+	t.Helper()
+	
+	dsn := databaseURL(t)
+	base, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		base.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %q", name))
+		closeDB(base)
+	})
+	
+	if err := base.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %q", name)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := base.Exec(fmt.Sprintf("CREATE DATABASE %q", name)).Error; err != nil {
+		t.Skipf("can't create a database for the test: %v", err)
+	}
+	
+	db, err := gorm.Open(postgres.Open(withDatabase(dsn, name)), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cleanups run last in first out, so this closes before the drop
+	t.Cleanup(func() { closeDB(db) })
+	return db
+}
+
+// databaseURL returns TEST_DATABASE_URL, skipping the test when it isn't set.
+func databaseURL(t testing.TB) string {
+	t.Helper()
+	
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	return dsn
+}
+
+// connect opens dsn with search_path set to schema, which is created if
+// missing. The extensions are installed in the default schema first so every
+// test schema can use them.
+func connect(dsn, schema string) (*gorm.DB, error) {
+	base, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB(base)
+	
+	if err := migrations.EnsureExtensions(base); err != nil {
+		return nil, err
+	}
+	if err := base.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %q", schema)).Error; err != nil {
+		return nil, err
+	}
+	
+	return gorm.Open(postgres.Open(withSearchPath(dsn, schema)), &gorm.Config{Logger: logger.Discard})
+}
+
+// truncate empties every table in the connection's schema except the record
+// of applied migrations.
+func truncate(db *gorm.DB) error {
+	var tables []string
+	if err := db.Raw(`SELECT tablename FROM pg_tables
+		WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'`).Scan(&tables).Error; err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = fmt.Sprintf("%q", table)
+	}
+	return db.Exec("TRUNCATE " + strings.Join(quoted, ", ") + " CASCADE").Error
+}
+
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + url.QueryEscape(schema+",public")
+	}
+	return dsn + " search_path=" + schema + ",public"
+}
+
+func withDatabase(dsn, name string) string {
+	if target, err := url.Parse(dsn); err == nil && (target.Scheme == "postgres" || target.Scheme == "postgresql") {
+		target.Path = "/" + name
+		return target.String()
+	}
+	return dsn + " dbname=" + name
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
\ No newline at end of file
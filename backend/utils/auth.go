@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"time"
 	
 	"github.com/golang-jwt/jwt/v5"
@@ -10,14 +11,37 @@ import (
 
 var jwtSecret = []byte("your-super-secret-jwt-key")
 
+var bcryptCost = bcrypt.DefaultCost
+
+var jwtExpiry = 24 * time.Hour
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	jwt.RegisteredClaims
 }
 
+// SetBcryptCost changes the cost used by HashPassword. Lower costs speed up
+// tests, higher costs make hashes stronger.
+func SetBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	bcryptCost = cost
+	return nil
+}
+
+// SetJWTExpiry changes how long tokens from GenerateJWT stay valid.
+func SetJWTExpiry(expiry time.Duration) error {
+	if expiry <= 0 {
+		return errors.New("JWT expiry must be positive")
+	}
+	jwtExpiry = expiry
+	return nil
+}
+
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 	return string(bytes), err
 }
 
@@ -26,21 +50,26 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateJWT(userID, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateJWT signs a token for the user and returns it with its claims, so
+// callers can tell clients when it was issued and when it expires.
+func GenerateJWT(userID, email string) (string, *Claims, error) {
+	now := time.Now()
 	
 	claims := &Claims{
 		UserID: userID,
 		Email: email,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(jwtExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "food-recipes",
 		},
 	}
 	
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
 }
 
 func ValidateJWT(tokenString string) (*Claims, error) {
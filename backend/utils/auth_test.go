@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordUsesConfiguredCost(t *testing.T) {
+	defer SetBcryptCost(bcryptCost)
+	
+	if err := SetBcryptCost(5); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != 5 {
+		t.Errorf("expected a hash at cost 5, got %d", cost)
+	}
+	if !CheckPasswordHash("secret", hash) {
+		t.Error("expected the hash to match the password")
+	}
+}
+
+func TestSetBcryptCostRejectsOutOfRangeCosts(t *testing.T) {
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if err := SetBcryptCost(cost); err == nil {
+			t.Errorf("expected cost %d to be rejected", cost)
+		}
+	}
+}
+
+func TestGenerateJWTUsesConfiguredExpiry(t *testing.T) {
+	defer SetJWTExpiry(jwtExpiry)
+	
+	if err := SetJWTExpiry(2 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	token, claims, err := GenerateJWT("user-1", "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != 2*time.Hour {
+		t.Errorf("expected the token to live 2h, got %s", lifetime)
+	}
+	
+	parsed, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.ExpiresAt.Equal(claims.ExpiresAt.Time) || !parsed.IssuedAt.Equal(claims.IssuedAt.Time) {
+		t.Errorf("expected the returned claims to match the token, got %v and %v", claims.RegisteredClaims, parsed.RegisteredClaims)
+	}
+	
+	if err := SetJWTExpiry(0); err == nil {
+		t.Error("expected a zero expiry to be rejected")
+	}
+}
\ No newline at end of file
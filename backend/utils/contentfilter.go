@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrBannedWords = errors.New("content contains banned words")
+	ErrTooManyURLs = errors.New("content contains too many links")
+)
+
+var urlPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// ContentFilter screens user submitted text such as comments. Check returns
+// a descriptive error when the content should be rejected or flagged.
+type ContentFilter interface {
+	Check(content string) error
+}
+
+// WordListFilter rejects content containing banned words or more links than
+// allowed, which catches most low-effort spam.
+type WordListFilter struct {
+	bannedWords *regexp.Regexp
+	maxURLs     int
+}
+
+// NewWordListFilter builds a filter matching the banned words case-insensitively
+// as whole words. A negative maxURLs disables the link check.
+func NewWordListFilter(bannedWords []string, maxURLs int) *WordListFilter {
+	filter := &WordListFilter{maxURLs: maxURLs}
+	
+	var quoted []string
+	for _, word := range bannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) > 0 {
+		filter.bannedWords = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	}
+	
+	return filter
+}
+
+func (f *WordListFilter) Check(content string) error {
+	if f.bannedWords != nil && f.bannedWords.MatchString(content) {
+		return ErrBannedWords
+	}
+	if f.maxURLs >= 0 && len(urlPattern.FindAllStringIndex(content, -1)) > f.maxURLs {
+		return ErrTooManyURLs
+	}
+	return nil
+}
\ No newline at end of file
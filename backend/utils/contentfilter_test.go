@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWordListFilter(t *testing.T) {
+	filter := NewWordListFilter([]string{"scam", " cheap pills "}, 2)
+	
+	tests := []struct {
+		content string
+		want    error
+	}{
+		{"Lovely recipe, the sauce was perfect", nil},
+		{"Scampi works well here too", nil},
+		{"This is a SCAM", ErrBannedWords},
+		{"Buy cheap pills now", ErrBannedWords},
+		{"See https://example.com and www.example.org", nil},
+		{"Deals at http://a.example http://b.example www.c.example", ErrTooManyURLs},
+	}
+	for _, tt := range tests {
+		if err := filter.Check(tt.content); !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestWordListFilterWithoutLinkLimit(t *testing.T) {
+	filter := NewWordListFilter(nil, -1)
+	if err := filter.Check("http://a.example http://b.example http://c.example"); err != nil {
+		t.Errorf("expected links to be allowed, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidEmail       = errors.New("must be a valid email address")
+	ErrDisposableEmail    = errors.New("disposable email addresses are not allowed")
+	ErrUndeliverableEmail = errors.New("email domain does not accept mail")
+)
+
+// domainLabel matches a single DNS label such as "example" or "my-domain".
+var domainLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// EmailValidator checks signup addresses more strictly than the binding
+// "email" rule: the domain must be a proper hostname with a TLD, must not be
+// on the disposable domain blocklist and, optionally, must be able to receive
+// mail according to DNS.
+type EmailValidator struct {
+	blocked   map[string]bool
+	checkMX   bool
+	mxTimeout time.Duration
+	resolver  *net.Resolver
+}
+
+// NewEmailValidator builds a validator rejecting the blocked domains and their
+// subdomains. With checkMX set the domain is looked up in DNS, giving up after
+// mxTimeout.
+func NewEmailValidator(blockedDomains []string, checkMX bool, mxTimeout time.Duration) *EmailValidator {
+	blocked := make(map[string]bool, len(blockedDomains))
+	for _, domain := range blockedDomains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			blocked[domain] = true
+		}
+	}
+	
+	return &EmailValidator{
+		blocked:   blocked,
+		checkMX:   checkMX,
+		mxTimeout: mxTimeout,
+		resolver:  net.DefaultResolver,
+	}
+}
+
+// Validate checks an already normalized (trimmed, lowercase) address.
+func (v *EmailValidator) Validate(ctx context.Context, email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return ErrInvalidEmail
+	}
+	
+	at := strings.LastIndexByte(email, '@')
+	domain := email[at+1:]
+	if !isHostname(domain) {
+		return ErrInvalidEmail
+	}
+	
+	if v.isBlocked(domain) {
+		return ErrDisposableEmail
+	}
+	
+	if v.checkMX && !v.acceptsMail(ctx, domain) {
+		return ErrUndeliverableEmail
+	}
+	return nil
+}
+
+// isBlocked reports whether domain or one of its parent domains is blocked.
+func (v *EmailValidator) isBlocked(domain string) bool {
+	for {
+		if v.blocked[domain] {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// acceptsMail looks for MX records, falling back to an address record as mail
+// servers do. Lookups that time out or fail for reasons other than the domain
+// not existing are given the benefit of the doubt, so a DNS hiccup doesn't
+// block signups.
+func (v *EmailValidator) acceptsMail(ctx context.Context, domain string) bool {
+	if v.mxTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.mxTimeout)
+		defer cancel()
+	}
+	
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		// A single "." MX is the null MX, meaning the domain takes no mail
+		return !(len(records) == 1 && records[0].Host == ".")
+	}
+	if err != nil && !isNotFound(err) {
+		return true
+	}
+	
+	if _, err := v.resolver.LookupHost(ctx, domain); err != nil {
+		return !isNotFound(err)
+	}
+	return true
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// isHostname requires at least two valid labels and an alphabetic TLD, which
+// rules out addresses like "a@b" or "user@127.0.0.1".
+func isHostname(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 || len(domain) > 253 {
+		return false
+	}
+	for _, label := range labels {
+		if !domainLabel.MatchString(label) {
+			return false
+		}
+	}
+	
+	tld := labels[len(labels)-1]
+	if strings.HasPrefix(tld, "xn--") {
+		return true
+	}
+	for _, r := range tld {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return len(tld) >= 2
+}
\ No newline at end of file
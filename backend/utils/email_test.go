@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEmailValidator(t *testing.T) {
+	v := NewEmailValidator([]string{" Mailinator.com ", ""}, false, 0)
+	
+	tests := map[string]error{
+		"cook@example.com":        nil,
+		"cook@mail.example.co.uk": nil,
+		"cook@mailinator.com":     ErrDisposableEmail,
+		"cook@eu.mailinator.com":  ErrDisposableEmail,
+		"cook@notmailinator.com":  nil,
+		"a@b":                     ErrInvalidEmail,
+		"cook@127.0.0.1":          ErrInvalidEmail,
+		"cook@example.c0m":        ErrInvalidEmail,
+		"Anna <cook@example.com>": ErrInvalidEmail,
+		"cook@-example.com":       ErrInvalidEmail,
+	}
+	for email, want := range tests {
+		if err := v.Validate(context.Background(), email); !errors.Is(err, want) {
+			t.Errorf("Validate(%q) = %v, want %v", email, err, want)
+		}
+	}
+}
\ No newline at end of file
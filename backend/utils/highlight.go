@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Highlight markers wrapped around matched terms. Everything outside the
+// markers is HTML escaped, so the result can be rendered as HTML.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// Highlight wraps every case-insensitive occurrence of term in text with the
+// highlight markers. With maxRunes > 0, long text is cut down to a snippet of
+// about that many characters around the first match, with ellipses marking
+// the cuts. It reports false if term doesn't occur in text.
+func Highlight(text, term string, maxRunes int) (string, bool) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return "", false
+	}
+	
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+	first := pattern.FindStringIndex(text)
+	if first == nil {
+		return "", false
+	}
+	
+	prefix, suffix := "", ""
+	if maxRunes > 0 && utf8.RuneCountInString(text) > maxRunes {
+		start, end := snippetBounds(text, first[0], first[1], maxRunes)
+		if start > 0 {
+			prefix = "…"
+		}
+		if end < len(text) {
+			suffix = "…"
+		}
+		text = text[start:end]
+	}
+	
+	var b strings.Builder
+	b.WriteString(prefix)
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:match[0]]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		b.WriteString(HighlightEnd)
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	b.WriteString(suffix)
+	
+	return b.String(), true
+}
+
+// snippetBounds picks byte offsets of a window of about maxRunes characters
+// around the match at [matchStart, matchEnd), starting a third of the window
+// before the match and snapping to word boundaries where possible.
+func snippetBounds(text string, matchStart, matchEnd, maxRunes int) (int, int) {
+	start := matchStart
+	for lead := maxRunes / 3; lead > 0 && start > 0; lead-- {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	
+	end := start
+	for n := 0; n < maxRunes && end < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	if end < matchEnd {
+		end = matchEnd
+	}
+	
+	// Avoid cutting words in half
+	if start > 0 {
+		if i := strings.IndexByte(text[start:matchStart], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[matchEnd:end], ' '); i >= 0 {
+			end = matchEnd + i
+		}
+	}
+	
+	return start, end
+}
\ No newline at end of file
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, term string
+		want       string
+		found      bool
+	}{
+		{"Spicy Lentil Soup", "lentil", "Spicy <mark>Lentil</mark> Soup", true},
+		{"Lentils & lentil stock", "LENTIL", "<mark>Lentil</mark>s &amp; <mark>lentil</mark> stock", true},
+		{"<b>Bold</b> soup", "soup", "&lt;b&gt;Bold&lt;/b&gt; <mark>soup</mark>", true},
+		{"Spicy Lentil Soup", "garlic", "", false},
+		{"Spicy Lentil Soup", "  ", "", false},
+	}
+	for _, tt := range tests {
+		got, found := Highlight(tt.text, tt.term, 0)
+		if got != tt.want || found != tt.found {
+			t.Errorf("Highlight(%q, %q) = %q, %v, want %q, %v", tt.text, tt.term, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestHighlightCutsLongTextToASnippet(t *testing.T) {
+	text := strings.Repeat("simmer the stock slowly ", 20) + "add the garlic " + strings.Repeat("and stir well ", 20)
+	
+	snippet, found := Highlight(text, "garlic", 60)
+	if !found {
+		t.Fatal("expected garlic to be found")
+	}
+	if !strings.Contains(snippet, HighlightStart+"garlic"+HighlightEnd) {
+		t.Errorf("expected the snippet to highlight garlic, got %q", snippet)
+	}
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") {
+		t.Errorf("expected ellipses on both cuts, got %q", snippet)
+	}
+	plain := strings.NewReplacer(HighlightStart, "", HighlightEnd, "", "…", "").Replace(snippet)
+	if n := utf8.RuneCountInString(plain); n > 60 {
+		t.Errorf("expected a snippet of at most 60 characters, got %d: %q", n, snippet)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a simple in-memory sliding window limiter keyed by an
+// arbitrary string such as a user ID or IP address.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+// A limiter with a non-positive limit or window never throttles.
+func (l *RateLimiter) Allow(key string) bool {
+	if l.limit <= 0 || l.window <= 0 {
+		return true
+	}
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+	
+	// Drop hits that fell out of the window
+	recent := l.hits[key][:0]
+	for _, hit := range l.hits[key] {
+		if hit.After(cutoff) {
+			recent = append(recent, hit)
+		}
+	}
+	
+	if len(recent) >= l.limit {
+		l.hits[key] = recent
+		return false
+	}
+	
+	l.hits[key] = append(recent, now)
+	return true
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterThrottlesPerKey(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Minute)
+	
+	for i, want := range []bool{true, true, false, false} {
+		if got := limiter.Allow("alice"); got != want {
+			t.Errorf("hit %d: Allow = %v, want %v", i+1, got, want)
+		}
+	}
+	if !limiter.Allow("bob") {
+		t.Error("expected another key to have its own budget")
+	}
+}
+
+func TestRateLimiterForgetsHitsOutsideTheWindow(t *testing.T) {
+	limiter := NewRateLimiter(1, 20*time.Millisecond)
+	
+	if !limiter.Allow("alice") || limiter.Allow("alice") {
+		t.Fatal("expected the second hit inside the window to be throttled")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !limiter.Allow("alice") {
+		t.Error("expected a hit after the window to be allowed")
+	}
+}
+
+func TestRateLimiterWithoutLimitNeverThrottles(t *testing.T) {
+	for _, limiter := range []*RateLimiter{NewRateLimiter(0, time.Minute), NewRateLimiter(1, 0)} {
+		for i := 0; i < 10; i++ {
+			if !limiter.Allow("alice") {
+				t.Fatal("expected a disabled limiter to allow every hit")
+			}
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of periodic background work. The context is cancelled when the
+// scheduler stops.
+type Job func(ctx context.Context) error
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs in the background, each on its own ticker.
+// A job that panics or fails is logged and tried again on its next tick.
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []scheduledJob
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Register adds a job that runs every interval once the scheduler is started.
+// Jobs with a non-positive interval are ignored.
+func (s *Scheduler) Register(name string, interval time.Duration, job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if interval <= 0 {
+		log.Printf("Not scheduling job %s: interval must be positive", name)
+		return
+	}
+	if s.started {
+		log.Printf("Not scheduling job %s: scheduler already started", name)
+		return
+	}
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: job})
+}
+
+// Start launches the registered jobs. Calling it more than once has no effect.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.started {
+		return
+	}
+	s.started = true
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop cancels the jobs and waits for running ones to return, or until ctx is
+// done. It reports ctx's error if the jobs didn't finish in time.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+	
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job scheduledJob) {
+	defer s.wg.Done()
+	
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+// runOnce runs a single tick of a job, recovering from panics so one broken job
+// can't take down the server.
+func (s *Scheduler) runOnce(ctx context.Context, job scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.name, r)
+		}
+	}()
+	
+	if err := job.run(ctx); err != nil {
+		log.Printf("Job %s failed: %v", job.name, err)
+	}
+}
\ No newline at end of file
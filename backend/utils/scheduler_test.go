@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobsUntilStopped(t *testing.T) {
+	s := NewScheduler()
+	var runs, panics, failures atomic.Int32
+	stopped := make(chan struct{})
+	s.Register("count", 5*time.Millisecond, func(ctx context.Context) error {
+		if runs.Add(1) == 3 {
+			// Stop waits for a running job, which sees the cancellation
+			go s.Stop(context.Background())
+			<-ctx.Done()
+			close(stopped)
+		}
+		return nil
+	})
+	s.Register("panic", 5*time.Millisecond, func(ctx context.Context) error {
+		panics.Add(1)
+		panic("broken job")
+	})
+	s.Register("fail", 5*time.Millisecond, func(ctx context.Context) error {
+		failures.Add(1)
+		return errors.New("failed")
+	})
+	s.Register("never", 0, func(ctx context.Context) error {
+		t.Error("a job without an interval must not run")
+		return nil
+	})
+	s.Start()
+	s.Start()
+	
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the job to run three times and see the stop")
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("expected the jobs to stop, got %v", err)
+	}
+	if panics.Load() < 1 || failures.Load() < 1 {
+		t.Error("expected failing jobs to keep being retried")
+	}
+	
+	total := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != total {
+		t.Error("expected no runs after Stop")
+	}
+}
+
+func TestSchedulerStopTimesOut(t *testing.T) {
+	s := NewScheduler()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	s.Register("stuck", time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	s.Start()
+	<-started
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Stop to give up on a stuck job, got %v", err)
+	}
+}
\ No newline at end of file
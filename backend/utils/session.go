@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// NewSessionID returns a random identifier for an anonymous visitor.
+func NewSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SignSession appends an HMAC of the session ID so it can be stored in a
+// cookie without clients being able to pick their own ID.
+func SignSession(id string, secret []byte) string {
+	return id + "." + sessionSignature(id, secret)
+}
+
+// VerifySession checks a value made by SignSession and returns the session ID.
+func VerifySession(value string, secret []byte) (string, bool) {
+	id, signature, found := strings.Cut(value, ".")
+	if !found || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(sessionSignature(id, secret))) {
+		return "", false
+	}
+	return id, true
+}
+
+func sessionSignature(id string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
\ No newline at end of file
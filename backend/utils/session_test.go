@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignedSessions(t *testing.T) {
+	secret := []byte("secret")
+	id, err := NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == other {
+		t.Fatal("expected session IDs to be random")
+	}
+	
+	signed := SignSession(id, secret)
+	if got, ok := VerifySession(signed, secret); !ok || got != id {
+		t.Errorf("expected %q to verify as %q, got %q", signed, id, got)
+	}
+	
+	_, signature, _ := strings.Cut(signed, ".")
+	for _, value := range []string{
+		other + "." + signature,
+		id,
+		"." + signature,
+		signed + "x",
+	} {
+		if _, ok := VerifySession(value, secret); ok {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+	if _, ok := VerifySession(signed, []byte("other secret")); ok {
+		t.Error("expected a value signed with another secret to be rejected")
+	}
+}
\ No newline at end of file
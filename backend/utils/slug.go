@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slugSeparators matches everything that isn't a letter, combining mark or
+// digit in any script, so titles like "Crème brûlée" or "ዶሮ ወጥ" keep their words.
+var slugSeparators = regexp.MustCompile(`[^\p{L}\p{M}\p{N}]+`)
+
+// maxSlugLength keeps slugs readable and leaves room for a numeric suffix. It
+// counts characters, not bytes.
+const maxSlugLength = 80
+
+// Slugify turns a title into a lowercase, hyphenated URL segment such as
+// "spicy-lentil-soup". Titles without any letters or digits give "recipe".
+func Slugify(title string) string {
+	slug := strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if runes := []rune(slug); len(runes) > maxSlugLength {
+		slug = strings.TrimRight(string(runes[:maxSlugLength]), "-")
+	}
+	if slug == "" {
+		return "recipe"
+	}
+	return slug
+}
\ No newline at end of file
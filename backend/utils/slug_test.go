@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Spicy Lentil Soup":      "spicy-lentil-soup",
+		"  Mom's -- Best Pie! ":  "mom-s-best-pie",
+		"Crème Brûlée":           "crème-brûlée",
+		"ዶሮ ወጥ":                  "ዶሮ-ወጥ",
+		"Борщ по-київськи":       "борщ-по-київськи",
+		"दाल मखनी":               "दाल-मखनी",
+		"!!!":                    "recipe",
+		"":                       "recipe",
+	}
+	for title, want := range tests {
+		if got := Slugify(title); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestSlugifyTruncatesByCharacter(t *testing.T) {
+	slug := Slugify(strings.Repeat("é", maxSlugLength+10))
+	if !utf8.ValidString(slug) {
+		t.Fatalf("slug was cut inside a character: %q", slug)
+	}
+	if n := utf8.RuneCountInString(slug); n != maxSlugLength {
+		t.Errorf("expected %d characters, got %d", maxSlugLength, n)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// durationPattern matches durations such as "15 minutes", "1.5 hrs" or
+// "10-12 min". For a range the lower bound is used, so the cook checks early.
+var durationPattern = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?)(?:\s*(?:-|–|to)\s*\d+(?:\.\d+)?)?\s*(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`)
+
+// durationJoiner matches the text allowed between two parts of one duration,
+// as in "1 hour and 30 minutes".
+var durationJoiner = regexp.MustCompile(`(?i)^\s*(?:,|and)?\s*$`)
+
+// Timer is a duration mentioned in a recipe step.
+type Timer struct {
+	Text    string `json:"text"`
+	Seconds int    `json:"seconds"`
+}
+
+// ParseTimers finds the durations mentioned in an instruction. Adjacent parts
+// such as "1 hour 30 minutes" are combined into one timer.
+func ParseTimers(text string) []Timer {
+	timers := []Timer{}
+	lastEnd := -1
+	lastStart := 0
+	
+	for _, match := range durationPattern.FindAllStringSubmatchIndex(text, -1) {
+		amount, err := strconv.ParseFloat(text[match[2]:match[3]], 64)
+		if err != nil {
+			continue
+		}
+		seconds := int(amount * unitSeconds(text[match[4]:match[5]]))
+		if seconds <= 0 {
+			continue
+		}
+		
+		if lastEnd >= 0 && durationJoiner.MatchString(text[lastEnd:match[0]]) {
+			last := &timers[len(timers)-1]
+			last.Seconds += seconds
+			last.Text = text[lastStart:match[1]]
+		} else {
+			timers = append(timers, Timer{Text: text[match[0]:match[1]], Seconds: seconds})
+			lastStart = match[0]
+		}
+		lastEnd = match[1]
+	}
+	
+	return timers
+}
+
+func unitSeconds(unit string) float64 {
+	unit = strings.ToLower(unit)
+	switch {
+	case strings.HasPrefix(unit, "h"):
+		return 3600
+	case strings.HasPrefix(unit, "m"):
+		return 60
+	default:
+		return 1
+	}
+}
\ No newline at end of file
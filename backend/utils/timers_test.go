@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTimers(t *testing.T) {
+	tests := map[string][]int{
+		"Simmer for 15 minutes":                     {900},
+		"Bake 1 hour 30 minutes, then rest 10 mins": {5400, 600},
+		"Roast for 1 hour and 15 minutes":           {4500},
+		"Boil 10-12 min":                            {600},
+		"Rest for 1.5 hrs":                          {5400},
+		"Blitz for 30 seconds":                      {30},
+		"Season to taste":                           {},
+	}
+	for text, want := range tests {
+		var got []int
+		for _, timer := range ParseTimers(text) {
+			got = append(got, timer.Seconds)
+		}
+		if len(got) == 0 && len(want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseTimers(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestParseTimersKeepsTheMatchedText(t *testing.T) {
+	timers := ParseTimers("Bake 1 hour and 30 minutes until golden")
+	if len(timers) != 1 || timers[0].Text != "1 hour and 30 minutes" {
+		t.Errorf("unexpected timers %+v", timers)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Measurement systems accepted by ConvertAmount and ConvertTemperatures.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// unitConversion converts one unit into its counterpart in the other system.
+type unitConversion struct {
+	system string  // system the unit belongs to
+	target string  // unit it converts to
+	factor float64 // multiplier from this unit to target
+}
+
+var unitConversions = map[string]unitConversion{
+	"g":   {UnitsMetric, "oz", 1 / 28.3495},
+	"kg":  {UnitsMetric, "lb", 2.20462},
+	"ml":  {UnitsMetric, "cup", 1 / 236.588},
+	"l":   {UnitsMetric, "cup", 4.22675},
+	"oz":  {UnitsImperial, "g", 28.3495},
+	"lb":  {UnitsImperial, "g", 453.592},
+	"cup": {UnitsImperial, "ml", 236.588},
+}
+
+var unitAliases = map[string]string{
+	"g": "g", "gr": "g", "gram": "g", "grams": "g",
+	"kg": "kg", "kilogram": "kg", "kilograms": "kg",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"cup": "cup", "cups": "cup",
+}
+
+// amountPattern splits a quantity like "200 g" or "1 1/2 cups" into its number
+// and unit.
+var amountPattern = regexp.MustCompile(`^\s*(\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?)\s*([a-zA-Z]*)\.?\s*$`)
+
+// rangePattern matches a quantity that may be a range, like "1-2 cups" or
+// "2 to 3".
+var rangePattern = regexp.MustCompile(`^\s*(\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?)(?:\s*(?:-|–|to)\s*(\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?))?\s*([a-zA-Z]*)\.?\s*$`)
+
+// temperaturePattern matches oven temperatures such as "180°C", "350 °F" or
+// "200 degrees C".
+var temperaturePattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:°\s*|degrees?\s+)([CF])\b`)
+
+// ConvertAmount converts an ingredient quantity and unit to the given system.
+// The unit may also be given inside the quantity ("200 g") when unit is empty.
+// ok is false when the amount is already in that system or isn't recognized,
+// in which case the caller should leave it untouched.
+func ConvertAmount(quantity, unit, system string) (string, string, bool) {
+	number := quantity
+	if strings.TrimSpace(unit) == "" {
+		match := amountPattern.FindStringSubmatch(quantity)
+		if match == nil {
+			return quantity, unit, false
+		}
+		number, unit = match[1], match[2]
+	}
+	
+	conversion, known := unitConversions[unitAliases[strings.ToLower(strings.TrimSpace(unit))]]
+	if !known || conversion.system == system {
+		return quantity, unit, false
+	}
+	
+	value, err := parseQuantity(number)
+	if err != nil {
+		return quantity, unit, false
+	}
+	
+	return formatAmount(value * conversion.factor), conversion.target, true
+}
+
+// ConvertTemperatures rewrites the Celsius or Fahrenheit temperatures in text
+// to the given system and reports whether anything changed.
+func ConvertTemperatures(text, system string) (string, bool) {
+	changed := false
+	converted := temperaturePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := temperaturePattern.FindStringSubmatch(match)
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return match
+		}
+		
+		scale := strings.ToUpper(parts[2])
+		switch {
+		case scale == "C" && system == UnitsImperial:
+			changed = true
+			return fmt.Sprintf("%.0f°F", value*9/5+32)
+		case scale == "F" && system == UnitsMetric:
+			changed = true
+			return fmt.Sprintf("%.0f°C", (value-32)*5/9)
+		}
+		return match
+	})
+	return converted, changed
+}
+
+// ParseAmount reads a quantity such as "2", "1/2", "1 1/2" or "1-2 cups". For
+// a range, high holds the upper bound; otherwise it equals low. unit is any unit
+// written after the number. ok is false for text like "a pinch".
+func ParseAmount(quantity string) (low, high float64, unit string, ok bool) {
+	match := rangePattern.FindStringSubmatch(quantity)
+	if match == nil {
+		return 0, 0, "", false
+	}
+	
+	low, err := parseQuantity(match[1])
+	if err != nil {
+		return 0, 0, "", false
+	}
+	high = low
+	if match[2] != "" {
+		if high, err = parseQuantity(match[2]); err != nil || high < low {
+			return 0, 0, "", false
+		}
+	}
+	return low, high, match[3], true
+}
+
+// parseQuantity reads whole numbers, decimals, fractions and mixed numbers.
+func parseQuantity(s string) (float64, error) {
+	total := 0.0
+	for _, field := range strings.Fields(s) {
+		if numerator, denominator, found := strings.Cut(field, "/"); found {
+			n, err := strconv.ParseFloat(numerator, 64)
+			if err != nil {
+				return 0, err
+			}
+			d, err := strconv.ParseFloat(denominator, 64)
+			if err != nil || d == 0 {
+				return 0, fmt.Errorf("invalid fraction %q", field)
+			}
+			total += n / d
+			continue
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += value
+	}
+	return total, nil
+}
+
+// formatAmount rounds converted amounts to a precision that reads naturally.
+func formatAmount(value float64) string {
+	switch {
+	case value >= 100:
+		value = math.Round(value)
+	case value >= 10:
+		value = math.Round(value*10) / 10
+	default:
+		value = math.Round(value*100) / 100
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
\ No newline at end of file
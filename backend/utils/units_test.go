@@ -0,0 +1,69 @@
+package utils
+
+import "testing"
+
+func TestConvertAmount(t *testing.T) {
+	tests := []struct {
+		quantity, unit, system string
+		wantQuantity, wantUnit string
+		ok                     bool
+	}{
+		{"200 g", "", UnitsImperial, "7.05", "oz", true},
+		{"200", "grams", UnitsImperial, "7.05", "oz", true},
+		{"1 1/2", "cups", UnitsMetric, "355", "ml", true},
+		{"2 lb", "", UnitsMetric, "907", "g", true},
+		{"2 cloves", "", UnitsImperial, "2 cloves", "", false},
+		{"200 g", "", UnitsMetric, "200 g", "", false},
+		{"a pinch", "", UnitsImperial, "a pinch", "", false},
+	}
+	for _, tt := range tests {
+		quantity, unit, ok := ConvertAmount(tt.quantity, tt.unit, tt.system)
+		if quantity != tt.wantQuantity || ok != tt.ok || (ok && unit != tt.wantUnit) {
+			t.Errorf("ConvertAmount(%q, %q, %s) = %q, %q, %v, want %q, %q, %v",
+				tt.quantity, tt.unit, tt.system, quantity, unit, ok, tt.wantQuantity, tt.wantUnit, tt.ok)
+		}
+	}
+}
+
+func TestConvertTemperatures(t *testing.T) {
+	tests := []struct {
+		text, system, want string
+		changed            bool
+	}{
+		{"Bake at 180°C for 20 minutes", UnitsImperial, "Bake at 356°F for 20 minutes", true},
+		{"Preheat to 350 degrees F", UnitsMetric, "Preheat to 177°C", true},
+		{"Bake at 180°C", UnitsMetric, "Bake at 180°C", false},
+		{"Stir well", UnitsImperial, "Stir well", false},
+	}
+	for _, tt := range tests {
+		got, changed := ConvertTemperatures(tt.text, tt.system)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("ConvertTemperatures(%q, %s) = %q, %v, want %q, %v", tt.text, tt.system, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		quantity  string
+		low, high float64
+		unit      string
+		ok        bool
+	}{
+		{"1/2", 0.5, 0.5, "", true},
+		{"1 1/2 tbsp", 1.5, 1.5, "tbsp", true},
+		{"1-2 cups", 1, 2, "cups", true},
+		{"2 to 3", 2, 3, "", true},
+		{"0.25 kg", 0.25, 0.25, "kg", true},
+		{"3-1", 0, 0, "", false},
+		{"1/0", 0, 0, "", false},
+		{"a pinch", 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		low, high, unit, ok := ParseAmount(tt.quantity)
+		if low != tt.low || high != tt.high || unit != tt.unit || ok != tt.ok {
+			t.Errorf("ParseAmount(%q) = %v, %v, %q, %v, want %v, %v, %q, %v",
+				tt.quantity, low, high, unit, ok, tt.low, tt.high, tt.unit, tt.ok)
+		}
+	}
+}
\ No newline at end of file